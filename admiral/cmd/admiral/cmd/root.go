@@ -63,6 +63,15 @@ func GetRootCmd(args []string) *cobra.Command {
 
 			wg := new(sync.WaitGroup)
 			wg.Add(2)
+			if len(params.WebhookCertPath) > 0 && len(params.WebhookKeyPath) > 0 {
+				webhookService := server.Service{}
+				webhookRoutes := routes.NewWebhookServer(&opts)
+				wg.Add(1)
+				go func() {
+					webhookService.StartTLS(ctx, params.WebhookPort, params.WebhookCertPath, params.WebhookKeyPath, webhookRoutes, routes.Filter, remoteRegistry)
+					wg.Done()
+				}()
+			}
 			go func() {
 				metricsService.Start(ctx, 6900, metricRoutes, routes.Filter, remoteRegistry)
 				wg.Done()
@@ -91,6 +100,8 @@ func GetRootCmd(args []string) *cobra.Command {
 		"Use a Kubernetes configuration file instead of in-cluster configuration")
 	rootCmd.PersistentFlags().BoolVar(&params.ArgoRolloutsEnabled, "argo_rollouts", false,
 		"Use argo rollout configurations")
+	rootCmd.PersistentFlags().BoolVar(&params.StatefulSetsEnabled, "statefulsets", false,
+		"Use statefulset configurations")
 	rootCmd.PersistentFlags().StringVar(&params.ClusterRegistriesNamespace, "secret_namespace", "admiral",
 		"Namespace to monitor for secrets defaults to admiral-secrets")
 	rootCmd.PersistentFlags().StringVar(&params.DependenciesNamespace, "dependency_namespace", "admiral",
@@ -136,6 +147,43 @@ func GetRootCmd(args []string) *cobra.Command {
 	rootCmd.PersistentFlags().StringVar(&params.AdmiralStateCheckerName, "admiral_state_checker_name", "NoOPStateChecker", "The value of the admiral_state_checker_name label to configure the DR Strategy for Admiral")
 	rootCmd.PersistentFlags().StringVar(&params.DRStateStoreConfigPath, "dr_state_store_config_path", "", "Location of config file which has details for data store. Ex:- Dynamo DB connection details")
 	rootCmd.PersistentFlags().StringVar(&params.ServiceEntryIPPrefix, "se_ip_prefix", "240.0", "IP prefix for the auto generated IPs for service entries. Only the first two octets:  Eg- 240.0")
+	rootCmd.PersistentFlags().Float32Var(&params.FullReconcileQPS, "full_reconcile_qps", common.DefaultFullReconcileQPS,
+		"Maximum number of identity/env pairs reconciled per second when Admiral moves from read-only to read-write")
+	rootCmd.PersistentFlags().StringVar(&params.ApiTokenPath, "api_token_path", "",
+		"Location of a file with the bearer token required by the authenticated APIs, like PUT /admiral/state. The authenticated APIs are disabled when not set")
+	rootCmd.PersistentFlags().DurationVar(&params.OrphanSweepInterval, "orphan_sweep_interval", 30*time.Minute,
+		"Interval for looking up the ServiceEntries, DestinationRules and VirtualServices created by Admiral in the sync namespace that Admiral doesn't want anymore. Set to 0 to disable the sweep")
+	rootCmd.PersistentFlags().DurationVar(&params.OrphanGracePeriod, "orphan_grace_period", time.Hour,
+		"Time an object must stay orphaned before the sweep deletes it")
+	rootCmd.PersistentFlags().BoolVar(&params.OrphanSweepDryRun, "orphan_sweep_dry_run", true,
+		"Only log and count the orphaned objects found by the sweep, without deleting them. Set to false to delete the orphaned objects")
+	rootCmd.PersistentFlags().StringToStringVar(&params.ClusterLocalityOverrides, "cluster_locality_overrides", map[string]string{},
+		"Locality to use for a cluster instead of the locality of most of its nodes, as cluster=region/zone/subzone pairs. Ex: cluster1=us-west-2,cluster2=us-east-2/us-east-2a")
+	rootCmd.PersistentFlags().StringToStringVar(&params.ClusterGatewaySelectors, "cluster_gateway_selectors", map[string]string{},
+		"Label selector of the east west gateways of a cluster in istio-system, used instead of app=<gateway_app>, as cluster=selector pairs. Ex: cluster1=istio=eastwestgateway")
+	rootCmd.PersistentFlags().DurationVar(&params.ReconcileDebounce, "reconcile_debounce", time.Second,
+		"Time an identity/env pair waits in the reconcile queue after its first workload, service or GTP event, the events received meanwhile are coalesced into a single reconcile. Set to 0 to reconcile on every event")
+	rootCmd.PersistentFlags().Float32Var(&params.ReconcileQPS, "reconcile_qps", common.DefaultReconcileQPS,
+		"Maximum number of identity/env pairs reconciled per second from the reconcile queue")
+	rootCmd.PersistentFlags().IntVar(&params.ReconcileWorkers, "reconcile_workers", common.DefaultReconcileWorkers,
+		"Number of identity/env pairs reconciled concurrently from the reconcile queue")
+	rootCmd.PersistentFlags().IntVar(&params.ClusterWriteWorkers, "cluster_write_workers", common.DefaultClusterWriteWorkers,
+		"Number of clusters the ServiceEntries and DestinationRules of an identity are written to concurrently")
+	rootCmd.PersistentFlags().DurationVar(&params.ClusterWriteTimeout, "cluster_write_timeout", common.DefaultClusterWriteTimeout,
+		"Time given to a cluster to write the ServiceEntries and DestinationRules of an identity, the writes that didn't complete are reported as failed and retried")
+	rootCmd.PersistentFlags().BoolVar(&params.DryRun, "dry_run", false,
+		"Compute the Istio configuration without writing it to the clusters, the changes Admiral would have made are listed by GET /pendingchanges")
+	rootCmd.PersistentFlags().Float32Var(&params.StatusUpdateQPS, "status_update_qps", common.DefaultStatusUpdateQPS,
+		"Maximum number of GlobalTrafficPolicy and Dependency status updates written per second. Set to 0 to disable the status updates")
+	rootCmd.PersistentFlags().IntVar(&params.WebhookPort, "webhook_port", common.DefaultWebhookPort,
+		"Port of the https server of the validating admission webhooks")
+	rootCmd.PersistentFlags().StringVar(&params.WebhookCertPath, "webhook_cert_path", "",
+		"Location of the certificate of the validating admission webhooks server. The webhooks aren't served unless both webhook_cert_path and webhook_key_path are set")
+	rootCmd.PersistentFlags().StringVar(&params.WebhookKeyPath, "webhook_key_path", "",
+		"Location of the private key of the validating admission webhooks server")
+	rootCmd.PersistentFlags().BoolVar(&params.RejectUnknownDestinations, "webhook_reject_unknown_destinations", false,
+		"Reject the dependency records with a destination that isn't the identity of any workload known to Admiral, instead of admitting them with a warning. "+
+			"The destinations are only checked once the workloads of every cluster have been listed")
 
 	return rootCmd
 }
@@ -1,12 +1,19 @@
 package filters
 
 import (
+	"bytes"
+	"crypto/subtle"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"strings"
 	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
 )
 
+const bearerPrefix = "Bearer "
+
 /*
  * func for the "middleware" or filters for example Access logging or processing Authn/z
  */
@@ -29,13 +36,36 @@ func Logger(inner http.Handler, name string) http.Handler {
 	})
 }
 
+/*
+ * Auth expects the bearer token stored in the file passed with --api_token_path in the Authorization header.
+ * The token file is read on every request so it can be rotated without restarting Admiral.
+ * Requests are rejected when no token is configured.
+ */
 func Auth(inner http.Handler, name string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
 		log.Printf(
 			"Auth Logger for endpoint %s", name,
 		)
-		//TODO implement authnz
+
+		tokenPath := common.GetApiTokenPath()
+		if tokenPath == "" {
+			http.Error(w, "Authenticated APIs are disabled as no api token is configured", http.StatusForbidden)
+			return
+		}
+		token, err := ioutil.ReadFile(tokenPath)
+		if err != nil || len(bytes.TrimSpace(token)) == 0 {
+			log.Printf("Failed to read api token from %s: %v", tokenPath, err)
+			http.Error(w, "Failed to read api token", http.StatusInternalServerError)
+			return
+		}
+		authorization := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authorization, bearerPrefix) ||
+			subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(authorization, bearerPrefix)), bytes.TrimSpace(token)) != 1 {
+			log.Printf("Unauthorized request for endpoint %s from %s", name, r.RemoteAddr)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
 
 		inner.ServeHTTP(w, r)
 
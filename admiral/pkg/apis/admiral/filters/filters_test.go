@@ -0,0 +1,82 @@
+package filters
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+)
+
+func TestAuth(t *testing.T) {
+	tokenFile, err := ioutil.TempFile("", "admiral-api-token")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.Remove(tokenFile.Name())
+	_, err = tokenFile.WriteString("secret-token\n")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	tokenFile.Close()
+
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := Auth(inner, "test")
+	common.InitializeConfig(common.AdmiralParams{})
+
+	testCases := []struct {
+		name          string
+		tokenPath     string
+		authorization string
+		statusCode    int
+	}{
+		{
+			name:          "forbidden when no token is configured",
+			tokenPath:     "",
+			authorization: "Bearer secret-token",
+			statusCode:    http.StatusForbidden,
+		},
+		{
+			name:          "unauthorized without a token",
+			tokenPath:     tokenFile.Name(),
+			authorization: "",
+			statusCode:    http.StatusUnauthorized,
+		},
+		{
+			name:          "unauthorized with a wrong token",
+			tokenPath:     tokenFile.Name(),
+			authorization: "Bearer wrong-token",
+			statusCode:    http.StatusUnauthorized,
+		},
+		{
+			name:          "unauthorized without the bearer scheme",
+			tokenPath:     tokenFile.Name(),
+			authorization: "secret-token",
+			statusCode:    http.StatusUnauthorized,
+		},
+		{
+			name:          "success with the configured token",
+			tokenPath:     tokenFile.Name(),
+			authorization: "Bearer secret-token",
+			statusCode:    http.StatusOK,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			common.SetApiTokenPath(c.tokenPath)
+			r := httptest.NewRequest("PUT", "https://admiral.com/admiral/state", nil)
+			if c.authorization != "" {
+				r.Header.Set("Authorization", c.authorization)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != c.statusCode {
+				t.Errorf("Status code mismatch. Got %v, want %v", w.Code, c.statusCode)
+			}
+		})
+	}
+}
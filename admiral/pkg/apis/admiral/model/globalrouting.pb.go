@@ -28,16 +28,21 @@ const (
 	//remote locality
 	TrafficPolicy_TOPOLOGY TrafficPolicy_LbType = 0
 	TrafficPolicy_FAILOVER TrafficPolicy_LbType = 1
+	//Traffic stays in the local region and moves to the first region of the targets other than the local one
+	//when the local endpoints are ejected by outlier detection, istio fails over to a single region
+	TrafficPolicy_FAILOVER_PRIORITY TrafficPolicy_LbType = 2
 )
 
 var TrafficPolicy_LbType_name = map[int32]string{
 	0: "TOPOLOGY",
 	1: "FAILOVER",
+	2: "FAILOVER_PRIORITY",
 }
 
 var TrafficPolicy_LbType_value = map[string]int32{
-	"TOPOLOGY": 0,
-	"FAILOVER": 1,
+	"TOPOLOGY":          0,
+	"FAILOVER":          1,
+	"FAILOVER_PRIORITY": 2,
 }
 
 func (x TrafficPolicy_LbType) String() string {
@@ -242,7 +247,9 @@ type TrafficGroup struct {
 	//region for the traffic
 	Region string `protobuf:"bytes,1,opt,name=region,proto3" json:"region,omitempty"`
 	//weight for traffic this region should get.
-	Weight               int32    `protobuf:"varint,2,opt,name=weight,proto3" json:"weight,omitempty"`
+	Weight int32 `protobuf:"varint,2,opt,name=weight,proto3" json:"weight,omitempty"`
+	//OPTIONAL: zone of the region for the traffic, the weight then goes to this zone only
+	Zone                 string   `protobuf:"bytes,3,opt,name=zone,proto3" json:"zone,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -287,6 +294,13 @@ func (m *TrafficGroup) GetWeight() int32 {
 	return 0
 }
 
+func (m *TrafficGroup) GetZone() string {
+	if m != nil {
+		return m.Zone
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterEnum("admiral.global.v1alpha.TrafficPolicy_LbType", TrafficPolicy_LbType_name, TrafficPolicy_LbType_value)
 	proto.RegisterType((*GlobalTrafficPolicy)(nil), "admiral.global.v1alpha.GlobalTrafficPolicy")
@@ -299,34 +313,36 @@ func init() {
 func init() { proto.RegisterFile("globalrouting.proto", fileDescriptor_a5c0dc509add6f4f) }
 
 var fileDescriptor_a5c0dc509add6f4f = []byte{
-	// 463 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x53, 0x5d, 0x6b, 0xdb, 0x30,
-	0x14, 0x9d, 0xe3, 0xc5, 0x4b, 0x6f, 0x9b, 0xe1, 0xa9, 0xa5, 0x88, 0xb0, 0x87, 0x12, 0x3a, 0xc8,
-	0x43, 0x31, 0x2c, 0x83, 0xb1, 0x6f, 0x58, 0x69, 0x16, 0x06, 0x81, 0x04, 0x2d, 0x1b, 0x6c, 0x2f,
-	0x46, 0xb1, 0x6f, 0x5c, 0x6d, 0x8a, 0x65, 0x64, 0x39, 0x5d, 0xfe, 0xca, 0x7e, 0xe1, 0xde, 0xf6,
-	0x17, 0x86, 0x25, 0xaf, 0x6b, 0x4a, 0xc7, 0xfa, 0xa6, 0x73, 0x8e, 0xce, 0xd1, 0xbd, 0x07, 0x1b,
-	0xf6, 0x33, 0xa9, 0x16, 0x5c, 0x6a, 0x55, 0x19, 0x91, 0x67, 0x51, 0xa1, 0x95, 0x51, 0xe4, 0x90,
-	0xa7, 0x2b, 0xa1, 0xb9, 0x8c, 0x9c, 0x18, 0xad, 0x1f, 0x73, 0x59, 0x9c, 0xf3, 0xfe, 0x4f, 0x0f,
-	0xf6, 0xc7, 0x96, 0x9a, 0x6b, 0xbe, 0x5c, 0x8a, 0x64, 0xa6, 0xa4, 0x48, 0x36, 0xe4, 0x35, 0x04,
-	0x85, 0x3d, 0x51, 0xef, 0xc8, 0x1f, 0xec, 0x0e, 0x1f, 0x45, 0x37, 0x07, 0x44, 0x5b, 0x36, 0xd6,
-	0x98, 0xc8, 0x47, 0xe8, 0x94, 0x28, 0x31, 0x31, 0x4a, 0xd3, 0x96, 0x0d, 0x78, 0xfe, 0xaf, 0x80,
-	0x1b, 0x5e, 0x8f, 0x3e, 0x34, 0xde, 0x51, 0x6e, 0xf4, 0x86, 0x5d, 0x46, 0xf5, 0x5e, 0x42, 0x77,
-	0x4b, 0x22, 0x21, 0xf8, 0xdf, 0xb0, 0x9e, 0xd1, 0x1b, 0xec, 0xb0, 0xfa, 0x48, 0x0e, 0xa0, 0xbd,
-	0xe6, 0xb2, 0x42, 0xda, 0xb2, 0x9c, 0x03, 0x2f, 0x5a, 0xcf, 0xbc, 0xfe, 0x2f, 0x1f, 0xba, 0xdb,
-	0x4b, 0x1e, 0x80, 0x9f, 0xe6, 0xa5, 0x73, 0x9f, 0xb6, 0xa8, 0xc7, 0x6a, 0x48, 0xce, 0x20, 0x90,
-	0x8b, 0xf9, 0xa6, 0x70, 0x11, 0xf7, 0x87, 0x27, 0xb7, 0x5a, 0x3d, 0x9a, 0x58, 0x0f, 0x6b, 0xbc,
-	0xe4, 0x15, 0x04, 0x86, 0xeb, 0x0c, 0x0d, 0xf5, 0xed, 0xfe, 0xc7, 0xff, 0x49, 0x19, 0x6b, 0x55,
-	0x15, 0xac, 0xf1, 0x90, 0x87, 0xb0, 0x93, 0xe6, 0xe5, 0x4c, 0xe3, 0x52, 0x7c, 0xa7, 0x77, 0xed,
-	0x26, 0x7f, 0x09, 0x92, 0xc0, 0x03, 0x55, 0x19, 0x29, 0x50, 0xc7, 0x29, 0x1a, 0x4c, 0x8c, 0x50,
-	0x39, 0x6d, 0x1f, 0x79, 0x83, 0xdd, 0xe1, 0xd3, 0xdb, 0x0d, 0x3b, 0x75, 0xf6, 0xb3, 0x3f, 0x6e,
-	0x16, 0xaa, 0x6b, 0x4c, 0xef, 0x87, 0x07, 0xe1, 0xf5, 0x6b, 0xe4, 0x04, 0xc8, 0x82, 0x97, 0x18,
-	0xe3, 0x57, 0x47, 0xc4, 0x46, 0xac, 0xd0, 0x16, 0xe8, 0xb3, 0xb0, 0x56, 0x46, 0x8d, 0x30, 0x17,
-	0xab, 0xba, 0x83, 0x5e, 0xa2, 0xf2, 0x12, 0x93, 0xca, 0x88, 0x35, 0xc6, 0x19, 0x37, 0x78, 0xc1,
-	0x37, 0x31, 0x6a, 0xad, 0x74, 0x69, 0xdb, 0xed, 0x32, 0x7a, 0xe5, 0xc6, 0xd8, 0x5d, 0x18, 0x59,
-	0x9d, 0xf4, 0xa0, 0x23, 0x72, 0x83, 0x7a, 0xcd, 0x25, 0xf5, 0xed, 0x0b, 0x97, 0xb8, 0x7f, 0x0c,
-	0x81, 0xeb, 0x9b, 0xec, 0x41, 0x67, 0x3e, 0x9d, 0x4d, 0x27, 0xd3, 0xf1, 0xe7, 0xf0, 0x4e, 0x8d,
-	0xde, 0xbd, 0x7d, 0x3f, 0x99, 0x7e, 0x1a, 0xb1, 0xd0, 0xeb, 0xbf, 0x81, 0xbd, 0xab, 0xed, 0x92,
-	0x43, 0x08, 0x34, 0x66, 0x75, 0x59, 0xee, 0x83, 0x69, 0x50, 0xcd, 0x5f, 0xa0, 0xc8, 0xce, 0x8d,
-	0x9d, 0xa9, 0xcd, 0x1a, 0x74, 0x7a, 0xef, 0x4b, 0x7b, 0xa5, 0x52, 0x94, 0x8b, 0xc0, 0xfe, 0x44,
-	0x4f, 0x7e, 0x07, 0x00, 0x00, 0xff, 0xff, 0xde, 0xb6, 0x59, 0xbf, 0x5b, 0x03, 0x00, 0x00,
+	// 486 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x53, 0xc1, 0x6e, 0xd3, 0x40,
+	0x10, 0xc5, 0x71, 0x62, 0x92, 0x69, 0x83, 0xdc, 0x6d, 0xa9, 0xac, 0x88, 0x43, 0x15, 0x81, 0x94,
+	0x43, 0x65, 0x89, 0x20, 0x21, 0xa0, 0x70, 0xa0, 0x6a, 0x88, 0x22, 0x45, 0x72, 0xb4, 0x04, 0xa4,
+	0x72, 0xb1, 0x36, 0xf6, 0xc4, 0x5d, 0xd8, 0x78, 0xad, 0xf5, 0x3a, 0x25, 0x7c, 0x00, 0x1f, 0xc1,
+	0x17, 0xf2, 0x19, 0xc8, 0x6b, 0xb7, 0x34, 0x55, 0x11, 0xbd, 0xcd, 0x7b, 0x33, 0xef, 0xed, 0xcc,
+	0x93, 0x0d, 0xfb, 0x89, 0x90, 0x0b, 0x26, 0x94, 0x2c, 0x34, 0x4f, 0x13, 0x3f, 0x53, 0x52, 0x4b,
+	0x72, 0xc8, 0xe2, 0x15, 0x57, 0x4c, 0xf8, 0x55, 0xd3, 0x5f, 0x3f, 0x67, 0x22, 0xbb, 0x60, 0xfd,
+	0xdf, 0x16, 0xec, 0x8f, 0x0d, 0x35, 0x57, 0x6c, 0xb9, 0xe4, 0xd1, 0x4c, 0x0a, 0x1e, 0x6d, 0xc8,
+	0x3b, 0x70, 0x32, 0x53, 0x79, 0xd6, 0x91, 0x3d, 0xd8, 0x19, 0x3e, 0xf3, 0xef, 0x36, 0xf0, 0xb7,
+	0x64, 0xb4, 0x16, 0x91, 0x4f, 0xd0, 0xce, 0x51, 0x60, 0xa4, 0xa5, 0xf2, 0x1a, 0xc6, 0xe0, 0xf5,
+	0xbf, 0x0c, 0xee, 0x78, 0xdd, 0xff, 0x58, 0x6b, 0x47, 0xa9, 0x56, 0x1b, 0x7a, 0x6d, 0xd5, 0x3b,
+	0x81, 0xee, 0x56, 0x8b, 0xb8, 0x60, 0x7f, 0xc3, 0x72, 0x47, 0x6b, 0xd0, 0xa1, 0x65, 0x49, 0x0e,
+	0xa0, 0xb5, 0x66, 0xa2, 0x40, 0xaf, 0x61, 0xb8, 0x0a, 0xbc, 0x69, 0xbc, 0xb2, 0xfa, 0x3f, 0x9b,
+	0xd0, 0xdd, 0x3e, 0xf2, 0x00, 0xec, 0x38, 0xcd, 0x2b, 0xf5, 0x69, 0xc3, 0xb3, 0x68, 0x09, 0xc9,
+	0x19, 0x38, 0x62, 0x31, 0xdf, 0x64, 0x95, 0xc5, 0xa3, 0xe1, 0xf1, 0xbd, 0x4e, 0xf7, 0xa7, 0x46,
+	0x43, 0x6b, 0x2d, 0x79, 0x0b, 0x8e, 0x66, 0x2a, 0x41, 0xed, 0xd9, 0xe6, 0xfe, 0xa7, 0xff, 0x71,
+	0x19, 0x2b, 0x59, 0x64, 0xb4, 0xd6, 0x90, 0x27, 0xd0, 0x89, 0xd3, 0x7c, 0xa6, 0x70, 0xc9, 0xbf,
+	0x7b, 0x4d, 0x73, 0xc9, 0x5f, 0x82, 0x44, 0xb0, 0x27, 0x0b, 0x2d, 0x38, 0xaa, 0x30, 0x46, 0x8d,
+	0x91, 0xe6, 0x32, 0xf5, 0x5a, 0x47, 0xd6, 0x60, 0x67, 0xf8, 0xf2, 0x7e, 0xcb, 0x06, 0x95, 0xfc,
+	0xec, 0x4a, 0x4d, 0x5d, 0x79, 0x8b, 0xe9, 0xfd, 0xb2, 0xc0, 0xbd, 0x3d, 0x46, 0x8e, 0x81, 0x2c,
+	0x58, 0x8e, 0x21, 0x7e, 0xad, 0x88, 0x50, 0xf3, 0x15, 0x9a, 0x00, 0x6d, 0xea, 0x96, 0x9d, 0x51,
+	0xdd, 0x98, 0xf3, 0x55, 0x99, 0x41, 0x2f, 0x92, 0x69, 0x8e, 0x51, 0xa1, 0xf9, 0x1a, 0xc3, 0x84,
+	0x69, 0xbc, 0x64, 0x9b, 0x10, 0x95, 0x92, 0x2a, 0x37, 0xe9, 0x76, 0xa9, 0x77, 0x63, 0x62, 0x5c,
+	0x0d, 0x8c, 0x4c, 0x9f, 0xf4, 0xa0, 0xcd, 0x53, 0x8d, 0x6a, 0xcd, 0x84, 0x67, 0x9b, 0x17, 0xae,
+	0x71, 0xff, 0x04, 0x9c, 0x2a, 0x6f, 0xb2, 0x0b, 0xed, 0x79, 0x30, 0x0b, 0xa6, 0xc1, 0xf8, 0xdc,
+	0x7d, 0x50, 0xa2, 0x0f, 0xef, 0x27, 0xd3, 0xe0, 0xf3, 0x88, 0xba, 0x16, 0x79, 0x0c, 0x7b, 0x57,
+	0x28, 0x9c, 0xd1, 0x49, 0x40, 0x27, 0xf3, 0x73, 0xb7, 0xd1, 0xa7, 0xb0, 0x7b, 0x33, 0x74, 0x72,
+	0x08, 0x8e, 0xc2, 0xa4, 0xcc, 0xb0, 0xfa, 0x8e, 0x6a, 0x54, 0xf2, 0x97, 0xc8, 0x93, 0x0b, 0x6d,
+	0x56, 0x6d, 0xd1, 0x1a, 0x11, 0x02, 0xcd, 0x1f, 0x32, 0x45, 0xb3, 0x54, 0x87, 0x9a, 0xfa, 0xf4,
+	0xe1, 0x97, 0xd6, 0x4a, 0xc6, 0x28, 0x16, 0x8e, 0xf9, 0xdf, 0x5e, 0xfc, 0x19, 0x00, 0xa4, 0x62,
+	0x39, 0x8c, 0x86, 0x03, 0x00, 0x00,
 }
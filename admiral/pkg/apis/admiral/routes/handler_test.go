@@ -225,3 +225,139 @@ func TestGetServiceEntriesByIdentity(t *testing.T) {
 		})
 	}
 }
+
+func TestSetAdmiralState(t *testing.T) {
+	url := "https://admiral.com/admiral/state"
+	common.InitializeMetrics()
+	clusters.SetAdmiralReadOnly(clusters.ReadWriteEnabled)
+	opts := RouteOpts{}
+	testCases := []struct {
+		name             string
+		body             string
+		statusCode       int
+		expectedReadOnly bool
+	}{
+		{
+			name:             "failure with invalid body",
+			body:             "{",
+			statusCode:       400,
+			expectedReadOnly: false,
+		},
+		{
+			name:             "failure without reason",
+			body:             `{"ReadOnly": true}`,
+			statusCode:       400,
+			expectedReadOnly: false,
+		},
+		{
+			name:             "success forcing read-only",
+			body:             `{"ReadOnly": true, "Reason": "incident", "RequestedBy": "oncall"}`,
+			statusCode:       200,
+			expectedReadOnly: true,
+		},
+		{
+			name:             "success removing the override",
+			body:             `{"ReadOnly": false, "Reason": "incident resolved"}`,
+			statusCode:       200,
+			expectedReadOnly: false,
+		},
+	}
+	//Run the test for every provided case
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest("PUT", url, strings.NewReader(c.body))
+			w := httptest.NewRecorder()
+			opts.SetAdmiralState(w, r)
+			resp := w.Result()
+			if resp.StatusCode != c.statusCode {
+				t.Errorf("Status code mismatch. Got %v, want %v", resp.StatusCode, c.statusCode)
+			}
+			if clusters.IsAdmiralReadOnly() != c.expectedReadOnly {
+				t.Errorf("Read-only mismatch. Got %v, want %v", clusters.IsAdmiralReadOnly(), c.expectedReadOnly)
+			}
+		})
+	}
+
+	r := httptest.NewRequest("GET", url, nil)
+	w := httptest.NewRecorder()
+	opts.GetAdmiralState(w, r)
+	resp := w.Result()
+	assert.Equal(t, 200, resp.StatusCode)
+	var status clusters.AdmiralStateStatus
+	err := json.NewDecoder(resp.Body).Decode(&status)
+	if err != nil {
+		t.Fatalf("Failed to unmarshall response: %v", err)
+	}
+	assert.False(t, status.ReadOnly)
+	assert.False(t, status.ForcedReadOnly)
+	if len(status.Transitions) < 2 {
+		t.Fatalf("Expected the forced transitions to be recorded, got %v", status.Transitions)
+	}
+	forced := status.Transitions[len(status.Transitions)-2]
+	assert.True(t, forced.ReadOnly)
+	assert.Equal(t, clusters.ManualOverrideTransitionSource, forced.Source)
+	assert.Equal(t, "incident (requested by oncall)", forced.Reason)
+}
+
+func TestGetDriftCorrections(t *testing.T) {
+	url := "https://admiral.com/drift"
+	opts := RouteOpts{
+		RemoteRegistry: clusters.NewRemoteRegistry(nil, common.AdmiralParams{}),
+	}
+	r := httptest.NewRequest("GET", url, strings.NewReader(""))
+	w := httptest.NewRecorder()
+
+	opts.GetDriftCorrections(w, r)
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "[]", string(body))
+}
+
+func TestGetPendingChanges(t *testing.T) {
+	url := "https://admiral.com/pendingchanges"
+	opts := RouteOpts{
+		RemoteRegistry: clusters.NewRemoteRegistry(nil, common.AdmiralParams{}),
+	}
+
+	r := httptest.NewRequest("GET", url, strings.NewReader(""))
+	w := httptest.NewRecorder()
+	opts.GetPendingChanges(w, r)
+	assert.Equal(t, 404, w.Result().StatusCode)
+
+	common.SetDryRun(true)
+	defer common.SetDryRun(false)
+	r = httptest.NewRequest("GET", url, strings.NewReader(""))
+	w = httptest.NewRecorder()
+	opts.GetPendingChanges(w, r)
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "{}", string(body))
+}
+
+func TestRenderIdentityEnv(t *testing.T) {
+	url := "https://admiral.com/identity/bar/env/test/render"
+	opts := RouteOpts{
+		RemoteRegistry: clusters.NewRemoteRegistry(nil, common.AdmiralParams{}),
+	}
+
+	r := httptest.NewRequest("GET", url, strings.NewReader(""))
+	r = mux.SetURLVars(r, map[string]string{"identity": "bar"})
+	w := httptest.NewRecorder()
+	opts.RenderIdentityEnv(w, r)
+	assert.Equal(t, 400, w.Result().StatusCode)
+
+	r = httptest.NewRequest("GET", url, strings.NewReader(""))
+	r = mux.SetURLVars(r, map[string]string{"identity": "bar", "env": "test"})
+	w = httptest.NewRecorder()
+	opts.RenderIdentityEnv(w, r)
+	resp := w.Result()
+	assert.Equal(t, 200, resp.StatusCode)
+	var render clusters.IdentityRender
+	err := json.NewDecoder(resp.Body).Decode(&render)
+	assert.Nil(t, err)
+	assert.Equal(t, "bar", render.Identity)
+	assert.Equal(t, "test", render.Env)
+	assert.Empty(t, render.Clusters)
+}
@@ -28,6 +28,12 @@ type IdentityServiceEntry struct {
 	ClusterNames []string `json:"Clusters,omitempty"`
 }
 
+type AdmiralStateRequest struct {
+	ReadOnly    bool   `json:"ReadOnly"`
+	Reason      string `json:"Reason,omitempty"`
+	RequestedBy string `json:"RequestedBy,omitempty"`
+}
+
 /*
 We expect the DNS health checker to include the query param checkifreadonly with value set to true.
 The query param is used to check if the current Admiral instance is running in Active Mode or Passive Mode (also called read only mode).
@@ -46,7 +52,7 @@ func (opts *RouteOpts) ReturnSuccessGET(w http.ResponseWriter, r *http.Request)
 	if len(checkIfReadOnlyStringVal) ==0 || nil==err {
 		if checkIfReadOnlyBoolVal{
 
-			if clusters.CurrentAdmiralState.ReadOnly{
+			if clusters.IsAdmiralReadOnly(){
 				//Force fail health check if Admiral is in Readonly mode
 				w.WriteHeader(503)
 			}else {
@@ -186,3 +192,117 @@ func (opts *RouteOpts) GetServiceEntriesByIdentity(w http.ResponseWriter, r *htt
 		http.Error(w, "Identity not provided as part of the request", http.StatusBadRequest)
 	}
 }
+
+/*
+Renders the ServiceEntries and DestinationRules admiral would write for the identity and env, by cluster, along with the selected GTP
+and the reasons clusters were left out. Nothing is written to the clusters.
+*/
+func (opts *RouteOpts) RenderIdentityEnv(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	params := mux.Vars(r)
+	identity := strings.Trim(params["identity"], " ")
+	env := strings.Trim(params["env"], " ")
+
+	if identity == "" || env == "" {
+		log.Printf("Identity or env not provided as part of the request")
+		http.Error(w, "Identity and env are required as part of the request", http.StatusBadRequest)
+		return
+	}
+
+	out, err := json.Marshal(opts.RemoteRegistry.RenderIdentityEnv(identity, env))
+	if err != nil {
+		log.Printf("Failed to marshall response for RenderIdentityEnv call")
+		http.Error(w, fmt.Sprintf("Failed to marshall response for rendering identity %s in env %s", identity, env), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	_, err = w.Write(out)
+	if err != nil {
+		log.Println("failed to write resp body: ", err)
+	}
+}
+
+func (opts *RouteOpts) GetDriftCorrections(w http.ResponseWriter, r *http.Request) {
+	out, err := json.Marshal(opts.RemoteRegistry.GetDriftCorrections())
+	if err != nil {
+		log.Printf("Failed to marshall response for drift call")
+		http.Error(w, "Failed to marshall response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	_, err = w.Write(out)
+	if err != nil {
+		log.Println("failed to write resp body: ", err)
+	}
+}
+
+func (opts *RouteOpts) GetPendingChanges(w http.ResponseWriter, r *http.Request) {
+	if !common.GetDryRun() {
+		http.Error(w, "Admiral is not running with --dry_run", http.StatusNotFound)
+		return
+	}
+	out, err := json.Marshal(opts.RemoteRegistry.GetPendingChanges())
+	if err != nil {
+		log.Printf("Failed to marshall response for pending changes call")
+		http.Error(w, "Failed to marshall response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	_, err = w.Write(out)
+	if err != nil {
+		log.Println("failed to write resp body: ", err)
+	}
+}
+
+func (opts *RouteOpts) GetAdmiralState(w http.ResponseWriter, r *http.Request) {
+	writeAdmiralState(w)
+}
+
+/*
+Lets an operator force Admiral into read-only mode, for example during an incident. Setting ReadOnly to false removes the override
+and Admiral goes back to the state decided by the state checker. Every call is recorded in an audit log entry.
+*/
+func (opts *RouteOpts) SetAdmiralState(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var request AdmiralStateRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		log.Printf("Failed to unmarshall request for SetAdmiralState call: %v", err)
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(request.Reason) == "" {
+		http.Error(w, "Reason is required to change the Admiral state", http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("AUDIT op=SetAdmiralState forcedReadOnly=%v requestedBy=%q remoteAddr=%s reason=%q",
+		request.ReadOnly, request.RequestedBy, r.RemoteAddr, request.Reason)
+	reason := request.Reason
+	if request.RequestedBy != "" {
+		reason = fmt.Sprintf("%s (requested by %s)", request.Reason, request.RequestedBy)
+	}
+	clusters.ForceAdmiralReadOnly(request.ReadOnly, reason)
+
+	writeAdmiralState(w)
+}
+
+func writeAdmiralState(w http.ResponseWriter) {
+	out, err := json.Marshal(clusters.GetAdmiralStateStatus())
+	if err != nil {
+		log.Printf("Failed to marshall response for admiral state call")
+		http.Error(w, "Failed to marshall response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	_, err = w.Write(out)
+	if err != nil {
+		log.Println("failed to write resp body: ", err)
+	}
+}
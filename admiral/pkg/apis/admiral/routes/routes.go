@@ -48,6 +48,39 @@ func NewAdmiralAPIServer(opts *RouteOpts) server.Routes {
 			Pattern:     "/identity/{identity}/serviceentries",
 			HandlerFunc: opts.GetServiceEntriesByIdentity,
 		},
+		server.Route{
+			Name:        "Render the service entries and destination rules admiral would generate for a given identity and env, without writing them",
+			Method:      "GET",
+			Pattern:     "/identity/{identity}/env/{env}/render",
+			HandlerFunc: opts.RenderIdentityEnv,
+		},
+		server.Route{
+			Name:        "Get the most recent corrections of objects modified or deleted outside of admiral",
+			Method:      "GET",
+			Pattern:     "/drift",
+			HandlerFunc: opts.GetDriftCorrections,
+		},
+		server.Route{
+			Name:        "Get the changes admiral would make to the clusters when running with --dry_run, by cluster and identity",
+			Method:      "GET",
+			Pattern:     "/pendingchanges",
+			HandlerFunc: opts.GetPendingChanges,
+		},
+		server.Route{
+			Name:        "Get the read-only state of admiral and its recent transitions",
+			Method:      "GET",
+			Pattern:     "/admiral/state",
+			HandlerFunc: opts.GetAdmiralState,
+		},
+		server.Route{
+			Name:        "Force admiral into read-only mode or remove the override",
+			Method:      "PUT",
+			Pattern:     "/admiral/state",
+			HandlerFunc: opts.SetAdmiralState,
+			FilterChain: server.Filters{
+				server.Filter{HandlerFunc: filters.Auth},
+			},
+		},
 	}
 }
 
@@ -0,0 +1,122 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/server"
+	"k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//admissionResponse adds to the v1beta1 response the warnings, shown by kubectl with the api servers from 1.19
+type admissionResponse struct {
+	v1beta1.AdmissionResponse
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+//admissionReview is the v1beta1 review, its json is the same as the admission.k8s.io/v1 one
+type admissionReview struct {
+	metav1.TypeMeta `json:",inline"`
+	Request         *v1beta1.AdmissionRequest `json:"request,omitempty"`
+	Response        *admissionResponse        `json:"response,omitempty"`
+}
+
+//returns the reasons to reject the object of an admission request and the warnings to show to its author
+type admissionValidator func(request *v1beta1.AdmissionRequest) ([]string, []string, error)
+
+func NewWebhookServer(opts *RouteOpts) server.Routes {
+	return server.Routes{
+		server.Route{
+			Name:        "Validate a global traffic policy before it's admitted",
+			Method:      "POST",
+			Pattern:     "/validate/globaltrafficpolicy",
+			HandlerFunc: opts.ValidateGlobalTrafficPolicy,
+		},
+		server.Route{
+			Name:        "Validate a dependency record before it's admitted",
+			Method:      "POST",
+			Pattern:     "/validate/dependency",
+			HandlerFunc: opts.ValidateDependency,
+		},
+	}
+}
+
+func (opts *RouteOpts) ValidateGlobalTrafficPolicy(w http.ResponseWriter, r *http.Request) {
+	admit(w, r, func(request *v1beta1.AdmissionRequest) ([]string, []string, error) {
+		gtp := &v1.GlobalTrafficPolicy{}
+		if err := json.Unmarshal(request.Object.Raw, gtp); err != nil {
+			return nil, nil, err
+		}
+		errs, warnings := opts.RemoteRegistry.ValidateGlobalTrafficPolicy(gtp)
+		return errs, warnings, nil
+	})
+}
+
+func (opts *RouteOpts) ValidateDependency(w http.ResponseWriter, r *http.Request) {
+	admit(w, r, func(request *v1beta1.AdmissionRequest) ([]string, []string, error) {
+		dependency := &v1.Dependency{}
+		if err := json.Unmarshal(request.Object.Raw, dependency); err != nil {
+			return nil, nil, err
+		}
+		errs, warnings := opts.RemoteRegistry.ValidateDependency(dependency)
+		return errs, warnings, nil
+	})
+}
+
+/*
+admit answers the admission review in the request body with the result of the validator. The deletes are always allowed.
+The response has the apiVersion and kind of the review it answers, so the webhook can be registered with both admissionReviewVersions.
+*/
+func admit(w http.ResponseWriter, r *http.Request, validate admissionValidator) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "can't read body", http.StatusBadRequest)
+		return
+	}
+	review := admissionReview{}
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		http.Error(w, fmt.Sprintf("invalid admission review: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	request := review.Request
+	response := &admissionResponse{AdmissionResponse: v1beta1.AdmissionResponse{UID: request.UID, Allowed: true}}
+	if request.Operation != v1beta1.Delete {
+		errs, warnings, err := validate(request)
+		if err != nil {
+			errs = []string{fmt.Sprintf("can't decode object: %v", err)}
+		}
+		if len(errs) > 0 {
+			message := fmt.Sprintf("%s %s/%s is invalid: %s", request.Kind.Kind, request.Namespace, request.Name, strings.Join(errs, "; "))
+			log.Printf("Rejecting %s", message)
+			response.Allowed = false
+			response.Result = &metav1.Status{
+				Status:  metav1.StatusFailure,
+				Reason:  metav1.StatusReasonInvalid,
+				Code:    http.StatusUnprocessableEntity,
+				Message: message,
+			}
+		}
+		response.Warnings = warnings
+	}
+
+	review.Request = nil
+	review.Response = response
+	out, err := json.Marshal(review)
+	if err != nil {
+		log.Printf("Error marshalling admission review: %v", err)
+		http.Error(w, "can't marshal admission review", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, writeErr := w.Write(out)
+	if writeErr != nil {
+		log.Printf("Error writing body: %v", writeErr)
+	}
+}
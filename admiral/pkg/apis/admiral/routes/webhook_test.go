@@ -0,0 +1,140 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/model"
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/clusters"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestValidateGlobalTrafficPolicy(t *testing.T) {
+	opts := RouteOpts{RemoteRegistry: clusters.NewRemoteRegistry(nil, common.AdmiralParams{})}
+
+	newReview := func(operation v1beta1.Operation, gtp *v1.GlobalTrafficPolicy) []byte {
+		raw, _ := json.Marshal(gtp)
+		review := admissionReview{
+			TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+			Request: &v1beta1.AdmissionRequest{
+				UID:       "123",
+				Kind:      metav1.GroupVersionKind{Group: "admiral.io", Version: "v1", Kind: "GlobalTrafficPolicy"},
+				Namespace: "bar-ns",
+				Name:      "bar-gtp",
+				Operation: operation,
+				Object:    runtime.RawExtension{Raw: raw},
+			},
+		}
+		body, _ := json.Marshal(review)
+		return body
+	}
+	invalid := &v1.GlobalTrafficPolicy{ObjectMeta: metav1.ObjectMeta{Name: "bar-gtp", Namespace: "bar-ns"}}
+	deprecated := &v1.GlobalTrafficPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "bar-gtp", Namespace: "bar-ns"},
+		Spec: model.GlobalTrafficPolicy{
+			Selector: map[string]string{common.GetGlobalTrafficDeploymentLabel(): "bar"},
+			Policy:   []*model.TrafficPolicy{{Dns: "bar.global"}},
+		},
+	}
+
+	testCases := []struct {
+		name            string
+		body            []byte
+		statusCode      int
+		allowed         bool
+		messageContains string
+		warnings        int
+	}{
+		{
+			name:            "invalid GTP is rejected",
+			body:            newReview(v1beta1.Create, invalid),
+			statusCode:      200,
+			messageContains: "GlobalTrafficPolicy bar-ns/bar-gtp is invalid: spec.selector is empty",
+		},
+		{
+			name:       "deleting an invalid GTP is allowed",
+			body:       newReview(v1beta1.Delete, invalid),
+			statusCode: 200,
+			allowed:    true,
+		},
+		{
+			name:       "deprecated dns field is allowed with a warning",
+			body:       newReview(v1beta1.Update, deprecated),
+			statusCode: 200,
+			allowed:    true,
+			warnings:   1,
+		},
+		{
+			name:       "body that isn't an admission review",
+			body:       []byte("{"),
+			statusCode: 400,
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "https://admiral.com/validate/globaltrafficpolicy", bytes.NewReader(c.body))
+			w := httptest.NewRecorder()
+			opts.ValidateGlobalTrafficPolicy(w, r)
+			resp := w.Result()
+			assert.Equal(t, c.statusCode, resp.StatusCode)
+			if resp.StatusCode != 200 {
+				return
+			}
+			review := admissionReview{}
+			assert.Nil(t, json.NewDecoder(resp.Body).Decode(&review))
+			assert.Equal(t, "admission.k8s.io/v1", review.APIVersion)
+			assert.Equal(t, "123", string(review.Response.UID))
+			assert.Equal(t, c.allowed, review.Response.Allowed)
+			if len(c.messageContains) > 0 {
+				assert.True(t, strings.Contains(review.Response.Result.Message, c.messageContains), review.Response.Result.Message)
+			}
+			assert.Equal(t, c.warnings, len(review.Response.Warnings))
+		})
+	}
+}
+
+func TestValidateDependency(t *testing.T) {
+	rr := clusters.NewRemoteRegistry(nil, common.AdmiralParams{})
+	//a cluster without workload controllers has nothing left to list, so the destinations are checked
+	rr.PutRemoteController("cluster1", &clusters.RemoteController{ClusterID: "cluster1"})
+	rr.AdmiralCache.IdentityClusterCache.Put("bar", "cluster1", "cluster1")
+	opts := RouteOpts{RemoteRegistry: rr}
+
+	dependency := &v1.Dependency{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo-dep", Namespace: "admiral"},
+		Spec:       model.Dependency{Source: "foo", IdentityLabel: "identity", Destinations: []string{"bar", "baz"}},
+	}
+	raw, _ := json.Marshal(dependency)
+	body, _ := json.Marshal(admissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1beta1", Kind: "AdmissionReview"},
+		Request: &v1beta1.AdmissionRequest{
+			UID:       "456",
+			Kind:      metav1.GroupVersionKind{Group: "admiral.io", Version: "v1", Kind: "Dependency"},
+			Namespace: "admiral",
+			Name:      "foo-dep",
+			Operation: v1beta1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	})
+
+	r := httptest.NewRequest("POST", "https://admiral.com/validate/dependency", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	opts.ValidateDependency(w, r)
+	resp := w.Result()
+	assert.Equal(t, 200, resp.StatusCode)
+
+	review := admissionReview{}
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&review))
+	assert.Equal(t, "456", string(review.Response.UID))
+	assert.True(t, review.Response.Allowed)
+	assert.Equal(t, []string{"spec.destinations[1] baz isn't the identity of any workload known to Admiral"}, review.Response.Warnings)
+}
@@ -40,6 +40,25 @@ type Filters []Filter
 
 func (s *Service) Start(ctx context.Context, port int, routes Routes, filter []Filter, remoteRegistry *clusters.RemoteRegistry) {
 
+	s.init(ctx, port, routes, filter, remoteRegistry)
+
+	log.Printf("Starting server on port=%d", port)
+	log.Fatalln(s.server.ListenAndServe())
+
+}
+
+//StartTLS serves the routes over https with the certificate and key at the given paths, the files are read once at startup
+func (s *Service) StartTLS(ctx context.Context, port int, certPath string, keyPath string, routes Routes, filter []Filter, remoteRegistry *clusters.RemoteRegistry) {
+
+	s.init(ctx, port, routes, filter, remoteRegistry)
+
+	log.Printf("Starting tls server on port=%d", port)
+	log.Fatalln(s.server.ListenAndServeTLS(certPath, keyPath))
+
+}
+
+func (s *Service) init(ctx context.Context, port int, routes Routes, filter []Filter, remoteRegistry *clusters.RemoteRegistry) {
+
 	s.ctx = ctx
 	s.Port = port
 	s.remoteRegistry = remoteRegistry
@@ -49,10 +68,6 @@ func (s *Service) Start(ctx context.Context, port int, routes Routes, filter []F
 	router := s.newRouter(routes, filter)
 
 	s.server = http.Server{Addr: ":" + strconv.Itoa(port), Handler: router}
-
-	log.Printf("Starting server on port=%d", port)
-	log.Fatalln(s.server.ListenAndServe())
-
 }
 
 func (s *Service) newRouter(routes Routes, filter []Filter) *mux.Router {
@@ -63,6 +78,10 @@ func (s *Service) newRouter(routes Routes, filter []Filter) *mux.Router {
 		var handler http.Handler
 		handler = route.HandlerFunc
 
+		for _, filter := range route.FilterChain {
+			handler = filter.HandlerFunc(handler, route.Name)
+		}
+
 		for _, filter := range filter {
 			handler = filter.HandlerFunc(handler, route.Name)
 		}
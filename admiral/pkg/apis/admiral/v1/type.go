@@ -19,6 +19,10 @@ type Dependency struct {
 type DependencyStatus struct {
 	ClusterSynced int32  `json:"clustersSynced"`
 	State         string `json:"state"`
+	//hosts of the destinations generated for the source
+	Hostnames          []string `json:"hostnames,omitempty"`
+	LastError          string   `json:"lastError,omitempty"`
+	ObservedGeneration int64    `json:"observedGeneration,omitempty"`
 }
 
 // FooList is a list of Foo resources
@@ -45,6 +49,11 @@ type GlobalTrafficPolicy struct {
 type GlobalTrafficPolicyStatus struct {
 	ClusterSynced int32  `json:"clustersSynced"`
 	State         string `json:"state"`
+	//true for the GTP Admiral applies to the identity/env, the others are ignored
+	Active             bool     `json:"active"`
+	Hostnames          []string `json:"hostnames,omitempty"`
+	LastError          string   `json:"lastError,omitempty"`
+	ObservedGeneration int64    `json:"observedGeneration,omitempty"`
 }
 
 // FooList is a list of Foo resources
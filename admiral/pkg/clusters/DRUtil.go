@@ -2,52 +2,286 @@ package clusters
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
 	log "github.com/sirupsen/logrus"
 )
-const  ReadWriteEnabled = false
-const ReadOnlyEnabled = true;
-const StateNotInitialized = false;
-const StateInitialized =true;
+
+const ReadWriteEnabled = false
+const ReadOnlyEnabled = true
+const StateNotInitialized = false
+const StateInitialized = true
 
 type AdmiralState struct {
-	ReadOnly  bool
+	ReadOnly           bool
 	IsStateInitialized bool
 }
+
+// guarded by admiralStateMutex, use IsAdmiralReadOnly and IsAdmiralStateInitialized to read it
 var CurrentAdmiralState AdmiralState
 
+const (
+	StateCheckerTransitionSource   = "state_checker"
+	ManualOverrideTransitionSource = "manual_override"
+
+	maxAdmiralStateTransitions = 20
+)
+
+/*
+Hook called every time Admiral moves between read-only and read-write modes, with the state before and after the transition
+*/
+type AdmiralStateTransitionHook func(previous AdmiralState, current AdmiralState)
+
+// AdmiralStateTransition records a single move between read-only and read-write modes
+type AdmiralStateTransition struct {
+	ReadOnly bool      `json:"ReadOnly"`
+	Time     time.Time `json:"Time"`
+	Source   string    `json:"Source"`
+	Reason   string    `json:"Reason,omitempty"`
+}
+
+// AdmiralStateStatus is a point in time view of the Admiral DR state
+type AdmiralStateStatus struct {
+	ReadOnly           bool                     `json:"ReadOnly"`
+	IsStateInitialized bool                     `json:"IsStateInitialized"`
+	ForcedReadOnly     bool                     `json:"ForcedReadOnly"`
+	StateCheckerName   string                   `json:"StateCheckerName,omitempty"`
+	InitializedTime    *time.Time               `json:"InitializedTime,omitempty"`
+	Transitions        []AdmiralStateTransition `json:"Transitions"`
+}
+
+var (
+	admiralStateMutex    sync.Mutex
+	stateTransitionHooks []AdmiralStateTransitionHook
+	//read-only state requested by the state checker, Admiral is read-only if either this or forcedReadOnly is set
+	checkerReadOnly      bool
+	forcedReadOnly       bool
+	stateCheckerName     string
+	stateInitializedTime time.Time
+	stateTransitions     []AdmiralStateTransition
+)
+
+/*
+RegisterStateTransitionHook adds a hook to be called on every read-only/read-write transition.
+Hooks are called synchronously from the state checker, so long running work should be moved to a new go routine.
+*/
+func RegisterStateTransitionHook(hook AdmiralStateTransitionHook) {
+	admiralStateMutex.Lock()
+	defer admiralStateMutex.Unlock()
+	stateTransitionHooks = append(stateTransitionHooks, hook)
+}
+
+/*
+SetAdmiralReadOnly is used by state checkers to move Admiral between read-only and read-write modes.
+When the mode changes the transition is recorded in metrics and the registered transition hooks are called.
+Admiral stays read-only while it is forced to with ForceAdmiralReadOnly, regardless of the state checker.
+*/
+func SetAdmiralReadOnly(readOnly bool) {
+	applyAdmiralState(StateCheckerTransitionSource, "", func() {
+		checkerReadOnly = readOnly
+	})
+}
+
+/*
+SetAdmiralReadOnlyAndInitialized is used by state checkers once they have determined the state of Admiral.
+The mode and the initialized flag are changed together, so Admiral is never seen initialized in a mode it is about to leave.
+*/
+func SetAdmiralReadOnlyAndInitialized(readOnly bool) {
+	applyAdmiralState(StateCheckerTransitionSource, "", func() {
+		checkerReadOnly = readOnly
+		if !CurrentAdmiralState.IsStateInitialized {
+			CurrentAdmiralState.IsStateInitialized = StateInitialized
+			stateInitializedTime = time.Now()
+		}
+	})
+}
+
+/*
+ForceAdmiralReadOnly lets an operator keep Admiral in read-only mode, for example during an incident.
+Removing the override moves Admiral back to the state last requested by the state checker.
+*/
+func ForceAdmiralReadOnly(force bool, reason string) {
+	applyAdmiralState(ManualOverrideTransitionSource, reason, func() {
+		forcedReadOnly = force
+	})
+}
+
+// IsAdmiralReadOnly returns true if Admiral should not write to the clusters
+func IsAdmiralReadOnly() bool {
+	admiralStateMutex.Lock()
+	defer admiralStateMutex.Unlock()
+	return CurrentAdmiralState.ReadOnly
+}
+
+// IsAdmiralStateInitialized returns true once the state checker has determined the state of Admiral
+func IsAdmiralStateInitialized() bool {
+	admiralStateMutex.Lock()
+	defer admiralStateMutex.Unlock()
+	return CurrentAdmiralState.IsStateInitialized
+}
+
+// GetAdmiralStateStatus returns the current Admiral DR state along with the most recent transitions
+func GetAdmiralStateStatus() AdmiralStateStatus {
+	admiralStateMutex.Lock()
+	defer admiralStateMutex.Unlock()
+	status := AdmiralStateStatus{
+		ReadOnly:           CurrentAdmiralState.ReadOnly,
+		IsStateInitialized: CurrentAdmiralState.IsStateInitialized,
+		ForcedReadOnly:     forcedReadOnly,
+		StateCheckerName:   stateCheckerName,
+		Transitions:        make([]AdmiralStateTransition, len(stateTransitions)),
+	}
+	copy(status.Transitions, stateTransitions)
+	if !stateInitializedTime.IsZero() {
+		initializedTime := stateInitializedTime
+		status.InitializedTime = &initializedTime
+	}
+	return status
+}
+
+// update is called with the admiral state lock held, to change the requested state before it is applied
+func applyAdmiralState(source string, reason string, update func()) {
+	admiralStateMutex.Lock()
+	previous := CurrentAdmiralState
+	update()
+	CurrentAdmiralState.ReadOnly = checkerReadOnly || forcedReadOnly
+	current := CurrentAdmiralState
+	if previous.ReadOnly == current.ReadOnly {
+		admiralStateMutex.Unlock()
+		return
+	}
+	transition := AdmiralStateTransition{ReadOnly: current.ReadOnly, Time: time.Now(), Source: source, Reason: reason}
+	stateTransitions = append(stateTransitions, transition)
+	if len(stateTransitions) > maxAdmiralStateTransitions {
+		stateTransitions = stateTransitions[len(stateTransitions)-maxAdmiralStateTransitions:]
+	}
+	hooks := make([]AdmiralStateTransitionHook, len(stateTransitionHooks))
+	copy(hooks, stateTransitionHooks)
+	admiralStateMutex.Unlock()
+
+	if current.ReadOnly {
+		log.Infof("Admiral state transitioned from Read/Write to Read-only source=%v reason=%v", source, reason)
+		common.AdmiralStateTransitions.With(common.ReadOnlyStateLabelValue, source).Inc()
+		common.AdmiralReadOnlyState.Set(1)
+	} else {
+		log.Infof("Admiral state transitioned from Read-only to Read/Write source=%v reason=%v", source, reason)
+		common.AdmiralStateTransitions.With(common.ReadWriteStateLabelValue, source).Inc()
+		common.AdmiralReadOnlyState.Set(0)
+		common.AdmiralLastPromotionTime.Set(float64(transition.Time.Unix()))
+	}
+	for _, hook := range hooks {
+		hook(previous, current)
+	}
+}
+
+/*
+Resets Admiral to read-only and not initialized before the state checker is started
+*/
+func initAdmiralState(checkerName string) {
+	admiralStateMutex.Lock()
+	defer admiralStateMutex.Unlock()
+	CurrentAdmiralState = AdmiralState{ReadOnly: ReadOnlyEnabled, IsStateInitialized: StateNotInitialized}
+	checkerReadOnly = ReadOnlyEnabled
+	forcedReadOnly = false
+	stateCheckerName = checkerName
+	stateInitializedTime = time.Time{}
+	stateTransitions = nil
+	common.AdmiralReadOnlyState.Set(1)
+}
+
+/*
+Interface to be implemented by Admiral DR strategies.
+RunStateCheck has the logic for DR and uses SetAdmiralReadOnly to transition Admiral between Active and Passive modes.
+ShouldRunOnIndependentGoRoutine should return true if RunStateCheck needs to run on a separate go routine.
+*/
 type AdmiralStateChecker interface {
-	runStateCheck(ctx context.Context)
-	shouldRunOnIndependentGoRoutine() bool
+	RunStateCheck(ctx context.Context)
+	ShouldRunOnIndependentGoRoutine() bool
+}
+
+/*
+Factory used to build an AdmiralStateChecker from the program parameters.
+drStateStoreConfigPath is the location of the config file passed with --dr_state_store_config_path
+*/
+type StateCheckerFactory func(params common.AdmiralParams, drStateStoreConfigPath string) (AdmiralStateChecker, error)
+
+var (
+	stateCheckersMutex sync.Mutex
+	stateCheckers      = make(map[string]StateCheckerFactory)
+)
+
+/*
+RegisterStateChecker makes an AdmiralStateChecker available by the provided name, which can then be selected with --admiral_state_checker_name.
+Names are case insensitive. Custom implementations can call this from an init function of their own package and be linked into a custom build.
+If RegisterStateChecker is called twice with the same name or if factory is nil, it panics.
+*/
+func RegisterStateChecker(name string, factory StateCheckerFactory) {
+	stateCheckersMutex.Lock()
+	defer stateCheckersMutex.Unlock()
+	if factory == nil {
+		panic("RegisterStateChecker factory is nil for state checker " + name)
+	}
+	key := strings.ToLower(name)
+	if _, ok := stateCheckers[key]; ok {
+		panic("RegisterStateChecker called twice for state checker " + name)
+	}
+	stateCheckers[key] = factory
+}
+
+// GetRegisteredStateCheckers returns the names of all the registered state checkers
+func GetRegisteredStateCheckers() []string {
+	stateCheckersMutex.Lock()
+	defer stateCheckersMutex.Unlock()
+	names := make([]string, 0, len(stateCheckers))
+	for name := range stateCheckers {
+		names = append(names, name)
+	}
+	return names
 }
+
+func getStateCheckerFactory(name string) (StateCheckerFactory, bool) {
+	stateCheckersMutex.Lock()
+	defer stateCheckersMutex.Unlock()
+	factory, ok := stateCheckers[strings.ToLower(name)]
+	return factory, ok
+}
+
 /*
 Utility function to start Admiral DR checks.
 DR checks can be run either on the main go routine or a new go routine
 */
-func RunAdmiralStateCheck(ctx context.Context,asc AdmiralStateChecker){
+func RunAdmiralStateCheck(ctx context.Context, asc AdmiralStateChecker) {
 	log.Infof("Starting Disaster Recovery state checks")
-	if asc.shouldRunOnIndependentGoRoutine() {
+	if asc.ShouldRunOnIndependentGoRoutine() {
 		log.Info("Starting Admiral State Checker  on a new Go Routine")
-		go asc.runStateCheck(ctx)
-	}else {
+		go asc.RunStateCheck(ctx)
+	} else {
 		log.Infof("Starting Admiral State Checker on existing Go Routine")
-		asc.runStateCheck(ctx)
+		asc.RunStateCheck(ctx)
 	}
 }
 
 /*
 utility function to identify the Admiral DR implementation based on the program parameters
 */
-func startAdmiralStateChecker (ctx context.Context,params common.AdmiralParams){
-	var  admiralStateChecker AdmiralStateChecker
-	switch  params.AdmiralStateCheckerName {
-/*
-     Add entries for your custom Disaster Recovery state checkers below
-     case "keywordforsomecustomchecker":
-		admiralStateChecker  = customChecker{}
-*/
-	default:
-		admiralStateChecker = NoOPStateChecker{}
+func startAdmiralStateChecker(ctx context.Context, params common.AdmiralParams) error {
+	checkerName := params.AdmiralStateCheckerName
+	factory, ok := getStateCheckerFactory(checkerName)
+	if !ok {
+		log.Warnf("No state checker registered with name=%v, registered state checkers=%v. Defaulting to %v",
+			checkerName, GetRegisteredStateCheckers(), NoOPStateCheckerName)
+		checkerName = NoOPStateCheckerName
+		factory, _ = getStateCheckerFactory(checkerName)
 	}
-	RunAdmiralStateCheck(ctx,admiralStateChecker)
-}
\ No newline at end of file
+	admiralStateChecker, err := factory(params, params.DRStateStoreConfigPath)
+	if err != nil {
+		return fmt.Errorf("could not create state checker with name=%v: %v", checkerName, err)
+	}
+	initAdmiralState(checkerName)
+	RunAdmiralStateCheck(ctx, admiralStateChecker)
+	return nil
+}
@@ -0,0 +1,196 @@
+package clusters
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+)
+
+type testStateChecker struct {
+	called chan string
+}
+
+func (testStateChecker) ShouldRunOnIndependentGoRoutine() bool {
+	return false
+}
+
+func (t testStateChecker) RunStateCheck(ctx context.Context) {
+	t.called <- "called"
+}
+
+// sets the admiral state under the lock for tests which need Admiral in a given state
+func setAdmiralState(state AdmiralState) {
+	admiralStateMutex.Lock()
+	defer admiralStateMutex.Unlock()
+	CurrentAdmiralState = state
+}
+
+func TestRegisterStateChecker(t *testing.T) {
+	called := make(chan string, 1)
+	var receivedPath string
+	RegisterStateChecker("TestRegisterStateChecker", func(params common.AdmiralParams, drStateStoreConfigPath string) (AdmiralStateChecker, error) {
+		receivedPath = drStateStoreConfigPath
+		return testStateChecker{called: called}, nil
+	})
+
+	params := common.AdmiralParams{AdmiralStateCheckerName: "testregisterstatechecker", DRStateStoreConfigPath: "testdata/dr.yaml"}
+	err := startAdmiralStateChecker(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(called) != 1 {
+		t.Errorf("Registered state checker was not run")
+	}
+	if receivedPath != params.DRStateStoreConfigPath {
+		t.Errorf("Config path mismatch. Got %v, want %v", receivedPath, params.DRStateStoreConfigPath)
+	}
+}
+
+func TestRegisterStateCheckerTwicePanics(t *testing.T) {
+	factory := func(params common.AdmiralParams, drStateStoreConfigPath string) (AdmiralStateChecker, error) {
+		return NoOPStateChecker{}, nil
+	}
+	RegisterStateChecker("TestRegisterStateCheckerTwice", factory)
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected panic when registering a state checker twice")
+		}
+	}()
+	RegisterStateChecker("testregisterstatecheckertwice", factory)
+}
+
+func TestStartAdmiralStateChecker(t *testing.T) {
+	RegisterStateChecker("TestStateCheckerWithError", func(params common.AdmiralParams, drStateStoreConfigPath string) (AdmiralStateChecker, error) {
+		return nil, errors.New("bad config")
+	})
+	testCases := []struct {
+		name        string
+		checkerName string
+		expectErr   bool
+	}{
+		{
+			name:        "unknown state checker defaults to NoOPStateChecker",
+			checkerName: "unknown",
+			expectErr:   false,
+		},
+		{
+			name:        "NoOPStateChecker is registered by default",
+			checkerName: NoOPStateCheckerName,
+			expectErr:   false,
+		},
+		{
+			name:        "factory error is returned",
+			checkerName: "TestStateCheckerWithError",
+			expectErr:   true,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			setAdmiralState(AdmiralState{ReadOnly: ReadOnlyEnabled, IsStateInitialized: StateNotInitialized})
+			err := startAdmiralStateChecker(context.Background(), common.AdmiralParams{AdmiralStateCheckerName: c.checkerName})
+			if c.expectErr {
+				if err == nil {
+					t.Errorf("Expected error for state checker %v", c.checkerName)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Unexpected error %v", err)
+				}
+				if IsAdmiralReadOnly() || !IsAdmiralStateInitialized() {
+					t.Errorf("Expected read-write initialized state, got %v", GetAdmiralStateStatus())
+				}
+				if name := GetAdmiralStateStatus().StateCheckerName; name != NoOPStateCheckerName {
+					t.Errorf("State checker name mismatch. Got %v, want %v", name, NoOPStateCheckerName)
+				}
+			}
+			setAdmiralState(AdmiralState{ReadOnly: ReadWriteEnabled, IsStateInitialized: StateInitialized})
+		})
+	}
+}
+
+func TestForceAdmiralReadOnly(t *testing.T) {
+	defer func() {
+		initAdmiralState("")
+		setAdmiralState(AdmiralState{ReadOnly: ReadWriteEnabled, IsStateInitialized: StateInitialized})
+	}()
+	initAdmiralState("TestForceAdmiralReadOnly")
+	SetAdmiralReadOnly(ReadWriteEnabled)
+
+	ForceAdmiralReadOnly(true, "incident")
+	if !IsAdmiralReadOnly() {
+		t.Fatalf("Expected Admiral to be read-only when forced")
+	}
+
+	SetAdmiralReadOnly(ReadWriteEnabled)
+	if !IsAdmiralReadOnly() {
+		t.Fatalf("Expected Admiral to stay read-only while forced, even if the state checker moves to read-write")
+	}
+
+	ForceAdmiralReadOnly(false, "incident resolved")
+	if IsAdmiralReadOnly() {
+		t.Fatalf("Expected Admiral to go back to the state checker state when the override is removed")
+	}
+
+	status := GetAdmiralStateStatus()
+	if status.StateCheckerName != "TestForceAdmiralReadOnly" || status.ForcedReadOnly {
+		t.Errorf("Unexpected status %v", status)
+	}
+	expected := []AdmiralStateTransition{
+		{ReadOnly: ReadWriteEnabled, Source: StateCheckerTransitionSource},
+		{ReadOnly: ReadOnlyEnabled, Source: ManualOverrideTransitionSource, Reason: "incident"},
+		{ReadOnly: ReadWriteEnabled, Source: ManualOverrideTransitionSource, Reason: "incident resolved"},
+	}
+	if len(status.Transitions) != len(expected) {
+		t.Fatalf("Transitions mismatch. Got %v, want %v", status.Transitions, expected)
+	}
+	for i, transition := range status.Transitions {
+		transition.Time = time.Time{}
+		if transition != expected[i] {
+			t.Errorf("Transition mismatch. Got %v, want %v", transition, expected[i])
+		}
+	}
+}
+
+func TestAdmiralStateTransitionHistoryIsBounded(t *testing.T) {
+	defer func() {
+		initAdmiralState("")
+		setAdmiralState(AdmiralState{ReadOnly: ReadWriteEnabled, IsStateInitialized: StateInitialized})
+	}()
+	initAdmiralState("TestAdmiralStateTransitionHistoryIsBounded")
+	for i := 0; i < maxAdmiralStateTransitions+5; i++ {
+		SetAdmiralReadOnly(i%2 == 1)
+	}
+	transitions := GetAdmiralStateStatus().Transitions
+	if len(transitions) != maxAdmiralStateTransitions {
+		t.Errorf("Expected %v transitions, got %v", maxAdmiralStateTransitions, len(transitions))
+	}
+}
+
+func TestSetAdmiralReadOnlyAndInitialized(t *testing.T) {
+	defer func() {
+		initAdmiralState("")
+		setAdmiralState(AdmiralState{ReadOnly: ReadWriteEnabled, IsStateInitialized: StateInitialized})
+	}()
+	initAdmiralState("TestSetAdmiralReadOnlyAndInitialized")
+
+	SetAdmiralReadOnly(ReadWriteEnabled)
+	if IsAdmiralStateInitialized() || GetAdmiralStateStatus().InitializedTime != nil {
+		t.Fatalf("Expected Admiral state to stay not initialized when only the mode changes")
+	}
+
+	SetAdmiralReadOnlyAndInitialized(ReadOnlyEnabled)
+	status := GetAdmiralStateStatus()
+	if !status.ReadOnly || !status.IsStateInitialized || status.InitializedTime == nil {
+		t.Fatalf("Expected read-only initialized state, got %v", status)
+	}
+
+	initializedTime := *status.InitializedTime
+	SetAdmiralReadOnlyAndInitialized(ReadWriteEnabled)
+	status = GetAdmiralStateStatus()
+	if status.ReadOnly || !status.InitializedTime.Equal(initializedTime) {
+		t.Errorf("Expected read-write state keeping the first initialized time, got %v", status)
+	}
+}
@@ -0,0 +1,197 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const (
+	LeaseStateCheckerName = "LeaseStateChecker"
+
+	DefaultLeaseName          = "admiral-dr-lease"
+	DefaultLeaseDuration      = 15 * time.Second
+	DefaultLeaseRenewDeadline = 10 * time.Second
+	DefaultLeaseRetryPeriod   = 2 * time.Second
+)
+
+type LeaseConfigWrapper struct {
+	LeaseConfig LeaseConfig `yaml:"lease,omitempty"`
+}
+
+/*
+Reference struct used to unmarshall the lease config present in the yaml config file
+*/
+type LeaseConfig struct {
+	Name          string        `yaml:"name,omitempty"`
+	Namespace     string        `yaml:"namespace,omitempty"`
+	Identity      string        `yaml:"identity,omitempty"`
+	LeaseDuration time.Duration `yaml:"leaseDuration,omitempty"`
+	RenewDeadline time.Duration `yaml:"renewDeadline,omitempty"`
+	RetryPeriod   time.Duration `yaml:"retryPeriod,omitempty"`
+}
+
+func init() {
+	RegisterStateChecker(LeaseStateCheckerName, NewLeaseStateChecker)
+}
+
+/*
+Implementation of the interface defined for DR which uses a coordination.k8s.io Lease, in the cluster Admiral runs in, as the lock object.
+The Admiral instance holding the lease is read-write, every other instance is read-only.
+*/
+type LeaseStateChecker struct {
+	leaseConfig *LeaseConfig
+	elector     *leaderelection.LeaderElector
+}
+
+func NewLeaseStateChecker(params common.AdmiralParams, drStateStoreConfigPath string) (AdmiralStateChecker, error) {
+	leaseConfig, err := getLeaseConfig(drStateStoreConfigPath, params.ClusterRegistriesNamespace)
+	if err != nil {
+		return nil, err
+	}
+	k8sClient, err := admiral.K8sClientFromPath(params.KubeconfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not create k8s client for lease: %v", err)
+	}
+	return newLeaseStateChecker(k8sClient, leaseConfig)
+}
+
+func newLeaseStateChecker(k8sClient kubernetes.Interface, leaseConfig *LeaseConfig) (*LeaseStateChecker, error) {
+	elector, err := newLeaseElector(k8sClient, leaseConfig)
+	if err != nil {
+		return nil, fmt.Errorf("could not create leader elector for lease: %v", err)
+	}
+	return &LeaseStateChecker{leaseConfig: leaseConfig, elector: elector}, nil
+}
+
+func (*LeaseStateChecker) ShouldRunOnIndependentGoRoutine() bool {
+	return true
+}
+
+/*
+Below is the logic for the Admiral instance holding the lease
+1. Renew the lease every RetryPeriod and stay in read-write mode
+2. If the lease cannot be renewed within RenewDeadline, move to read-only mode and go back to acquiring the lease
+
+Below is the logic for Admiral instances not holding the lease
+1. Stay in read-only mode while the lease is held and renewed by another instance
+2. Take over the lease once it has not been renewed for LeaseDuration and move to read-write mode
+
+The lease is released when the context is cancelled, so a passive instance can take over without waiting for the lease to expire
+*/
+func (lc *LeaseStateChecker) RunStateCheck(ctx context.Context) {
+	SetAdmiralReadOnly(ReadOnlyEnabled)
+	leaseConfig := lc.leaseConfig
+	log.Infof("LeaseDR: CurrentPod=%v LeaseName=%v LeaseNamespace=%v LeaseDuration=%v RenewDeadline=%v RetryPeriod=%v", leaseConfig.Identity,
+		leaseConfig.Name, leaseConfig.Namespace, leaseConfig.LeaseDuration, leaseConfig.RenewDeadline, leaseConfig.RetryPeriod)
+	//Run returns every time the lease is lost, go back to acquiring it until the context is done
+	for {
+		lc.elector.Run(ctx)
+		select {
+		case <-ctx.Done():
+			log.Info("LeaseDR: context done stopping lease state checker")
+			return
+		default:
+			log.Info("LeaseDR: lease lost, trying to re-acquire")
+		}
+	}
+}
+
+func newLeaseElector(k8sClient kubernetes.Interface, leaseConfig *LeaseConfig) (*leaderelection.LeaderElector, error) {
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metaV1.ObjectMeta{
+			Name:      leaseConfig.Name,
+			Namespace: leaseConfig.Namespace,
+		},
+		Client: k8sClient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: leaseConfig.Identity,
+		},
+	}
+	return leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   leaseConfig.LeaseDuration,
+		RenewDeadline:   leaseConfig.RenewDeadline,
+		RetryPeriod:     leaseConfig.RetryPeriod,
+		ReleaseOnCancel: true,
+		Name:            leaseConfig.Name,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				log.Infof("LeaseDR: Lease with name=%v is owned by the current pod=%v. Admiral will write", leaseConfig.Name, leaseConfig.Identity)
+				SetAdmiralReadOnlyAndInitialized(ReadWriteEnabled)
+			},
+			OnStoppedLeading: func() {
+				log.Infof("LeaseDR: Lease with name=%v is not owned by the current pod=%v. Admiral will not write", leaseConfig.Name, leaseConfig.Identity)
+				SetAdmiralReadOnly(ReadOnlyEnabled)
+			},
+			OnNewLeader: func(identity string) {
+				if identity == leaseConfig.Identity {
+					return
+				}
+				log.Infof("LeaseDR: Lease with name=%v held by %v. Admiral will not write", leaseConfig.Name, identity)
+				SetAdmiralReadOnlyAndInitialized(ReadOnlyEnabled)
+			},
+		},
+	})
+}
+
+func getLeaseConfig(drStateStoreConfigPath string, defaultNamespace string) (*LeaseConfig, error) {
+	var leaseConfig LeaseConfig
+	if len(drStateStoreConfigPath) > 0 {
+		config, err := BuildLeaseConfig(drStateStoreConfigPath)
+		if err != nil {
+			return nil, err
+		}
+		leaseConfig = config
+	}
+	if len(leaseConfig.Name) == 0 {
+		leaseConfig.Name = DefaultLeaseName
+	}
+	if len(leaseConfig.Namespace) == 0 {
+		leaseConfig.Namespace = defaultNamespace
+	}
+	if len(leaseConfig.Identity) == 0 {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("could not determine identity for lease: %v", err)
+		}
+		leaseConfig.Identity = hostname
+	}
+	if leaseConfig.LeaseDuration == 0 {
+		leaseConfig.LeaseDuration = DefaultLeaseDuration
+	}
+	if leaseConfig.RenewDeadline == 0 {
+		leaseConfig.RenewDeadline = DefaultLeaseRenewDeadline
+	}
+	if leaseConfig.RetryPeriod == 0 {
+		leaseConfig.RetryPeriod = DefaultLeaseRetryPeriod
+	}
+	return &leaseConfig, nil
+}
+
+/*
+Utility function to read the lease config from the yaml file passed with --dr_state_store_config_path
+*/
+func BuildLeaseConfig(configFile string) (LeaseConfig, error) {
+	data, err := ioutil.ReadFile(configFile)
+	leaseConfigWrapper := &LeaseConfigWrapper{}
+	if err != nil {
+		return LeaseConfig{}, fmt.Errorf("error reading config file to build lease configurations: %v", err)
+	}
+	err = yaml.Unmarshal(data, &leaseConfigWrapper)
+	if err != nil {
+		return LeaseConfig{}, fmt.Errorf("error unmarshalling config file err: %v", err)
+	}
+	return leaseConfigWrapper.LeaseConfig, nil
+}
@@ -0,0 +1,171 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/test"
+	coordinationV1 "k8s.io/api/coordination/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func getTestLeaseConfig(identity string) *LeaseConfig {
+	return &LeaseConfig{
+		Name:          "test-lease",
+		Namespace:     "test-ns",
+		Identity:      identity,
+		LeaseDuration: time.Second,
+		//not a multiple of RetryPeriod, client-go 0.17 races on the elector state when a renew retry is in flight as the deadline passes
+		RenewDeadline: 550 * time.Millisecond,
+		RetryPeriod:   100 * time.Millisecond,
+	}
+}
+
+func getTestLease(holder string, renewTime time.Time) *coordinationV1.Lease {
+	leaseDurationSeconds := int32(1)
+	microRenewTime := metaV1.NewMicroTime(renewTime)
+	return &coordinationV1.Lease{
+		ObjectMeta: metaV1.ObjectMeta{Name: "test-lease", Namespace: "test-ns"},
+		Spec: coordinationV1.LeaseSpec{
+			HolderIdentity:       &holder,
+			LeaseDurationSeconds: &leaseDurationSeconds,
+			AcquireTime:          &microRenewTime,
+			RenewTime:            &microRenewTime,
+		},
+	}
+}
+
+// starts the state checker and returns a func which stops it and restores the admiral state for other tests
+func startTestLeaseStateChecker(t *testing.T, client kubernetes.Interface, config *LeaseConfig) func() {
+	checker, err := newLeaseStateChecker(client, config)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	setAdmiralState(AdmiralState{ReadOnly: ReadOnlyEnabled, IsStateInitialized: StateNotInitialized})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		checker.RunStateCheck(ctx)
+		close(done)
+	}()
+	return func() {
+		cancel()
+		<-done
+		setAdmiralState(AdmiralState{ReadOnly: ReadWriteEnabled, IsStateInitialized: StateInitialized})
+	}
+}
+
+func TestBuildLeaseConfig(t *testing.T) {
+	config, err := BuildLeaseConfig("testdata/lease.yaml")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	expected := LeaseConfig{
+		Name:          "test-lease",
+		Namespace:     "test-ns",
+		Identity:      "admiral-0",
+		LeaseDuration: 20 * time.Second,
+		RenewDeadline: 15 * time.Second,
+		RetryPeriod:   3 * time.Second,
+	}
+	if config != expected {
+		t.Errorf("Lease config mismatch. Got %v, want %v", config, expected)
+	}
+
+	_, err = BuildLeaseConfig("testdata/does-not-exist.yaml")
+	if err == nil {
+		t.Errorf("Expected error for missing config file")
+	}
+
+	_, err = newLeaseStateChecker(fake.NewSimpleClientset(), &LeaseConfig{Name: "test-lease", LeaseDuration: time.Second, RenewDeadline: 2 * time.Second, RetryPeriod: time.Second})
+	if err == nil {
+		t.Errorf("Expected error when renew deadline is greater than lease duration")
+	}
+}
+
+func TestGetLeaseConfigDefaults(t *testing.T) {
+	config, err := getLeaseConfig("", "default")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if config.Name != DefaultLeaseName {
+		t.Errorf("Lease name mismatch. Got %v, want %v", config.Name, DefaultLeaseName)
+	}
+	if config.Namespace != "default" {
+		t.Errorf("Lease namespace mismatch. Got %v, want %v", config.Namespace, "default")
+	}
+	if len(config.Identity) == 0 {
+		t.Errorf("Lease identity should default to the hostname")
+	}
+	if config.LeaseDuration != DefaultLeaseDuration || config.RenewDeadline != DefaultLeaseRenewDeadline || config.RetryPeriod != DefaultLeaseRetryPeriod {
+		t.Errorf("Lease durations mismatch. Got %v", config)
+	}
+}
+
+func TestLeaseStateCheckerAcquiresFreeLease(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	stop := startTestLeaseStateChecker(t, client, getTestLeaseConfig("admiral-0"))
+
+	test.NewEventualOpts(10*time.Millisecond, 5*time.Second).Eventually(t, "admiral is read-write", func() bool {
+		return IsAdmiralStateInitialized() && !IsAdmiralReadOnly()
+	})
+
+	stop()
+
+	lease, err := client.CoordinationV1().Leases("test-ns").Get("test-lease", metaV1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity != "" {
+		t.Errorf("Lease should be released on shutdown, held by %v", *lease.Spec.HolderIdentity)
+	}
+}
+
+func TestLeaseStateCheckerFailover(t *testing.T) {
+	client := fake.NewSimpleClientset(getTestLease("admiral-1", time.Now()))
+	config := getTestLeaseConfig("admiral-0")
+	start := time.Now()
+	stop := startTestLeaseStateChecker(t, client, config)
+	defer stop()
+
+	test.NewEventualOpts(10*time.Millisecond, 5*time.Second).Eventually(t, "admiral is initialized", func() bool {
+		return IsAdmiralStateInitialized()
+	})
+	if !IsAdmiralReadOnly() {
+		t.Fatalf("Admiral should be read-only while the lease is held by another instance")
+	}
+
+	//admiral-1 never renews, so the lease is taken over once it expires
+	test.NewEventualOpts(10*time.Millisecond, 5*time.Second).Eventually(t, "admiral is read-write", func() bool {
+		return !IsAdmiralReadOnly()
+	})
+	elapsed := time.Since(start)
+	if elapsed < config.LeaseDuration {
+		t.Errorf("Lease taken over after %v, before it expired after %v", elapsed, config.LeaseDuration)
+	}
+	if elapsed > 3*config.LeaseDuration {
+		t.Errorf("Lease taken over after %v, expected within %v", elapsed, 3*config.LeaseDuration)
+	}
+}
+
+func TestLeaseStateCheckerLosesLease(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	stop := startTestLeaseStateChecker(t, client, getTestLeaseConfig("admiral-0"))
+	defer stop()
+
+	test.NewEventualOpts(10*time.Millisecond, 5*time.Second).Eventually(t, "admiral is read-write", func() bool {
+		return !IsAdmiralReadOnly()
+	})
+
+	//another instance steals the lease, the renewal fails and admiral moves to read-only
+	_, err := client.CoordinationV1().Leases("test-ns").Update(getTestLease("admiral-1", time.Now().Add(time.Minute)))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	test.NewEventualOpts(10*time.Millisecond, 5*time.Second).Eventually(t, "admiral is read-only", func() bool {
+		return IsAdmiralReadOnly()
+	})
+}
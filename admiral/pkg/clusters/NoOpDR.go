@@ -2,22 +2,30 @@ package clusters
 
 import (
 	"context"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
 	log "github.com/sirupsen/logrus"
 )
 
+const NoOPStateCheckerName = "NoOPStateChecker"
+
+func init() {
+	RegisterStateChecker(NoOPStateCheckerName, func(params common.AdmiralParams, drStateStoreConfigPath string) (AdmiralStateChecker, error) {
+		return NoOPStateChecker{}, nil
+	})
+}
+
 /*
 Default implementation of the interface defined for DR
 */
 
-type NoOPStateChecker struct {}
+type NoOPStateChecker struct{}
 
-func (NoOPStateChecker) shouldRunOnIndependentGoRoutine() bool{
-	return false;
+func (NoOPStateChecker) ShouldRunOnIndependentGoRoutine() bool {
+	return false
 }
 
-func (NoOPStateChecker) runStateCheck(ctx context.Context){
+func (NoOPStateChecker) RunStateCheck(ctx context.Context) {
 	log.Info("NoOP State Checker called. Marking Admiral state as Read/Write enabled")
-	CurrentAdmiralState.ReadOnly = ReadWriteEnabled
-	CurrentAdmiralState.IsStateInitialized = StateInitialized
+	SetAdmiralReadOnlyAndInitialized(ReadWriteEnabled)
 }
-
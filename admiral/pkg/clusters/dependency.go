@@ -0,0 +1,290 @@
+package clusters
+
+import (
+	"fmt"
+
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/util"
+	log "github.com/sirupsen/logrus"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+/*
+removeStaleDependencies compares the destinations of a dependency record before and after it changed, the new record is nil when it was deleted.
+The edges of IdentityDependencyCache that neither the new record nor another record of the same source asks for are removed,
+along with the resources that were only synced for them. Nothing is removed until the DependencyController cache is synced,
+as the other records of the source may not be known yet.
+*/
+func (dh *DependencyHandler) removeStaleDependencies(oldDep *v1.Dependency, newDep *v1.Dependency) {
+	if dh.DepController == nil || oldDep == nil {
+		return
+	}
+	if !dh.DepController.HasSynced() {
+		log.Infof(LogFormat, "Delete", "dependency-cache", oldDep.Name, "", "Skipped removing stale dependencies as the dependency records aren't synced yet")
+		return
+	}
+	otherDeps := make([]*v1.Dependency, 0)
+	dh.DepController.Cache.Range(func(dep *v1.Dependency) {
+		if dep.Name != oldDep.Name {
+			otherDeps = append(otherDeps, dep)
+		}
+	})
+	removeStaleDependencies(dh.RemoteRegistry, getStaleDependencies(oldDep, newDep, otherDeps))
+}
+
+// returns the source of oldDep for each destination of oldDep that newDep and otherDeps don't depend on anymore, key=destination identity
+func getStaleDependencies(oldDep *v1.Dependency, newDep *v1.Dependency, otherDeps []*v1.Dependency) map[string][]string {
+	source := oldDep.Spec.Source
+	desiredDestinations := make(map[string]bool)
+	for _, dep := range append(otherDeps, newDep) {
+		if dep == nil || dep.Spec.Source != source {
+			continue
+		}
+		for _, destination := range dep.Spec.Destinations {
+			desiredDestinations[destination] = true
+		}
+	}
+	staleDependencies := make(map[string][]string)
+	if len(source) == 0 {
+		return staleDependencies
+	}
+	for _, destination := range oldDep.Spec.Destinations {
+		if !desiredDestinations[destination] {
+			staleDependencies[destination] = []string{source}
+		}
+	}
+	return staleDependencies
+}
+
+// removes the edges of staleDependencies, key=destination identity value=source identities not depending on it anymore
+func removeStaleDependencies(rr *RemoteRegistry, staleDependencies map[string][]string) {
+	cache := rr.AdmiralCache
+	for destination, sources := range staleDependencies {
+		for _, source := range sources {
+			cache.IdentityDependencyCache.DeleteMap(destination, source)
+			log.Infof(LogFormat, "Delete", "dependency-cache", destination, "", "Removed dependent="+source)
+		}
+		if IsAdmiralReadOnly() {
+			log.Infof(LogFormat, "Delete", "dependency-cache", destination, "", "Skipped deleting stale resources as Admiral is in Read-only mode")
+			continue
+		}
+		deleteStaleResourcesForDestination(rr, destination, sources)
+	}
+}
+
+/*
+deleteStaleResourcesForDestination deletes the ServiceEntries and DestinationRules of the destination identity from the clusters
+that don't run the destination or any of its remaining dependents, and the egress hosts of the destination from the Sidecars of removedSources.
+*/
+func deleteStaleResourcesForDestination(rr *RemoteRegistry, destination string, removedSources []string) {
+	defer util.LogElapsedTime("deleteStaleResourcesForDestination", destination, "", "")()
+	cache := rr.AdmiralCache
+
+	hosts := getHostsForIdentity(cache, destination)
+
+	//clusters the destination resources may have been synced to
+	candidateClusters := make(map[string]string)
+	for _, source := range removedSources {
+		for clusterID := range cache.IdentityClusterCache.Get(source).Copy() {
+			candidateClusters[clusterID] = clusterID
+		}
+	}
+	for _, host := range hosts {
+		for clusterID := range cache.CnameDependentClusterCache.Get(host).Copy() {
+			candidateClusters[clusterID] = clusterID
+		}
+	}
+
+	sourceClusters := cache.IdentityClusterCache.Get(destination).Copy()
+	dependentClusters := getDependentClusters(cache.IdentityDependencyCache.Get(destination).Copy(), cache.IdentityClusterCache, nil)
+
+	for clusterID := range candidateClusters {
+		if _, ok := sourceClusters[clusterID]; ok {
+			continue
+		}
+		if _, ok := dependentClusters[clusterID]; ok {
+			continue
+		}
+		rc := rr.GetRemoteController(clusterID)
+		if rc == nil {
+			continue
+		}
+		deleteIdentityResourcesFromCluster(rc, cache, destination, hosts)
+		for _, host := range hosts {
+			cache.CnameDependentClusterCache.DeleteMap(host, clusterID)
+		}
+	}
+
+	for _, source := range removedSources {
+		removeSidecarEgressForDependency(rr, source, destination)
+	}
+}
+
+// returns the hosts Admiral generated ServiceEntries for from the workloads of the identity
+func getHostsForIdentity(cache *AdmiralCache, identity string) []string {
+	hosts := make([]string, 0)
+	cache.CnameIdentityCache.Range(func(host interface{}, hostIdentity interface{}) bool {
+		if fmt.Sprint(hostIdentity) == identity {
+			hosts = append(hosts, fmt.Sprint(host))
+		}
+		return true
+	})
+	return hosts
+}
+
+// deletes the ServiceEntries labeled with the identity from the sync namespace, along with their DestinationRules
+func deleteIdentityResourcesFromCluster(rc *RemoteController, cache *AdmiralCache, identity string, hosts []string) {
+	if rc.ServiceEntryController == nil || rc.DestinationRuleController == nil {
+		return
+	}
+	syncNamespace := common.GetSyncNamespace()
+
+	defaultHosts := make(map[string]bool)
+	for _, host := range hosts {
+		defaultHosts[host] = true
+	}
+
+	serviceEntries, err := rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(syncNamespace).List(v12.ListOptions{
+		LabelSelector: common.GetWorkloadIdentifier() + "=" + identity,
+	})
+	if err != nil {
+		log.Errorf(LogErrFormat, "List", "ServiceEntry", identity, rc.ClusterID, err)
+		return
+	}
+
+	for i := range serviceEntries.Items {
+		serviceEntry := &serviceEntries.Items[i]
+		if len(serviceEntry.Spec.Hosts) == 0 {
+			continue
+		}
+		host := serviceEntry.Spec.Hosts[0]
+		//the hosts added by a GTP dns prefix don't use the default DestinationRule name, see createSeAndDrSetFromGtp
+		drName := getIstioResourceName(host, "-dr")
+		if defaultHosts[host] {
+			drName = getIstioResourceName(host, "-default-dr")
+		}
+		destinationRule, err := rc.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules(syncNamespace).Get(drName, v12.GetOptions{})
+		if err != nil {
+			log.Infof(LogFormat, "Get (error)", "old DestinationRule", drName, rc.ClusterID, err)
+			destinationRule = nil
+		}
+		cache.SeClusterCache.DeleteMap(host, rc.ClusterID)
+		deleteServiceEntry(serviceEntry, syncNamespace, rc)
+		deleteDestinationRule(destinationRule, syncNamespace, rc)
+	}
+}
+
+// removes the local fqdn and cnames of the destination from the sidecar egress of the source identity
+func removeSidecarEgressForDependency(rr *RemoteRegistry, source string, destination string) {
+	cache := rr.AdmiralCache
+
+	//local fqdns of the destination services
+	destinationFqdns := make(map[string]bool)
+	for clusterID := range cache.IdentityClusterCache.Get(destination).Copy() {
+		rc := rr.GetRemoteController(clusterID)
+		if rc == nil {
+			continue
+		}
+		for _, workload := range getWorkloadsForIdentity(rc, destination) {
+			for _, weightedService := range workload.GetServices(rc) {
+				destinationFqdns[weightedService.Service.Name+common.Sep+weightedService.Service.Namespace+common.DotLocalDomainSuffix] = true
+			}
+		}
+	}
+
+	removedEgress := make([]common.SidecarEgress, 0)
+	for _, sidecarEgress := range cache.DependencyNamespaceCache.Get(source) {
+		if destinationFqdns[sidecarEgress.FQDN] {
+			removedEgress = append(removedEgress, sidecarEgress)
+		}
+	}
+	for _, sidecarEgress := range removedEgress {
+		cache.DependencyNamespaceCache.DeleteNamespace(source, sidecarEgress.Namespace)
+	}
+
+	if len(removedEgress) == 0 || common.GetWorkloadSidecarUpdate() != "enabled" {
+		return
+	}
+
+	//the other destinations of the source can share the namespace of the destination, their egress hosts are kept
+	neededHosts := make(map[string]bool)
+	cache.IdentityDependencyCache.Range(func(otherDestination string, sources *common.Map) {
+		if otherDestination == destination || len(sources.Get(source)) == 0 {
+			return
+		}
+		for host := range getEgressHostsForIdentity(rr, otherDestination) {
+			neededHosts[host] = true
+		}
+	})
+
+	for clusterID := range cache.IdentityClusterCache.Get(source).Copy() {
+		rc := rr.GetRemoteController(clusterID)
+		if rc == nil {
+			continue
+		}
+		for _, workload := range getWorkloadsForIdentity(rc, source) {
+			removeSidecarEgress(workload.GetObjectMeta().Namespace, removedEgress, neededHosts, rc)
+		}
+	}
+}
+
+// returns the egress hosts, scoped by namespace, modifySidecarForLocalClusterCommunication adds to the sidecars of the dependents of the identity
+func getEgressHostsForIdentity(rr *RemoteRegistry, identity string) map[string]bool {
+	hosts := make(map[string]bool)
+	for clusterID := range rr.AdmiralCache.IdentityClusterCache.Get(identity).Copy() {
+		rc := rr.GetRemoteController(clusterID)
+		if rc == nil {
+			continue
+		}
+		for _, workload := range getWorkloadsForIdentity(rc, identity) {
+			cname := getCnameForWorkload(workload)
+			for _, weightedService := range workload.GetServices(rc) {
+				namespace := weightedService.Service.Namespace
+				hosts[namespace+"/"+weightedService.Service.Name+common.Sep+namespace+common.DotLocalDomainSuffix] = true
+				hosts[namespace+"/"+cname] = true
+			}
+		}
+	}
+	return hosts
+}
+
+// the reverse of modifySidecarForLocalClusterCommunication, removes the egress hosts of sidecarEgresses but neededHosts from the workload sidecar
+func removeSidecarEgress(sidecarNamespace string, sidecarEgresses []common.SidecarEgress, neededHosts map[string]bool, rc *RemoteController) {
+
+	sidecarConfig := rc.SidecarController
+
+	if sidecarConfig == nil {
+		return
+	}
+
+	sidecar, _ := sidecarConfig.IstioClient.NetworkingV1alpha3().Sidecars(sidecarNamespace).Get(common.GetWorkloadSidecarName(), v12.GetOptions{})
+
+	if sidecar == nil || len(sidecar.Spec.Egress) == 0 {
+		return
+	}
+
+	staleHosts := make(map[string]bool)
+	for _, sidecarEgress := range sidecarEgresses {
+		staleHosts[sidecarEgress.Namespace+"/"+sidecarEgress.FQDN] = true
+		for cname := range sidecarEgress.CNAMEs {
+			staleHosts[sidecarEgress.Namespace+"/"+cname] = true
+		}
+	}
+
+	newSidecar := copySidecar(sidecar)
+	egressHosts := make([]string, 0, len(newSidecar.Spec.Egress[0].Hosts))
+	for _, egressHost := range newSidecar.Spec.Egress[0].Hosts {
+		if !staleHosts[egressHost] || neededHosts[egressHost] {
+			egressHosts = append(egressHosts, egressHost)
+		}
+	}
+	if len(egressHosts) == len(newSidecar.Spec.Egress[0].Hosts) {
+		return
+	}
+	newSidecar.Spec.Egress[0].Hosts = egressHosts
+
+	newSidecarConfig := createSidecarSkeletion(newSidecar.Spec, common.GetWorkloadSidecarName(), sidecarNamespace)
+
+	addUpdateSidecar(newSidecarConfig, sidecar, sidecarNamespace, rc)
+}
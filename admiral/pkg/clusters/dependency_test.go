@@ -0,0 +1,204 @@
+package clusters
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/model"
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	istionetworkingv1alpha3 "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newDependencyTestRemoteController(clusterID string, identity string, hosts ...string) *RemoteController {
+	fakeIstioClient := istiofake.NewSimpleClientset()
+	for _, host := range hosts {
+		//hosts added by a gtp dns prefix, like west.e2e.bar.mesh, don't use the default DestinationRule name
+		drName := getIstioResourceName(host, "-default-dr")
+		if strings.HasPrefix(host, "west.") {
+			drName = getIstioResourceName(host, "-dr")
+		}
+		fakeIstioClient.NetworkingV1alpha3().ServiceEntries("ns").Create(&v1alpha3.ServiceEntry{
+			ObjectMeta: v12.ObjectMeta{Name: getIstioResourceName(host, "-se"), Namespace: "ns", Labels: map[string]string{"identity": identity}},
+			Spec:       istionetworkingv1alpha3.ServiceEntry{Hosts: []string{host}},
+		})
+		fakeIstioClient.NetworkingV1alpha3().DestinationRules("ns").Create(&v1alpha3.DestinationRule{
+			ObjectMeta: v12.ObjectMeta{Name: drName, Namespace: "ns"},
+			Spec:       istionetworkingv1alpha3.DestinationRule{Host: host},
+		})
+	}
+	return &RemoteController{
+		ClusterID:                 clusterID,
+		ServiceEntryController:    &istio.ServiceEntryController{IstioClient: fakeIstioClient},
+		DestinationRuleController: &istio.DestinationRuleController{IstioClient: fakeIstioClient},
+	}
+}
+
+func getServiceEntryHosts(t *testing.T, rc *RemoteController) []string {
+	serviceEntries, err := rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries("ns").List(v12.ListOptions{})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	destinationRules, err := rc.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules("ns").List(v12.ListOptions{})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(serviceEntries.Items) != len(destinationRules.Items) {
+		t.Errorf("Wanted a DestinationRule per ServiceEntry in cluster %v, got %v and %v", rc.ClusterID, serviceEntries.Items, destinationRules.Items)
+	}
+	hosts := make([]string, 0)
+	for _, serviceEntry := range serviceEntries.Items {
+		hosts = append(hosts, serviceEntry.Spec.Hosts[0])
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+func TestRemoveStaleDependencies(t *testing.T) {
+	rr := NewRemoteRegistry(context.TODO(), common.AdmiralParams{})
+	cache := rr.AdmiralCache
+
+	//bar runs in cluster1, its dependents foo in cluster2, baz and qux in cluster3
+	rc1 := newDependencyTestRemoteController("cluster1", "bar", "e2e.bar.mesh")
+	rc2 := newDependencyTestRemoteController("cluster2", "bar", "e2e.bar.mesh", "west.e2e.bar.mesh")
+	rc3 := newDependencyTestRemoteController("cluster3", "bar", "e2e.bar.mesh")
+	rc4 := newDependencyTestRemoteController("cluster4", "other", "e2e.other.mesh")
+	for _, rc := range []*RemoteController{rc1, rc2, rc3, rc4} {
+		rr.PutRemoteController(rc.ClusterID, rc)
+	}
+
+	cache.IdentityClusterCache.Put("bar", "cluster1", "cluster1")
+	cache.IdentityClusterCache.Put("foo", "cluster2", "cluster2")
+	cache.IdentityClusterCache.Put("baz", "cluster3", "cluster3")
+	cache.IdentityClusterCache.Put("qux", "cluster3", "cluster3")
+	cache.IdentityClusterCache.Put("qux", "cluster4", "cluster4")
+	for _, source := range []string{"foo", "baz", "qux"} {
+		cache.IdentityDependencyCache.Put("bar", source, source)
+	}
+	cache.IdentityDependencyCache.Put("other", "qux", "qux")
+	cache.CnameIdentityCache.Store("e2e.bar.mesh", "bar")
+	cache.CnameIdentityCache.Store("e2e.other.mesh", "other")
+	for _, clusterID := range []string{"cluster2", "cluster3"} {
+		cache.CnameDependentClusterCache.Put("e2e.bar.mesh", clusterID, clusterID)
+	}
+	cache.CnameDependentClusterCache.Put("e2e.other.mesh", "cluster4", "cluster4")
+
+	//foo and qux don't depend on bar anymore, qux still depends on other
+	removeStaleDependencies(rr, map[string][]string{"bar": {"foo", "qux"}})
+
+	if dependents := cache.IdentityDependencyCache.Get("bar").Copy(); !reflect.DeepEqual(dependents, map[string]string{"baz": "baz"}) {
+		t.Errorf("Wanted baz as the only dependent of bar, got %v", dependents)
+	}
+	if dependents := cache.IdentityDependencyCache.Get("other").Copy(); !reflect.DeepEqual(dependents, map[string]string{"qux": "qux"}) {
+		t.Errorf("Wanted qux as the only dependent of other, got %v", dependents)
+	}
+	if clusters := cache.CnameDependentClusterCache.Get("e2e.bar.mesh").Copy(); !reflect.DeepEqual(clusters, map[string]string{"cluster3": "cluster3"}) {
+		t.Errorf("Wanted cluster3 as the only dependent cluster of e2e.bar.mesh, got %v", clusters)
+	}
+
+	expectedHosts := map[*RemoteController][]string{
+		//the source cluster of bar is never cleaned up
+		rc1: {"e2e.bar.mesh"},
+		//foo was the only dependent of bar in cluster2, the host added by the gtp is deleted too
+		rc2: {},
+		//baz still depends on bar in cluster3
+		rc3: {"e2e.bar.mesh"},
+		rc4: {"e2e.other.mesh"},
+	}
+	for rc, hosts := range expectedHosts {
+		if actualHosts := getServiceEntryHosts(t, rc); !reflect.DeepEqual(actualHosts, hosts) {
+			t.Errorf("Wanted ServiceEntries for %v in cluster %v, got %v", hosts, rc.ClusterID, actualHosts)
+		}
+	}
+}
+
+func TestGetStaleDependencies(t *testing.T) {
+	newDependency := func(name string, source string, destinations ...string) *v1.Dependency {
+		return &v1.Dependency{
+			ObjectMeta: v12.ObjectMeta{Name: name, Namespace: "admiral"},
+			Spec:       model.Dependency{Source: source, IdentityLabel: "identity", Destinations: destinations},
+		}
+	}
+	oldDep := newDependency("foo-dep", "foo", "bar", "baz", "qux")
+
+	testCases := []struct {
+		name      string
+		newDep    *v1.Dependency
+		otherDeps []*v1.Dependency
+		expected  map[string][]string
+	}{
+		{
+			name:     "Given a destination removed from the record, Then only its edge is stale",
+			newDep:   newDependency("foo-dep", "foo", "bar", "qux"),
+			expected: map[string][]string{"baz": {"foo"}},
+		},
+		{
+			name:      "Given a deleted record, Then the destinations another record of the source has are kept",
+			otherDeps: []*v1.Dependency{newDependency("foo-dep2", "foo", "qux"), newDependency("other-dep", "other", "bar")},
+			expected:  map[string][]string{"bar": {"foo"}, "baz": {"foo"}},
+		},
+		{
+			name:     "Given a record whose source changed, Then all the edges of the previous source are stale",
+			newDep:   newDependency("foo-dep", "foo2", "bar", "baz", "qux"),
+			expected: map[string][]string{"bar": {"foo"}, "baz": {"foo"}, "qux": {"foo"}},
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := getStaleDependencies(oldDep, c.newDep, c.otherDeps); !reflect.DeepEqual(actual, c.expected) {
+				t.Errorf("Wanted stale dependencies %v, got %v", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRemoveStaleDependenciesBeforeSync(t *testing.T) {
+	rr := NewRemoteRegistry(context.TODO(), common.AdmiralParams{})
+	rr.AdmiralCache.IdentityDependencyCache.Put("bar", "foo", "foo")
+	dh := DependencyHandler{RemoteRegistry: rr, DepController: &admiral.DependencyController{}}
+
+	dep := &v1.Dependency{Spec: model.Dependency{Source: "foo", IdentityLabel: "identity", Destinations: []string{"bar"}}}
+	dh.Deleted(dep)
+
+	if dependents := rr.AdmiralCache.IdentityDependencyCache.Get("bar").Copy(); !reflect.DeepEqual(dependents, map[string]string{"foo": "foo"}) {
+		t.Errorf("Wanted foo to stay a dependent of bar until the records are synced, got %v", dependents)
+	}
+}
+
+func TestRemoveSidecarEgress(t *testing.T) {
+	sidecarController := &istio.SidecarController{IstioClient: istiofake.NewSimpleClientset()}
+	rc := &RemoteController{SidecarController: sidecarController}
+
+	sidecar := &v1alpha3.Sidecar{
+		ObjectMeta: v12.ObjectMeta{Name: common.GetWorkloadSidecarName(), Namespace: "foo-ns"},
+		Spec: istionetworkingv1alpha3.Sidecar{
+			Egress: []*istionetworkingv1alpha3.IstioEgressListener{{
+				Hosts: []string{"./*", "bar-ns/bar.bar-ns.svc.cluster.local", "bar-ns/e2e.bar.mesh", "bar-ns/e2e.qux.mesh", "baz-ns/baz.baz-ns.svc.cluster.local"},
+			}},
+		},
+	}
+	sidecarController.IstioClient.NetworkingV1alpha3().Sidecars("foo-ns").Create(sidecar)
+
+	//qux shares the namespace of bar, its cname is still needed
+	removeSidecarEgress("foo-ns", []common.SidecarEgress{
+		{Namespace: "bar-ns", FQDN: "bar.bar-ns.svc.cluster.local", CNAMEs: map[string]string{"e2e.bar.mesh": "1", "e2e.qux.mesh": "1"}},
+	}, map[string]bool{"bar-ns/e2e.qux.mesh": true}, rc)
+
+	updatedSidecar, err := sidecarController.IstioClient.NetworkingV1alpha3().Sidecars("foo-ns").Get(common.GetWorkloadSidecarName(), v12.GetOptions{})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	expectedHosts := []string{"./*", "bar-ns/e2e.qux.mesh", "baz-ns/baz.baz-ns.svc.cluster.local"}
+	if !reflect.DeepEqual(updatedSidecar.Spec.Egress[0].Hosts, expectedHosts) {
+		t.Errorf("Wanted egress hosts %v, got %v", expectedHosts, updatedSidecar.Spec.Egress[0].Hosts)
+	}
+}
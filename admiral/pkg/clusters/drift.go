@@ -0,0 +1,150 @@
+package clusters
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	driftOp = "Drift"
+
+	ModifiedDrift = "modified"
+	DeletedDrift  = "deleted"
+
+	maxDriftCorrections = 100
+)
+
+// DriftCorrection records an object generated by Admiral that was found changed or deleted by someone else, and reconciled again
+type DriftCorrection struct {
+	Time       time.Time           `json:"Time"`
+	Cluster    string              `json:"Cluster"`
+	ObjectType common.ResourceType `json:"ObjectType"`
+	Name       string              `json:"Name"`
+	Drift      string              `json:"Drift"`
+	Identity   string              `json:"Identity"`
+	Env        string              `json:"Env"`
+}
+
+/*
+checkDrift compares a ServiceEntry or DestinationRule generated by Admiral in the sync namespace with the desired state in the AdmiralCache.
+The object drifted when its spec doesn't match the hash Admiral wrote it with, or when it was deleted by someone else while its host is still desired in the cluster.
+The identity/env of a drifted object is reconciled again, which writes the object back.
+*/
+func (r *RemoteRegistry) checkDrift(clusterID string, objectType common.ResourceType, obj *v12.ObjectMeta, host string, spec interface{}, deleted bool) {
+	if obj.Namespace != common.GetSyncNamespace() || !isCreatedByAdmiral(obj.Annotations) {
+		return
+	}
+	if deleted && r.isAdmiralDelete(clusterID, objectType, obj.Namespace, obj.Name) {
+		return
+	}
+	if IsCacheWarmupTime(r) {
+		return
+	}
+	if _, desired := r.AdmiralCache.SeClusterCache.Get(host).Copy()[clusterID]; !desired {
+		//deleted or left behind by Admiral, see orphanSweeper
+		return
+	}
+	ie, ok := r.getIdentityEnvForHost(host)
+	if !ok {
+		return
+	}
+
+	drift := DeletedDrift
+	if !deleted {
+		expectedHash := obj.Annotations[common.SpecHashAnnotation]
+		if len(expectedHash) == 0 {
+			//written before Admiral stored the spec hashes
+			return
+		}
+		hash, err := common.GetSpecHash(spec)
+		if err != nil {
+			log.Errorf(LogErrFormat, driftOp, objectType, obj.Name, clusterID, err)
+			return
+		}
+		if hash == expectedHash {
+			return
+		}
+		drift = ModifiedDrift
+	}
+
+	common.DriftDetected.With(clusterID, string(objectType), drift).Inc()
+	log.Warnf(LogFormat, driftOp, objectType, obj.Name, clusterID, fmt.Sprintf("Object %s outside of Admiral, reconciling identity=%s env=%s", drift, ie.identity, ie.env))
+	r.recordDriftCorrection(DriftCorrection{
+		Time:       time.Now(),
+		Cluster:    clusterID,
+		ObjectType: objectType,
+		Name:       obj.Name,
+		Drift:      drift,
+		Identity:   ie.identity,
+		Env:        ie.env,
+	})
+	r.reconcileIdentityEnv(admiral.Update, ie.identity, ie.env)
+}
+
+func getAdmiralDeleteKey(objectType common.ResourceType, namespace string, name string) string {
+	return string(objectType) + "/" + namespace + "/" + name
+}
+
+/*
+markAdmiralDelete remembers that Admiral is deleting the object, before the delete is sent, as the informer delete event
+can be handled before the delete returns. The returned func forgets it again, it is called when the delete failed.
+*/
+func markAdmiralDelete(rc *RemoteController, objectType common.ResourceType, namespace string, name string) func() {
+	key := getAdmiralDeleteKey(objectType, namespace, name)
+	rc.admiralDeletes.Store(key, true)
+	return func() {
+		rc.admiralDeletes.Delete(key)
+	}
+}
+
+//returns whether the delete of the object was made by Admiral, and forgets it
+func (r *RemoteRegistry) isAdmiralDelete(clusterID string, objectType common.ResourceType, namespace string, name string) bool {
+	rc := r.GetRemoteController(clusterID)
+	if rc == nil {
+		return false
+	}
+	key := getAdmiralDeleteKey(objectType, namespace, name)
+	if _, ok := rc.admiralDeletes.Load(key); !ok {
+		return false
+	}
+	rc.admiralDeletes.Delete(key)
+	return true
+}
+
+//returns the identity/env a host was generated for, the hosts added by a GTP dns prefix are looked up without their prefix
+func (r *RemoteRegistry) getIdentityEnvForHost(host string) (identityEnv, bool) {
+	if value, ok := r.AdmiralCache.HostIdentityEnvCache.Load(host); ok {
+		return value.(identityEnv), true
+	}
+	parts := strings.SplitN(host, common.Sep, 2)
+	if len(parts) == 2 {
+		if value, ok := r.AdmiralCache.HostIdentityEnvCache.Load(parts[1]); ok {
+			return value.(identityEnv), true
+		}
+	}
+	return identityEnv{}, false
+}
+
+func (r *RemoteRegistry) recordDriftCorrection(correction DriftCorrection) {
+	r.driftMutex.Lock()
+	defer r.driftMutex.Unlock()
+	r.driftCorrections = append(r.driftCorrections, correction)
+	if len(r.driftCorrections) > maxDriftCorrections {
+		r.driftCorrections = r.driftCorrections[len(r.driftCorrections)-maxDriftCorrections:]
+	}
+}
+
+// GetDriftCorrections returns the most recent drift corrections, the latest last
+func (r *RemoteRegistry) GetDriftCorrections() []DriftCorrection {
+	r.driftMutex.Lock()
+	defer r.driftMutex.Unlock()
+	corrections := make([]DriftCorrection, len(r.driftCorrections))
+	copy(corrections, r.driftCorrections)
+	return corrections
+}
@@ -0,0 +1,115 @@
+package clusters
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	istionetworkingv1alpha3 "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+)
+
+func TestCheckDrift(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rr := NewRemoteRegistry(ctx, common.AdmiralParams{})
+	rr.StartTime = time.Now().Add(-time.Hour)
+	reconciles := make(chan identityEnv, 10)
+	rr.reconcileQueue = newIdentityReconcileQueue(ctx, func(identity string, env string) error {
+		reconciles <- identityEnv{identity: identity, env: env}
+		return nil
+	})
+	rr.reconcileQueue.debounce = 0
+	go rr.reconcileQueue.run()
+
+	rr.AdmiralCache.HostIdentityEnvCache.Store("e2e.bar.mesh", identityEnv{identity: "bar", env: "e2e"})
+	rr.AdmiralCache.SeClusterCache.Put("e2e.bar.mesh", "cluster1", "cluster1")
+	rr.AdmiralCache.SeClusterCache.Put("west.e2e.bar.mesh", "cluster1", "cluster1")
+
+	newServiceEntry := func(host string, address string, createdByAdmiral bool) *v1alpha3.ServiceEntry {
+		se := &v1alpha3.ServiceEntry{
+			ObjectMeta: getSweeperTestObjectMeta(getIstioResourceName(host, "-se"), "ns", createdByAdmiral),
+			Spec: istionetworkingv1alpha3.ServiceEntry{
+				Hosts:     []string{host},
+				Endpoints: []*istionetworkingv1alpha3.ServiceEntry_Endpoint{{Address: address}},
+			},
+		}
+		if createdByAdmiral {
+			se.Annotations[common.SpecHashAnnotation], _ = common.GetSpecHash(&se.Spec)
+		}
+		return se
+	}
+	modified := func(se *v1alpha3.ServiceEntry) *v1alpha3.ServiceEntry {
+		se.Spec.Endpoints[0].Address = "hand-edited.mesh"
+		return se
+	}
+	newDestinationRule := func(host string) *v1alpha3.DestinationRule {
+		dr := &v1alpha3.DestinationRule{
+			ObjectMeta: getSweeperTestObjectMeta(getIstioResourceName(host, "-dr"), "ns", true),
+			Spec:       istionetworkingv1alpha3.DestinationRule{Host: host},
+		}
+		dr.Annotations[common.SpecHashAnnotation], _ = common.GetSpecHash(&dr.Spec)
+		dr.Spec.TrafficPolicy = &istionetworkingv1alpha3.TrafficPolicy{}
+		return dr
+	}
+
+	seHandler := &ServiceEntryHandler{RemoteRegistry: rr, ClusterID: "cluster1"}
+	drHandler := &DestinationRuleHandler{RemoteRegistry: rr, ClusterID: "cluster1"}
+
+	//no drift
+	seHandler.Updated(newServiceEntry("e2e.bar.mesh", "east.mesh", true))
+	seHandler.Updated(modified(newServiceEntry("e2e.bar.mesh", "east.mesh", false)))
+	seHandler.Deleted(newServiceEntry("e2e.foo.mesh", "east.mesh", true))
+	otherClusterHandler := &ServiceEntryHandler{RemoteRegistry: rr, ClusterID: "cluster2"}
+	otherClusterHandler.Deleted(newServiceEntry("e2e.bar.mesh", "east.mesh", true))
+	//deleted by Admiral before SeClusterCache was updated, the next delete isn't
+	rc := &RemoteController{ClusterID: "cluster1"}
+	rr.PutRemoteController(rc.ClusterID, rc)
+	markAdmiralDelete(rc, common.ServiceEntry, "ns", "e2e.bar.mesh-se")
+	seHandler.Deleted(newServiceEntry("e2e.bar.mesh", "east.mesh", true))
+
+	//drift
+	seHandler.Updated(modified(newServiceEntry("e2e.bar.mesh", "east.mesh", true)))
+	seHandler.Deleted(newServiceEntry("e2e.bar.mesh", "east.mesh", true))
+	drHandler.Updated(newDestinationRule("west.e2e.bar.mesh"))
+
+	//the corrections of an identity/env are coalesced in the reconcile queue
+	select {
+	case ie := <-reconciles:
+		if ie != (identityEnv{identity: "bar", env: "e2e"}) {
+			t.Errorf("Wanted bar/e2e reconciled, got %v", ie)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for the reconcile of the drifted objects")
+	}
+
+	drifts := make([]string, 0)
+	for _, correction := range rr.GetDriftCorrections() {
+		drifts = append(drifts, string(correction.ObjectType)+"/"+correction.Name+"/"+correction.Drift)
+	}
+	expected := []string{
+		"ServiceEntry/e2e.bar.mesh-se/modified",
+		"ServiceEntry/e2e.bar.mesh-se/deleted",
+		"DestinationRule/west.e2e.bar.mesh-dr/modified",
+	}
+	if !reflect.DeepEqual(drifts, expected) {
+		t.Errorf("Wanted drift corrections %v, got %v", expected, drifts)
+	}
+}
+
+func TestGetDriftCorrectionsKeepsTheMostRecent(t *testing.T) {
+	rr := NewRemoteRegistry(context.TODO(), common.AdmiralParams{})
+	for i := 0; i < maxDriftCorrections+5; i++ {
+		rr.recordDriftCorrection(DriftCorrection{Name: "e2e.foo.mesh-se", Time: time.Unix(int64(i), 0)})
+	}
+	corrections := rr.GetDriftCorrections()
+	if len(corrections) != maxDriftCorrections {
+		t.Fatalf("Wanted %v corrections, got %v", maxDriftCorrections, len(corrections))
+	}
+	if corrections[0].Time.Unix() != 5 {
+		t.Errorf("Wanted the oldest corrections dropped, got %v first", corrections[0].Time)
+	}
+}
@@ -0,0 +1,127 @@
+package clusters
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PendingChange is a create, update or delete that Admiral would have made to a cluster if it wasn't running with --dry_run
+type PendingChange struct {
+	Time       time.Time           `json:"Time"`
+	Cluster    string              `json:"Cluster"`
+	Identity   string              `json:"Identity"`
+	ObjectType common.ResourceType `json:"ObjectType"`
+	Namespace  string              `json:"Namespace"`
+	Name       string              `json:"Name"`
+	Op         string              `json:"Op"`
+	Diff       string              `json:"Diff"`
+	//host of the object, used to look up its identity when it isn't labeled with it
+	host string
+}
+
+/*
+pendingChanges keeps the changes recorded with --dry_run, by cluster and object. It's shared by the RemoteRegistry and its RemoteControllers.
+As nothing is written with --dry_run, every reconcile compares the desired objects with the same live objects,
+the latest change recorded for an object is the whole change Admiral would make to it.
+*/
+type pendingChanges struct {
+	mutex   sync.Mutex
+	changes map[string]map[string]PendingChange
+}
+
+func newPendingChanges() *pendingChanges {
+	return &pendingChanges{changes: make(map[string]map[string]PendingChange)}
+}
+
+//the address of the ServiceEntries of new hosts with --dry_run, outside the range GenerateNewAddressAndAddToConfigMap allocates from
+const dryRunAddress = common.LocalAddressPrefix + ".0.0"
+
+//ignores the fields the protobuf library keeps for itself in the istio api types
+var ignoreProtoInternals = cmp.FilterPath(func(p cmp.Path) bool {
+	field, ok := p.Last().(cmp.StructField)
+	return ok && strings.HasPrefix(field.Name(), "XXX_")
+}, cmp.Ignore())
+
+/*
+recordPendingChange records the change instead of writing it when Admiral runs with --dry_run, and returns true when it did.
+before and after are the live and desired specs, the spec missing on create or delete is passed as the zero value of the spec type.
+*/
+func recordPendingChange(rc *RemoteController, objectType common.ResourceType, obj *v12.ObjectMeta, namespace string, op string, host string, before interface{}, after interface{}) bool {
+	if !common.GetDryRun() {
+		return false
+	}
+	change := PendingChange{
+		Time:       time.Now(),
+		Cluster:    rc.ClusterID,
+		Identity:   obj.Labels[common.GetWorkloadIdentifier()],
+		ObjectType: objectType,
+		Namespace:  namespace,
+		Name:       obj.Name,
+		Op:         op,
+		Diff:       cmp.Diff(before, after, ignoreProtoInternals),
+		host:       host,
+	}
+	//the change isn't kept for a RemoteController that isn't registered
+	if p := rc.pendingChanges; p != nil {
+		p.mutex.Lock()
+		if p.changes[rc.ClusterID] == nil {
+			p.changes[rc.ClusterID] = make(map[string]PendingChange)
+		}
+		p.changes[rc.ClusterID][getPendingChangeKey(objectType, namespace, obj.Name)] = change
+		p.mutex.Unlock()
+	}
+	log.Infof(LogFormat+" diff=%s", op, objectType, obj.Name, rc.ClusterID, "Dry run, change not written", change.Diff)
+	return true
+}
+
+//forgets the change recorded for an object that is up to date again
+func clearPendingChange(rc *RemoteController, objectType common.ResourceType, namespace string, name string) {
+	p := rc.pendingChanges
+	if !common.GetDryRun() || p == nil {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	delete(p.changes[rc.ClusterID], getPendingChangeKey(objectType, namespace, name))
+}
+
+func getPendingChangeKey(objectType common.ResourceType, namespace string, name string) string {
+	return string(objectType) + "/" + namespace + "/" + name
+}
+
+/*
+GetPendingChanges returns the changes Admiral would make to the clusters with --dry_run, by cluster and identity.
+The identity of an object is its identity label, or the identity its host was generated for.
+The changes of the objects without an identity, like the workload sidecars, are listed with an empty identity.
+*/
+func (r *RemoteRegistry) GetPendingChanges() map[string]map[string][]PendingChange {
+	r.pendingChanges.mutex.Lock()
+	defer r.pendingChanges.mutex.Unlock()
+	changes := make(map[string]map[string][]PendingChange)
+	for cluster, clusterChanges := range r.pendingChanges.changes {
+		byIdentity := make(map[string][]PendingChange)
+		for _, change := range clusterChanges {
+			if len(change.Identity) == 0 && len(change.host) > 0 {
+				if ie, ok := r.getIdentityEnvForHost(change.host); ok {
+					change.Identity = ie.identity
+				}
+			}
+			byIdentity[change.Identity] = append(byIdentity[change.Identity], change)
+		}
+		for _, identityChanges := range byIdentity {
+			sort.Slice(identityChanges, func(i, j int) bool {
+				return getPendingChangeKey(identityChanges[i].ObjectType, identityChanges[i].Namespace, identityChanges[i].Name) <
+					getPendingChangeKey(identityChanges[j].ObjectType, identityChanges[j].Namespace, identityChanges[j].Name)
+			})
+		}
+		changes[cluster] = byIdentity
+	}
+	return changes
+}
@@ -0,0 +1,185 @@
+package clusters
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/test"
+	istionetworkingv1alpha3 "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestDryRunRecordsPendingChanges(t *testing.T) {
+	common.SetDryRun(true)
+	defer common.SetDryRun(false)
+
+	liveServiceEntry := &v1alpha3.ServiceEntry{
+		ObjectMeta: v12.ObjectMeta{Name: "e2e.bar.mesh-se", Namespace: "ns", Labels: map[string]string{"identity": "bar"}},
+		Spec: istionetworkingv1alpha3.ServiceEntry{
+			Hosts:     []string{"e2e.bar.mesh"},
+			Endpoints: []*istionetworkingv1alpha3.ServiceEntry_Endpoint{{Address: "east.mesh"}},
+		},
+	}
+	liveDestinationRule := &v1alpha3.DestinationRule{
+		ObjectMeta: v12.ObjectMeta{Name: "e2e.foo.mesh-default-dr", Namespace: "ns"},
+		Spec:       istionetworkingv1alpha3.DestinationRule{Host: "e2e.foo.mesh"},
+	}
+	liveSidecar := &v1alpha3.Sidecar{
+		ObjectMeta: v12.ObjectMeta{Name: "default", Namespace: "bar-ns"},
+		Spec: istionetworkingv1alpha3.Sidecar{
+			Egress: []*istionetworkingv1alpha3.IstioEgressListener{{Hosts: []string{"./*"}}},
+		},
+	}
+	fakeIstioClient := istiofake.NewSimpleClientset(liveServiceEntry, liveDestinationRule, liveSidecar)
+	rc := &RemoteController{
+		ClusterID:                 "cluster1",
+		ServiceEntryController:    &istio.ServiceEntryController{IstioClient: fakeIstioClient},
+		DestinationRuleController: &istio.DestinationRuleController{IstioClient: fakeIstioClient},
+		VirtualServiceController:  &istio.VirtualServiceController{IstioClient: fakeIstioClient},
+		SidecarController:         &istio.SidecarController{IstioClient: fakeIstioClient},
+	}
+	rr := NewRemoteRegistry(context.TODO(), common.AdmiralParams{})
+	rr.PutRemoteController(rc.ClusterID, rc)
+	rr.AdmiralCache.HostIdentityEnvCache.Store("e2e.foo.mesh", identityEnv{identity: "foo", env: "e2e"})
+
+	updatedServiceEntry := liveServiceEntry.DeepCopy()
+	updatedServiceEntry.Spec.Endpoints[0].Address = "west.mesh"
+	if err := addUpdateServiceEntry(updatedServiceEntry, liveServiceEntry.DeepCopy(), "ns", rc); err != nil {
+		t.Fatalf("%v", err)
+	}
+	newServiceEntry := &v1alpha3.ServiceEntry{
+		ObjectMeta: v12.ObjectMeta{Name: "e2e.baz.mesh-se", Labels: map[string]string{"identity": "baz"}},
+		Spec:       istionetworkingv1alpha3.ServiceEntry{Hosts: []string{"e2e.baz.mesh"}},
+	}
+	if err := addUpdateServiceEntry(newServiceEntry, nil, "ns", rc); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := deleteDestinationRule(liveDestinationRule.DeepCopy(), "ns", rc); err != nil {
+		t.Fatalf("%v", err)
+	}
+	newSidecar := liveSidecar.DeepCopy()
+	newSidecar.Spec.Egress[0].Hosts = append(newSidecar.Spec.Egress[0].Hosts, "ns/e2e.foo.mesh")
+	addUpdateSidecar(newSidecar, liveSidecar.DeepCopy(), "bar-ns", rc)
+
+	for _, action := range fakeIstioClient.Actions() {
+		if verb := action.GetVerb(); verb != "get" && verb != "list" && verb != "watch" {
+			t.Errorf("Wanted nothing written with --dry_run, got a %v of %v", verb, action.GetResource().Resource)
+		}
+	}
+
+	changes := rr.GetPendingChanges()["cluster1"]
+	expected := map[string]string{
+		"bar": "Update ServiceEntry ns/e2e.bar.mesh-se",
+		"baz": "Add ServiceEntry ns/e2e.baz.mesh-se",
+		"foo": "Delete DestinationRule ns/e2e.foo.mesh-default-dr",
+		"":    "Update Sidecar bar-ns/default",
+	}
+	if len(changes) != len(expected) {
+		t.Errorf("Wanted changes for %v identities, got %v", len(expected), changes)
+	}
+	for identity, change := range expected {
+		if len(changes[identity]) != 1 {
+			t.Errorf("Wanted a change for identity %q, got %v", identity, changes[identity])
+			continue
+		}
+		got := changes[identity][0]
+		if summary := got.Op + " " + string(got.ObjectType) + " " + got.Namespace + "/" + got.Name; summary != change {
+			t.Errorf("Wanted %q for identity %q, got %q", change, identity, summary)
+		}
+		if len(got.Diff) == 0 {
+			t.Errorf("Wanted the diff of %q", change)
+		}
+	}
+	if diff := changes["bar"][0].Diff; !strings.Contains(diff, "east.mesh") || !strings.Contains(diff, "west.mesh") {
+		t.Errorf("Wanted the endpoint change in the diff, got %v", diff)
+	}
+
+	//the ServiceEntry wanted as it is live has no pending change anymore
+	live, _ := rc.ServiceEntryController.Get("ns", "e2e.bar.mesh-se")
+	live.Annotations = map[string]string{}
+	setSpecHash(&live.ObjectMeta, &live.Spec)
+	if err := addUpdateServiceEntry(liveServiceEntry.DeepCopy(), live, "ns", rc); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if changes := rr.GetPendingChanges()["cluster1"]["bar"]; len(changes) != 0 {
+		t.Errorf("Wanted the pending change of the up to date ServiceEntry cleared, got %v", changes)
+	}
+}
+
+func TestDryRunRecordsNewServiceEntry(t *testing.T) {
+	common.SetDryRun(true)
+	defer common.SetDryRun(false)
+	stop := make(chan struct{})
+	defer close(stop)
+
+	//no address is allocated yet for the host of bar
+	rr := NewRemoteRegistry(context.TODO(), common.AdmiralParams{})
+	rr.StartTime = time.Now().Add(-time.Hour)
+
+	deployment := &k8sAppsV1.Deployment{
+		ObjectMeta: v12.ObjectMeta{Name: "bar", Namespace: "bar-ns"},
+		Spec: k8sAppsV1.DeploymentSpec{
+			Selector: &v12.LabelSelector{MatchLabels: map[string]string{"app": "bar"}},
+			Template: coreV1.PodTemplateSpec{
+				ObjectMeta: v12.ObjectMeta{Labels: map[string]string{"identity": "bar", "env": "test", "app": "bar"}},
+			},
+		},
+	}
+	service := &coreV1.Service{
+		ObjectMeta: v12.ObjectMeta{Name: "bar", Namespace: "bar-ns"},
+		Spec: coreV1.ServiceSpec{
+			Selector: map[string]string{"app": "bar"},
+			Ports:    []coreV1.ServicePort{{Name: "http", Port: 8080}},
+		},
+	}
+	config := rest.Config{Host: "localhost"}
+	gtpc, err := admiral.NewGlobalTrafficController("cluster1", stop, &test.MockGlobalTrafficHandler{}, &config, time.Second*time.Duration(300))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	fakeIstioClient := istiofake.NewSimpleClientset()
+	rc := newReconcileTestRemoteController(t, "cluster1", stop)
+	rc.ServiceEntryController = &istio.ServiceEntryController{IstioClient: fakeIstioClient}
+	rc.DestinationRuleController = &istio.DestinationRuleController{IstioClient: fakeIstioClient}
+	rc.NodeController = &admiral.NodeController{Locality: &admiral.Locality{Region: "us-west-2"}}
+	rc.GlobalTraffic = gtpc
+	rc.DeploymentController.Cache.UpdateDeploymentToClusterCache("bar", deployment)
+	rc.ServiceController.Cache.Put(service)
+	rr.PutRemoteController("cluster1", rc)
+
+	if _, err := modifyServiceEntryForNewServiceOrPod(admiral.Add, "test", "bar", rr); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	for _, action := range fakeIstioClient.Actions() {
+		if verb := action.GetVerb(); verb != "get" && verb != "list" && verb != "watch" {
+			t.Errorf("Wanted nothing written with --dry_run, got a %v of %v", verb, action.GetResource().Resource)
+		}
+	}
+	if len(rr.AdmiralCache.ServiceEntryAddressStore.EntryAddresses) != 0 {
+		t.Errorf("Wanted no address allocated with --dry_run, got %v", rr.AdmiralCache.ServiceEntryAddressStore.EntryAddresses)
+	}
+
+	var seChange PendingChange
+	for _, change := range rr.GetPendingChanges()["cluster1"]["bar"] {
+		if change.ObjectType == common.ServiceEntry && change.Name == "test.bar.mesh-se" {
+			seChange = change
+		}
+	}
+	if seChange.Op != "Add" {
+		t.Fatalf("Wanted the creation of test.bar.mesh-se recorded, got %v", rr.GetPendingChanges())
+	}
+	if !strings.Contains(seChange.Diff, dryRunAddress) {
+		t.Errorf("Wanted the placeholder address %v in the diff, got %v", dryRunAddress, seChange.Diff)
+	}
+}
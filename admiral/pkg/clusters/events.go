@@ -0,0 +1,170 @@
+package clusters
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	k8sV1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedCoreV1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	//an event identical to one recorded less than this long ago is dropped, the reconciles and informer resyncs would repeat it otherwise
+	eventDedupInterval = 30 * time.Minute
+	//number of recorded events remembered, the ones older than eventDedupInterval are forgotten first, then the oldest ones
+	maxRecordedEvents = 10000
+
+	identityNotFoundReason  = "IdentityNotFound"
+	serviceNotFoundReason   = "ServiceNotFound"
+	meshPortsNotFoundReason = "MeshPortsNotFound"
+	localityNotFoundReason  = "LocalityNotFound"
+	gtpAppliedReason        = "GlobalTrafficPolicyApplied"
+	gtpIgnoredReason        = "GlobalTrafficPolicyIgnored"
+	sourceNotFoundReason    = "SourceNotFound"
+)
+
+// api version and kind of the objects events are recorded on, by workload kind
+var workloadTypeMeta = map[string]v12.TypeMeta{
+	deploymentKind:  {APIVersion: "apps/v1", Kind: "Deployment"},
+	rolloutKind:     {APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout"},
+	statefulSetKind: {APIVersion: "apps/v1", Kind: "StatefulSet"},
+}
+
+var (
+	gtpTypeMeta        = v12.TypeMeta{APIVersion: v1.SchemeGroupVersion.String(), Kind: "GlobalTrafficPolicy"}
+	dependencyTypeMeta = v12.TypeMeta{APIVersion: v1.SchemeGroupVersion.String(), Kind: "Dependency"}
+	namespaceTypeMeta  = v12.TypeMeta{APIVersion: "v1", Kind: "Namespace"}
+)
+
+/*
+eventRecorder records Kubernetes events on the objects of a cluster, so the teams owning them see with kubectl describe why Admiral left them out.
+Identical events are recorded once per eventDedupInterval, the client-go recorder aggregates the ones it still receives.
+Nothing is recorded while Admiral is read-only or runs with --dry_run.
+*/
+type eventRecorder struct {
+	clusterID   string
+	broadcaster record.EventBroadcaster
+	recorder    record.EventRecorder
+	mutex       sync.Mutex
+	//key=object, type, reason and message of an event, value=time it was recorded
+	recorded map[string]time.Time
+}
+
+func newEventRecorder(clusterID string, client kubernetes.Interface) *eventRecorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedCoreV1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+	return &eventRecorder{
+		clusterID:   clusterID,
+		broadcaster: broadcaster,
+		recorder:    broadcaster.NewRecorder(scheme.Scheme, k8sV1.EventSource{Component: common.CreatedByAdmiral}),
+		recorded:    make(map[string]time.Time),
+	}
+}
+
+// records the event on the object, a no-op on a nil recorder
+func (e *eventRecorder) event(typeMeta v12.TypeMeta, obj *v12.ObjectMeta, eventType string, reason string, message string) {
+	if e == nil || IsAdmiralReadOnly() || common.GetDryRun() {
+		return
+	}
+	key := fmt.Sprintf("%s/%s/%s/%s/%s/%s/%s", typeMeta.Kind, obj.Namespace, obj.Name, obj.UID, eventType, reason, message)
+	now := time.Now()
+	e.mutex.Lock()
+	if recorded, ok := e.recorded[key]; ok && now.Sub(recorded) < eventDedupInterval {
+		e.mutex.Unlock()
+		return
+	}
+	if len(e.recorded) >= maxRecordedEvents {
+		for k, recorded := range e.recorded {
+			if now.Sub(recorded) >= eventDedupInterval {
+				delete(e.recorded, k)
+			}
+		}
+	}
+	if len(e.recorded) >= maxRecordedEvents {
+		//a tenth is evicted at once so the recorded events aren't sorted on every event
+		e.evictOldest(len(e.recorded) - maxRecordedEvents*9/10)
+	}
+	e.recorded[key] = now
+	e.mutex.Unlock()
+
+	log.Debugf(LogFormat, "Event", typeMeta.Kind, obj.Name, e.clusterID, fmt.Sprintf("Recording %s event reason=%s message=%s", eventType, reason, message))
+	e.recorder.Event(&k8sV1.ObjectReference{
+		APIVersion:      typeMeta.APIVersion,
+		Kind:            typeMeta.Kind,
+		Namespace:       obj.Namespace,
+		Name:            obj.Name,
+		UID:             obj.UID,
+		ResourceVersion: obj.ResourceVersion,
+	}, eventType, reason, message)
+}
+
+//forgets the count oldest recorded events, an event recorded again afterwards isn't deduplicated
+func (e *eventRecorder) evictOldest(count int) {
+	keys := make([]string, 0, len(e.recorded))
+	for k := range e.recorded {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return e.recorded[keys[i]].Before(e.recorded[keys[j]])
+	})
+	for _, k := range keys[:count] {
+		delete(e.recorded, k)
+	}
+}
+
+func (e *eventRecorder) shutdown() {
+	if e != nil {
+		e.broadcaster.Shutdown()
+	}
+}
+
+//returns the event recorder of the cluster, nil when the cluster is unknown
+func (r *RemoteRegistry) getEventRecorder(clusterID string) *eventRecorder {
+	if rc := r.GetRemoteController(clusterID); rc != nil {
+		return rc.eventRecorder
+	}
+	return nil
+}
+
+func (e *eventRecorder) workloadEvent(workload Workload, eventType string, reason string, message string) {
+	e.event(workloadTypeMeta[workload.GetKind()], workload.GetObjectMeta(), eventType, reason, message)
+}
+
+// records on each GTP of the identity/env whether it's the one applied to it
+func recordGtpEvents(rr *RemoteRegistry, identity string, env string, gtps map[string][]*v1.GlobalTrafficPolicy, activeGtp *v1.GlobalTrafficPolicy) {
+	for cluster, clusterGtps := range gtps {
+		rc := rr.GetRemoteController(cluster)
+		if rc == nil {
+			continue
+		}
+		for _, gtp := range clusterGtps {
+			if gtp.Name == activeGtp.Name && gtp.Namespace == activeGtp.Namespace {
+				rc.eventRecorder.event(gtpTypeMeta, &gtp.ObjectMeta, k8sV1.EventTypeNormal, gtpAppliedReason,
+					fmt.Sprintf("Applied to identity=%s env=%s", identity, env))
+				continue
+			}
+			rc.eventRecorder.event(gtpTypeMeta, &gtp.ObjectMeta, k8sV1.EventTypeWarning, gtpIgnoredReason,
+				fmt.Sprintf("Not applied to identity=%s env=%s, the GTP %s/%s in cluster %s has a higher priority or is more recent", identity, env, activeGtp.Namespace, activeGtp.Name, getGtpCluster(gtps, activeGtp)))
+		}
+	}
+}
+
+func getGtpCluster(gtps map[string][]*v1.GlobalTrafficPolicy, gtp *v1.GlobalTrafficPolicy) string {
+	for cluster, clusterGtps := range gtps {
+		for _, clusterGtp := range clusterGtps {
+			if clusterGtp == gtp {
+				return cluster
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+package clusters
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	k8sV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func newTestEventRecorder() (*eventRecorder, *record.FakeRecorder) {
+	fakeRecorder := record.NewFakeRecorder(10)
+	return &eventRecorder{clusterID: "cluster1", recorder: fakeRecorder, recorded: make(map[string]time.Time)}, fakeRecorder
+}
+
+func getRecordedEvents(fakeRecorder *record.FakeRecorder) []string {
+	events := make([]string, 0)
+	for {
+		select {
+		case event := <-fakeRecorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestEventRecorderDeduplicatesEvents(t *testing.T) {
+	e, fakeRecorder := newTestEventRecorder()
+	deployment := newDeploymentWorkload(&k8sAppsV1.Deployment{ObjectMeta: metaV1.ObjectMeta{Name: "foo", Namespace: "foo-ns", UID: "1"}})
+
+	e.workloadEvent(deployment, k8sV1.EventTypeWarning, serviceNotFoundReason, "No service")
+	e.workloadEvent(deployment, k8sV1.EventTypeWarning, serviceNotFoundReason, "No service")
+	e.workloadEvent(deployment, k8sV1.EventTypeWarning, meshPortsNotFoundReason, "No mesh ports")
+
+	events := getRecordedEvents(fakeRecorder)
+	expected := []string{"Warning ServiceNotFound No service", "Warning MeshPortsNotFound No mesh ports"}
+	if len(events) != len(expected) || events[0] != expected[0] || events[1] != expected[1] {
+		t.Errorf("Expected events %v, got %v", expected, events)
+	}
+
+	//a repeat is recorded again once the dedup interval is over
+	for key := range e.recorded {
+		e.recorded[key] = time.Now().Add(-eventDedupInterval)
+	}
+	e.workloadEvent(deployment, k8sV1.EventTypeWarning, serviceNotFoundReason, "No service")
+	if events := getRecordedEvents(fakeRecorder); len(events) != 1 {
+		t.Errorf("Expected the event to be recorded again after the dedup interval, got %v", events)
+	}
+
+	common.SetDryRun(true)
+	e.workloadEvent(deployment, k8sV1.EventTypeNormal, serviceNotFoundReason, "Dry run")
+	common.SetDryRun(false)
+	if events := getRecordedEvents(fakeRecorder); len(events) != 0 {
+		t.Errorf("Expected no event with --dry_run, got %v", events)
+	}
+
+	//the recorder of an unknown cluster is nil
+	var disabled *eventRecorder
+	disabled.workloadEvent(deployment, k8sV1.EventTypeWarning, serviceNotFoundReason, "No service")
+	disabled.shutdown()
+}
+
+func TestEventRecorderEvictsTheOldestEvents(t *testing.T) {
+	e, fakeRecorder := newTestEventRecorder()
+	deployment := newDeploymentWorkload(&k8sAppsV1.Deployment{ObjectMeta: metaV1.ObjectMeta{Name: "foo", Namespace: "foo-ns", UID: "1"}})
+
+	//recent events that can't be forgotten as expired
+	now := time.Now()
+	for i := 0; i < maxRecordedEvents; i++ {
+		e.recorded[strconv.Itoa(i)] = now.Add(time.Duration(i-maxRecordedEvents) * time.Second)
+	}
+	e.workloadEvent(deployment, k8sV1.EventTypeWarning, serviceNotFoundReason, "No service")
+
+	if len(e.recorded) > maxRecordedEvents*9/10+1 {
+		t.Errorf("Expected at most %v recorded events, got %v", maxRecordedEvents*9/10+1, len(e.recorded))
+	}
+	if _, ok := e.recorded["0"]; ok {
+		t.Errorf("Expected the oldest event to be evicted")
+	}
+	if _, ok := e.recorded[strconv.Itoa(maxRecordedEvents-1)]; !ok {
+		t.Errorf("Expected the most recent event to be kept")
+	}
+	if events := getRecordedEvents(fakeRecorder); len(events) != 1 {
+		t.Errorf("Expected the new event to be recorded, got %v", events)
+	}
+}
+
+func TestRecordGtpEvents(t *testing.T) {
+	rr := NewRemoteRegistry(nil, common.AdmiralParams{})
+	e1, fakeRecorder1 := newTestEventRecorder()
+	e2, fakeRecorder2 := newTestEventRecorder()
+	rr.PutRemoteController("cluster1", &RemoteController{ClusterID: "cluster1", eventRecorder: e1})
+	rr.PutRemoteController("cluster2", &RemoteController{ClusterID: "cluster2", eventRecorder: e2})
+
+	activeGtp := &v1.GlobalTrafficPolicy{ObjectMeta: metaV1.ObjectMeta{Name: "bar-gtp", Namespace: "bar-ns"}}
+	oldGtp := &v1.GlobalTrafficPolicy{ObjectMeta: metaV1.ObjectMeta{Name: "bar-gtp-old", Namespace: "bar-ns"}}
+	gtps := map[string][]*v1.GlobalTrafficPolicy{"cluster1": {activeGtp}, "cluster2": {oldGtp}}
+
+	recordGtpEvents(rr, "bar", "test", gtps, activeGtp)
+
+	if events := getRecordedEvents(fakeRecorder1); len(events) != 1 || events[0] != "Normal GlobalTrafficPolicyApplied Applied to identity=bar env=test" {
+		t.Errorf("Expected the active GTP to be reported as applied, got %v", events)
+	}
+	if events := getRecordedEvents(fakeRecorder2); len(events) != 1 || !strings.HasPrefix(events[0], "Warning GlobalTrafficPolicyIgnored") ||
+		!strings.Contains(events[0], "bar-ns/bar-gtp in cluster cluster1") {
+		t.Errorf("Expected the other GTP to be reported as ignored, got %v", events)
+	}
+}
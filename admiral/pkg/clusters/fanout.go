@@ -0,0 +1,64 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+//ClusterError is the error of the writes to a cluster that failed or timed out, the writes to the other clusters are not affected
+type ClusterError struct {
+	ClusterID string
+	Err       error
+}
+
+func (e *ClusterError) Error() string {
+	return fmt.Sprintf("cluster=%s, e=%v", e.ClusterID, e.Err)
+}
+
+/*
+forEachCluster calls fn for every cluster on at most --cluster_write_workers goroutines, and returns the aggregate of the ClusterErrors.
+Each cluster gets --cluster_write_timeout, fn should stop issuing calls once ctx is done. A worker is only freed when fn returns,
+the istio clients time out their calls after --cluster_write_timeout too, so a cluster whose API server doesn't answer holds its worker
+for two timeouts at most, and no retry writes to a cluster while a call of the previous write is still pending.
+*/
+func forEachCluster(parent context.Context, clusterIDs []string, fn func(ctx context.Context, clusterID string) error) error {
+	if parent == nil {
+		parent = context.Background()
+	}
+	sort.Strings(clusterIDs)
+
+	workers := make(chan struct{}, common.GetClusterWriteWorkers())
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	errs := make([]error, 0)
+
+	for _, clusterID := range clusterIDs {
+		workers <- struct{}{}
+		wg.Add(1)
+		go func(clusterID string) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			ctx, cancel := context.WithTimeout(parent, common.GetClusterWriteTimeout())
+			defer cancel()
+
+			if err := fn(ctx, clusterID); err != nil {
+				mutex.Lock()
+				errs = append(errs, &ClusterError{ClusterID: clusterID, Err: err})
+				mutex.Unlock()
+			}
+		}(clusterID)
+	}
+	wg.Wait()
+
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].(*ClusterError).ClusterID < errs[j].(*ClusterError).ClusterID
+	})
+	return utilerrors.NewAggregate(errs)
+}
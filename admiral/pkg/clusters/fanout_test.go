@@ -0,0 +1,76 @@
+package clusters
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+func TestForEachCluster(t *testing.T) {
+	common.SetClusterWriteTimeout(100 * time.Millisecond)
+	defer common.SetClusterWriteTimeout(0)
+
+	unblock := make(chan struct{})
+	defer close(unblock)
+
+	var mutex sync.Mutex
+	written := make([]string, 0)
+	cluster3Returned := false
+	start := time.Now()
+	err := forEachCluster(context.TODO(), []string{"cluster1", "cluster2", "cluster3", "cluster4"}, func(ctx context.Context, clusterID string) error {
+		switch clusterID {
+		case "cluster2":
+			return errors.New("connection refused")
+		case "cluster3":
+			//the api server of cluster3 doesn't answer, the client gives up at the timeout
+			select {
+			case <-unblock:
+				return nil
+			case <-ctx.Done():
+				mutex.Lock()
+				cluster3Returned = true
+				mutex.Unlock()
+				return ctx.Err()
+			}
+		}
+		mutex.Lock()
+		written = append(written, clusterID)
+		mutex.Unlock()
+		return nil
+	})
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Wanted the unreachable cluster to be given up on at the timeout, took %v", elapsed)
+	}
+	agg, ok := err.(utilerrors.Aggregate)
+	if !ok {
+		t.Fatalf("Wanted an aggregate error, got %v", err)
+	}
+	failedClusters := make([]string, 0)
+	for _, e := range agg.Errors() {
+		failedClusters = append(failedClusters, e.(*ClusterError).ClusterID)
+	}
+	if !reflect.DeepEqual(failedClusters, []string{"cluster2", "cluster3"}) {
+		t.Errorf("Wanted cluster2 and cluster3 to fail, got %v", err)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if !cluster3Returned {
+		t.Errorf("Wanted the write to cluster3 to return before its worker is freed")
+	}
+	if len(written) != 2 {
+		t.Errorf("Wanted cluster1 and cluster4 to be written, got %v", written)
+	}
+
+	if err := forEachCluster(context.TODO(), []string{"cluster1"}, func(ctx context.Context, clusterID string) error {
+		return nil
+	}); err != nil {
+		t.Errorf("Wanted no error, got %v", err)
+	}
+}
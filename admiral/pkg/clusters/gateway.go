@@ -0,0 +1,66 @@
+package clusters
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	k8sV1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	gatewayReadinessOp    = "GatewayReadiness"
+	gatewayNotFoundReason = "GatewayNotFound"
+)
+
+/*
+updateGatewayReadiness looks up the east west gateways of the cluster after a change to a service in istio-system.
+The endpoints of a cluster are left out of the ServiceEntries while its gateways don't have an address, as they would black-hole the traffic.
+The identities running in the cluster are reconciled when the gateway addresses change, so the endpoints are added back as soon as a gateway gets one.
+*/
+func updateGatewayReadiness(rr *RemoteRegistry, rc *RemoteController) {
+	loadBalancers := getLoadBalancers(rc)
+	addresses := make([]string, 0, len(loadBalancers))
+	for _, loadBalancer := range loadBalancers {
+		addresses = append(addresses, fmt.Sprintf("%s:%d", loadBalancer.Address, loadBalancer.Port))
+	}
+	current := strings.Join(addresses, ",")
+
+	var previous string
+	value, known := rr.AdmiralCache.GatewayAddressCache.Load(rc.ClusterID)
+	if known {
+		previous = value.(string)
+	}
+
+	if len(current) == 0 {
+		common.GatewayReady.With(rc.ClusterID).Set(0)
+	} else {
+		common.GatewayReady.With(rc.ClusterID).Set(1)
+	}
+	if known && current == previous {
+		return
+	}
+	//the gateways may not have been received yet
+	if len(current) == 0 && IsCacheWarmupTime(rr) {
+		return
+	}
+	rr.AdmiralCache.GatewayAddressCache.Store(rc.ClusterID, current)
+
+	if len(current) == 0 {
+		message := fmt.Sprintf("No address found for the east west gateways matching %s in %s, the endpoints of the cluster are left out of the ServiceEntries", common.GetClusterGatewaySelector(rc.ClusterID), common.NamespaceIstioSystem)
+		log.Warnf(LogFormat, gatewayReadinessOp, "gateway", common.GetClusterGatewaySelector(rc.ClusterID), rc.ClusterID, message)
+		recordGatewayNotFoundEvent(rc, message)
+	} else {
+		log.Infof(LogFormat, gatewayReadinessOp, "gateway", common.GetClusterGatewaySelector(rc.ClusterID), rc.ClusterID, "Gateway addresses changed to="+current)
+	}
+	if len(current) > 0 || len(previous) > 0 {
+		rr.triggerClusterReconcile(rc.ClusterID)
+	}
+}
+
+//records a warning event on the istio-system namespace of the cluster, only called when the gateways are found without an address so the event isn't repeated
+func recordGatewayNotFoundEvent(rc *RemoteController, message string) {
+	rc.eventRecorder.event(namespaceTypeMeta, &v12.ObjectMeta{Name: common.NamespaceIstioSystem}, k8sV1.EventTypeWarning, gatewayNotFoundReason, message)
+}
@@ -0,0 +1,70 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/test"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+func TestUpdateGatewayReadiness(t *testing.T) {
+	config := rest.Config{
+		Host: "localhost",
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	s, e := admiral.NewServiceController("test", stop, &test.MockServiceHandler{}, &config, time.Second*time.Duration(300))
+	if e != nil {
+		t.Fatalf("%v", e)
+	}
+	s.K8sClient = fake.NewSimpleClientset()
+
+	common.SetClusterGatewaySelectors(map[string]string{"cluster1": "istio=eastwestgateway"})
+	defer common.SetClusterGatewaySelectors(nil)
+
+	//cancelled so the cluster reconciles triggered by the gateway changes don't outlive the test and race on the params reset above
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rr := NewRemoteRegistry(ctx, common.AdmiralParams{})
+	rr.StartTime = time.Now().Add(-time.Hour)
+	recorder, fakeRecorder := newTestEventRecorder()
+	rc := &RemoteController{ClusterID: "cluster1", ServiceController: s, eventRecorder: recorder}
+	rr.PutRemoteController(rc.ClusterID, rc)
+
+	getGatewayAddresses := func() interface{} {
+		addresses, _ := rr.AdmiralCache.GatewayAddressCache.Load(rc.ClusterID)
+		return addresses
+	}
+
+	updateGatewayReadiness(rr, rc)
+	updateGatewayReadiness(rr, rc)
+	if addresses := getGatewayAddresses(); addresses != "" {
+		t.Errorf("Wanted no gateway address, got %v", addresses)
+	}
+	if count := len(getRecordedEvents(fakeRecorder)); count != 1 {
+		t.Errorf("Wanted a single event while the gateway is missing, got %v", count)
+	}
+
+	gateway := newTestGatewayService("eastwestgateway.com")
+	s.Cache.Put(gateway)
+	updateGatewayReadiness(rr, rc)
+	if addresses := getGatewayAddresses(); addresses != "eastwestgateway.com:15443" {
+		t.Errorf("Wanted the address of the gateway, got %v", addresses)
+	}
+
+	s.Cache.Delete(gateway)
+	updateGatewayReadiness(rr, rc)
+	updateGatewayReadiness(rr, rc)
+	if addresses := getGatewayAddresses(); addresses != "" {
+		t.Errorf("Wanted no gateway address after the gateway was deleted, got %v", addresses)
+	}
+	//the recorder drops the event recorded less than eventDedupInterval ago
+	if count := len(getRecordedEvents(fakeRecorder)); count != 0 {
+		t.Errorf("Wanted the repeated event to be deduplicated, got %v", count)
+	}
+}
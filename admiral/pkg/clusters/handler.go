@@ -65,6 +65,12 @@ func getIstioResourceName(host string, suffix string) string {
 	return strings.ToLower(host) + suffix
 }
 
+/*
+getDestinationRule generates the DestinationRule of the ServiceEntry for a client cluster in the locality.
+Zones are only handled by the distribute settings of a FAILOVER policy with targets naming a zone. The failover settings of Istio
+only go from a region to another, so TOPOLOGY DestinationRules get no locality settings and rely on the locality priority of Istio,
+which fails over from the zone of the client to the other zones of its region before the other regions, based on the endpoint localities.
+*/
 func getDestinationRule(se *v1alpha32.ServiceEntry, locality string, gtpTrafficPolicy *model.TrafficPolicy) *v1alpha32.DestinationRule {
 	var dr = &v1alpha32.DestinationRule{}
 	dr.Host = se.Hosts[0]
@@ -85,17 +91,21 @@ func getDestinationRule(se *v1alpha32.ServiceEntry, locality string, gtpTrafficP
 			if gtpTrafficPolicy.LbType == model.TrafficPolicy_FAILOVER {
 				distribute := make([]*v1alpha32.LocalityLoadBalancerSetting_Distribute, 0)
 				targetTrafficMap := make(map[string]uint32)
+				zoneTargets := false
 				for _, tg := range gtpTrafficPolicy.Target {
 					//skip 0 values from GTP as that's implicit for locality settings
 					if tg.Weight != int32(0) {
-						targetTrafficMap[tg.Region] = uint32(tg.Weight)
+						targetTrafficMap[getTargetLocality(tg)] = uint32(tg.Weight)
 					}
+					zoneTargets = zoneTargets || len(tg.Zone) > 0
 				}
 				distribute = append(distribute, &v1alpha32.LocalityLoadBalancerSetting_Distribute{
-					From: locality + "/*",
+					From: getDistributeFrom(locality, zoneTargets),
 					To:   targetTrafficMap,
 				})
 				localityLbSettings.Distribute = distribute
+			} else if gtpTrafficPolicy.LbType == model.TrafficPolicy_FAILOVER_PRIORITY {
+				localityLbSettings.Failover = getFailover(locality, gtpTrafficPolicy.Target)
 			}
 			// else default behavior
 			loadBalancerSettings.LocalityLbSetting = localityLbSettings
@@ -103,9 +113,97 @@ func getDestinationRule(se *v1alpha32.ServiceEntry, locality string, gtpTrafficP
 		}
 	}
 	dr.TrafficPolicy.OutlierDetection = getOutlierDetection(se, locality, gtpTrafficPolicy)
+	setTcpTrafficPolicy(dr.TrafficPolicy, se)
 	return dr
 }
 
+//a target naming a zone only gets the traffic of that zone, other targets get the traffic of the whole region
+func getTargetLocality(target *model.TrafficGroup) string {
+	if len(target.Zone) == 0 {
+		return target.Region
+	}
+	return target.Region + common.Slash + target.Zone + "/*"
+}
+
+/*
+Returns the locality the traffic is distributed from, the region of the cluster when the targets are regions,
+and the zone of the cluster when some targets name a zone, so clients in different zones of a region can be sent to different targets.
+*/
+func getDistributeFrom(locality string, zoneTargets bool) string {
+	localityParts := strings.Split(locality, common.Slash)
+	if zoneTargets && len(localityParts) > 1 {
+		return localityParts[0] + common.Slash + localityParts[1] + "/*"
+	}
+	return localityParts[0] + "/*"
+}
+
+/*
+Returns the region the traffic of the cluster fails over to with a FAILOVER_PRIORITY policy, the first region of the targets other than the region of the cluster.
+Istio fails over from a region to a single other region, the targets after it are only used by the clusters in their regions.
+The traffic stays in the region of the cluster until outlier detection ejects its endpoints, the weights and zones of the targets are ignored.
+Nothing is returned when the targets only name the region of the cluster, istio then fails over to the closest localities.
+*/
+func getFailover(locality string, targets []*model.TrafficGroup) []*v1alpha32.LocalityLoadBalancerSetting_Failover {
+	region := strings.Split(locality, common.Slash)[0]
+	for _, tg := range targets {
+		if len(tg.Region) > 0 && tg.Region != region {
+			return []*v1alpha32.LocalityLoadBalancerSetting_Failover{{From: region, To: tg.Region}}
+		}
+	}
+	return nil
+}
+
+/*
+Opaque tcp ports (tcp, tls, mongo, mysql, redis) never see http responses, so ejecting on gateway errors has no effect on them.
+For those ports the configured threshold is applied to consecutive 5xx errors instead, which envoy counts as connection failures for tcp,
+and tcp keepalive is enabled to detect dead connections through the gateways.
+When the service entry mixes http and tcp ports the tcp settings are applied per port.
+*/
+func setTcpTrafficPolicy(trafficPolicy *v1alpha32.TrafficPolicy, se *v1alpha32.ServiceEntry) {
+	tcpPorts := make([]*v1alpha32.Port, 0)
+	for _, port := range se.Ports {
+		if !isHttpProtocol(port.Protocol) {
+			tcpPorts = append(tcpPorts, port)
+		}
+	}
+	if len(tcpPorts) == 0 {
+		return
+	}
+	if len(tcpPorts) == len(se.Ports) {
+		trafficPolicy.OutlierDetection = getTcpOutlierDetection(trafficPolicy.OutlierDetection)
+		trafficPolicy.ConnectionPool = getTcpConnectionPool()
+		return
+	}
+	for _, port := range tcpPorts {
+		trafficPolicy.PortLevelSettings = append(trafficPolicy.PortLevelSettings, &v1alpha32.TrafficPolicy_PortTrafficPolicy{
+			Port:             &v1alpha32.PortSelector{Number: port.Number},
+			OutlierDetection: getTcpOutlierDetection(trafficPolicy.OutlierDetection),
+			ConnectionPool:   getTcpConnectionPool(),
+		})
+	}
+}
+
+func getTcpOutlierDetection(outlierDetection *v1alpha32.OutlierDetection) *v1alpha32.OutlierDetection {
+	if outlierDetection == nil {
+		return nil
+	}
+	tcpOutlierDetection := &v1alpha32.OutlierDetection{
+		BaseEjectionTime:      outlierDetection.BaseEjectionTime,
+		Consecutive_5XxErrors: outlierDetection.ConsecutiveGatewayErrors,
+		Interval:              outlierDetection.Interval,
+		MaxEjectionPercent:    outlierDetection.MaxEjectionPercent,
+	}
+	return tcpOutlierDetection
+}
+
+func getTcpConnectionPool() *v1alpha32.ConnectionPoolSettings {
+	return &v1alpha32.ConnectionPoolSettings{
+		Tcp: &v1alpha32.ConnectionPoolSettings_TCPSettings{
+			TcpKeepalive: &v1alpha32.ConnectionPoolSettings_TCPSettings_TcpKeepalive{},
+		},
+	}
+}
+
 func getOutlierDetection(se *v1alpha32.ServiceEntry, locality string, gtpTrafficPolicy *model.TrafficPolicy) *v1alpha32.OutlierDetection {
 
 	outlierDetection := &v1alpha32.OutlierDetection{
@@ -148,10 +246,11 @@ func getOutlierDetection(se *v1alpha32.ServiceEntry, locality string, gtpTraffic
 }
 
 func (se *ServiceEntryHandler) Added(obj *v1alpha3.ServiceEntry) {
-	if CurrentAdmiralState.ReadOnly {
+	if IsAdmiralReadOnly() {
 		log.Infof(LogFormat, "Add", "ServiceEntry", obj.Name, se.ClusterID, "Admiral is in read-only mode. Skipping resource from namespace="+obj.Namespace)
 		return
 	}
+	se.RemoteRegistry.checkDrift(se.ClusterID, common.ServiceEntry, &obj.ObjectMeta, getServiceEntryHost(obj), &obj.Spec, false)
 	if IgnoreIstioResource(obj.Spec.ExportTo, obj.Annotations, obj.Namespace) {
 		log.Infof(LogFormat, "Add", "ServiceEntry", obj.Name, se.ClusterID, "Skipping resource from namespace="+obj.Namespace)
 		return
@@ -159,10 +258,11 @@ func (se *ServiceEntryHandler) Added(obj *v1alpha3.ServiceEntry) {
 }
 
 func (se *ServiceEntryHandler) Updated(obj *v1alpha3.ServiceEntry) {
-	if CurrentAdmiralState.ReadOnly {
+	if IsAdmiralReadOnly() {
 		log.Infof(LogFormat, "Update", "ServiceEntry", obj.Name, se.ClusterID, "Admiral is in read-only mode. Skipping resource from namespace="+obj.Namespace)
 		return
 	}
+	se.RemoteRegistry.checkDrift(se.ClusterID, common.ServiceEntry, &obj.ObjectMeta, getServiceEntryHost(obj), &obj.Spec, false)
 	if IgnoreIstioResource(obj.Spec.ExportTo, obj.Annotations, obj.Namespace) {
 		log.Infof(LogFormat, "Update", "ServiceEntry", obj.Name, se.ClusterID, "Skipping resource from namespace="+obj.Namespace)
 		return
@@ -170,10 +270,11 @@ func (se *ServiceEntryHandler) Updated(obj *v1alpha3.ServiceEntry) {
 }
 
 func (se *ServiceEntryHandler) Deleted(obj *v1alpha3.ServiceEntry) {
-	if CurrentAdmiralState.ReadOnly {
+	if IsAdmiralReadOnly() {
 		log.Infof(LogFormat, "Delete", "ServiceEntry", obj.Name, se.ClusterID, "Admiral is in read-only mode. Skipping resource from namespace="+obj.Namespace)
 		return
 	}
+	se.RemoteRegistry.checkDrift(se.ClusterID, common.ServiceEntry, &obj.ObjectMeta, getServiceEntryHost(obj), &obj.Spec, true)
 	if IgnoreIstioResource(obj.Spec.ExportTo, obj.Annotations, obj.Namespace) {
 		log.Infof(LogFormat, "Delete", "ServiceEntry", obj.Name, se.ClusterID, "Skipping resource from namespace="+obj.Namespace)
 		return
@@ -181,10 +282,11 @@ func (se *ServiceEntryHandler) Deleted(obj *v1alpha3.ServiceEntry) {
 }
 
 func (dh *DestinationRuleHandler) Added(obj *v1alpha3.DestinationRule) {
-	if CurrentAdmiralState.ReadOnly {
+	if IsAdmiralReadOnly() {
 		log.Infof(LogFormat, "Add", "DestinationRule", obj.Name, dh.ClusterID, "Admiral is in read-only mode. Skipping resource from namespace="+obj.Namespace)
 		return
 	}
+	dh.RemoteRegistry.checkDrift(dh.ClusterID, common.DestinationRule, &obj.ObjectMeta, obj.Spec.Host, &obj.Spec, false)
 	if IgnoreIstioResource(obj.Spec.ExportTo, obj.Annotations, obj.Namespace) {
 		log.Infof(LogFormat, "Add", "DestinationRule", obj.Name, dh.ClusterID, "Skipping resource from namespace="+obj.Namespace)
 		return
@@ -193,10 +295,11 @@ func (dh *DestinationRuleHandler) Added(obj *v1alpha3.DestinationRule) {
 }
 
 func (dh *DestinationRuleHandler) Updated(obj *v1alpha3.DestinationRule) {
-	if CurrentAdmiralState.ReadOnly {
+	if IsAdmiralReadOnly() {
 		log.Infof(LogFormat, "Update", "DestinationRule", obj.Name, dh.ClusterID, "Admiral is in read-only mode. Skipping resource from namespace="+obj.Namespace)
 		return
 	}
+	dh.RemoteRegistry.checkDrift(dh.ClusterID, common.DestinationRule, &obj.ObjectMeta, obj.Spec.Host, &obj.Spec, false)
 	if IgnoreIstioResource(obj.Spec.ExportTo, obj.Annotations, obj.Namespace) {
 		log.Infof(LogFormat, "Update", "DestinationRule", obj.Name, dh.ClusterID, "Skipping resource from namespace="+obj.Namespace)
 		return
@@ -205,10 +308,11 @@ func (dh *DestinationRuleHandler) Updated(obj *v1alpha3.DestinationRule) {
 }
 
 func (dh *DestinationRuleHandler) Deleted(obj *v1alpha3.DestinationRule) {
-	if CurrentAdmiralState.ReadOnly {
+	if IsAdmiralReadOnly() {
 		log.Infof(LogFormat, "Delete", "DestinationRule", obj.Name, dh.ClusterID, "Admiral is in read-only mode. Skipping resource from namespace="+obj.Namespace)
 		return
 	}
+	dh.RemoteRegistry.checkDrift(dh.ClusterID, common.DestinationRule, &obj.ObjectMeta, obj.Spec.Host, &obj.Spec, true)
 	if IgnoreIstioResource(obj.Spec.ExportTo, obj.Annotations, obj.Namespace) {
 		log.Infof(LogFormat, "Delete", "DestinationRule", obj.Name, dh.ClusterID, "Skipping resource from namespace="+obj.Namespace)
 		return
@@ -217,7 +321,7 @@ func (dh *DestinationRuleHandler) Deleted(obj *v1alpha3.DestinationRule) {
 }
 
 func (vh *VirtualServiceHandler) Added(obj *v1alpha3.VirtualService) {
-	if CurrentAdmiralState.ReadOnly {
+	if IsAdmiralReadOnly() {
 		log.Infof(LogFormat, "Add", "VirtualService", obj.Name, vh.ClusterID, "Admiral is in read-only mode. Skipping resource from namespace="+obj.Namespace)
 		return
 	}
@@ -232,7 +336,7 @@ func (vh *VirtualServiceHandler) Added(obj *v1alpha3.VirtualService) {
 }
 
 func (vh *VirtualServiceHandler) Updated(obj *v1alpha3.VirtualService) {
-	if CurrentAdmiralState.ReadOnly {
+	if IsAdmiralReadOnly() {
 		log.Infof(LogFormat, "Update", "VirtualService", obj.Name, vh.ClusterID, "Admiral is in read-only mode. Skipping resource from namespace="+obj.Namespace)
 		return
 	}
@@ -247,7 +351,7 @@ func (vh *VirtualServiceHandler) Updated(obj *v1alpha3.VirtualService) {
 }
 
 func (vh *VirtualServiceHandler) Deleted(obj *v1alpha3.VirtualService) {
-	if CurrentAdmiralState.ReadOnly {
+	if IsAdmiralReadOnly() {
 		log.Infof(LogFormat, "Delete", "VirtualService", obj.Name, vh.ClusterID, "Admiral is in read-only mode. Skipping resource from namespace="+obj.Namespace)
 		return
 	}
@@ -267,6 +371,20 @@ func (dh *SidecarHandler) Updated(obj *v1alpha3.Sidecar) {}
 
 func (dh *SidecarHandler) Deleted(obj *v1alpha3.Sidecar) {}
 
+func getServiceEntryHost(obj *v1alpha3.ServiceEntry) string {
+	if len(obj.Spec.Hosts) == 0 {
+		return ""
+	}
+	return obj.Spec.Hosts[0]
+}
+
+func getVirtualServiceHost(obj *v1alpha3.VirtualService) string {
+	if len(obj.Spec.Hosts) == 0 {
+		return ""
+	}
+	return obj.Spec.Hosts[0]
+}
+
 func IgnoreIstioResource(exportTo []string, annotations map[string]string, namespace string) bool {
 
 	if len(annotations) > 0 && annotations[common.AdmiralIgnoreAnnotation] == "true" {
@@ -316,16 +434,11 @@ func handleDestinationRuleEvent(obj *v1alpha3.DestinationRule, dh *DestinationRu
 
 			if event == common.Delete {
 
-				err := rc.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules(syncNamespace).Delete(obj.Name, &v12.DeleteOptions{})
-				if err != nil {
-					log.Infof(LogFormat, "Delete", "DestinationRule", obj.Name, clusterId, "success")
-				} else {
-					log.Errorf(LogErrFormat, "Delete", "DestinationRule", obj.Name, clusterId, err)
-				}
+				deleteDestinationRule(obj, syncNamespace, rc)
 
 			} else {
 
-				exist, _ := rc.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules(syncNamespace).Get(obj.Name, v12.GetOptions{})
+				exist, _ := rc.DestinationRuleController.Get(syncNamespace, obj.Name)
 
 				//copy destination rule only to other clusters
 				if dependentCluster != clusterId {
@@ -344,14 +457,9 @@ func handleDestinationRuleEvent(obj *v1alpha3.DestinationRule, dh *DestinationRu
 		if ClusterID != clusterId {
 			rc := r.GetRemoteController(ClusterID)
 			if event == common.Delete {
-				err := rc.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules(syncNamespace).Delete(obj.Name, &v12.DeleteOptions{})
-				if err != nil {
-					log.Infof(LogErrFormat, "Delete", "DestinationRule", obj.Name, clusterId, err)
-				} else {
-					log.Infof(LogFormat, "Delete", "DestinationRule", obj.Name, clusterId, "Success")
-				}
+				deleteDestinationRule(obj, syncNamespace, rc)
 			} else {
-				exist, _ := rc.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules(syncNamespace).Get(obj.Name, v12.GetOptions{})
+				exist, _ := rc.DestinationRuleController.Get(syncNamespace, obj.Name)
 				addUpdateDestinationRule(obj, exist, syncNamespace, rc)
 			}
 		}
@@ -405,15 +513,14 @@ func handleVirtualServiceEvent(obj *v1alpha3.VirtualService, vh *VirtualServiceH
 				log.Infof(LogFormat, "Event", "VirtualService", obj.Name, clusterId, "Processing")
 
 				if event == common.Delete {
-					log.Infof(LogFormat, "Delete", "VirtualService", obj.Name, clusterId, "Success")
-					err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(syncNamespace).Delete(obj.Name, &v12.DeleteOptions{})
+					err := deleteVirtualService(obj, syncNamespace, rc)
 					if err != nil {
 						return err
 					}
 
 				} else {
 
-					exist, _ := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(syncNamespace).Get(obj.Name, v12.GetOptions{})
+					exist, _ := rc.VirtualServiceController.Get(syncNamespace, obj.Name)
 
 					//change destination host for all http routes <service_name>.<ns>. to same as host on the virtual service
 					for _, httpRoute := range virtualService.Http {
@@ -450,15 +557,12 @@ func handleVirtualServiceEvent(obj *v1alpha3.VirtualService, vh *VirtualServiceH
 		if ClusterID != clusterId {
 			rc := r.GetRemoteController(ClusterID)
 			if event == common.Delete {
-				err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(syncNamespace).Delete(obj.Name, &v12.DeleteOptions{})
+				err := deleteVirtualService(obj, syncNamespace, rc)
 				if err != nil {
-					log.Infof(LogErrFormat, "Delete", "VirtualService", obj.Name, clusterId, err)
 					return err
-				} else {
-					log.Infof(LogFormat, "Delete", "VirtualService", obj.Name, clusterId, "Success")
 				}
 			} else {
-				exist, _ := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(syncNamespace).Get(obj.Name, v12.GetOptions{})
+				exist, _ := rc.VirtualServiceController.Get(syncNamespace, obj.Name)
 				addUpdateVirtualService(obj, exist, syncNamespace, rc)
 			}
 		}
@@ -472,13 +576,24 @@ func addUpdateVirtualService(obj *v1alpha3.VirtualService, exist *v1alpha3.Virtu
 	if obj.Annotations == nil {
 		obj.Annotations = map[string]string{}
 	}
-	obj.Annotations["app.kubernetes.io/created-by"] = "admiral"
+	obj.Annotations[common.CreatedByAnnotation] = common.CreatedByAdmiral
+	setSpecHash(&obj.ObjectMeta, &obj.Spec)
 	if exist == nil || len(exist.Spec.Hosts) == 0 {
 		obj.Namespace = namespace
 		obj.ResourceVersion = ""
+		if recordPendingChange(rc, common.VirtualService, &obj.ObjectMeta, namespace, "Add", getVirtualServiceHost(obj), &v1alpha32.VirtualService{}, &obj.Spec) {
+			return
+		}
 		_, err = rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(namespace).Create(obj)
 		op = "Add"
 	} else {
+		if isUpToDate(&obj.ObjectMeta, &exist.ObjectMeta, &exist.Spec) {
+			skipWrite(rc, common.VirtualService, namespace, obj.Name)
+			return
+		}
+		if recordPendingChange(rc, common.VirtualService, &obj.ObjectMeta, namespace, "Update", getVirtualServiceHost(obj), &exist.Spec, &obj.Spec) {
+			return
+		}
 		exist.Labels = obj.Labels
 		exist.Annotations = obj.Annotations
 		exist.Spec = obj.Spec
@@ -489,25 +604,49 @@ func addUpdateVirtualService(obj *v1alpha3.VirtualService, exist *v1alpha3.Virtu
 	if err != nil {
 		log.Errorf(LogErrFormat, op, "VirtualService", obj.Name, rc.ClusterID, err)
 	} else {
+		common.ClusterWrites.With(rc.ClusterID, string(common.VirtualService), op).Inc()
 		log.Infof(LogFormat, op, "VirtualService", obj.Name, rc.ClusterID, "Success")
 	}
 }
 
-func addUpdateServiceEntry(obj *v1alpha3.ServiceEntry, exist *v1alpha3.ServiceEntry, namespace string, rc *RemoteController) {
+func deleteVirtualService(exist *v1alpha3.VirtualService, namespace string, rc *RemoteController) error {
+	if exist != nil {
+		if recordPendingChange(rc, common.VirtualService, &exist.ObjectMeta, namespace, "Delete", getVirtualServiceHost(exist), &exist.Spec, &v1alpha32.VirtualService{}) {
+			return nil
+		}
+		err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(namespace).Delete(exist.Name, &v12.DeleteOptions{})
+		if err != nil {
+			log.Errorf(LogErrFormat, "Delete", "VirtualService", exist.Name, rc.ClusterID, err)
+			return err
+		}
+		log.Infof(LogFormat, "Delete", "VirtualService", exist.Name, rc.ClusterID, "Success")
+	}
+	return nil
+}
+
+func addUpdateServiceEntry(obj *v1alpha3.ServiceEntry, exist *v1alpha3.ServiceEntry, namespace string, rc *RemoteController) error {
 	var err error
 	var op, diff string
 	var skipUpdate bool
 	if obj.Annotations == nil {
 		obj.Annotations = map[string]string{}
 	}
-	obj.Annotations["app.kubernetes.io/created-by"] = "admiral"
+	obj.Annotations[common.CreatedByAnnotation] = common.CreatedByAdmiral
+	setSpecHash(&obj.ObjectMeta, &obj.Spec)
 	if exist == nil || exist.Spec.Hosts == nil {
 		obj.Namespace = namespace
 		obj.ResourceVersion = ""
+		if recordPendingChange(rc, common.ServiceEntry, &obj.ObjectMeta, namespace, "Add", getServiceEntryHost(obj), &v1alpha32.ServiceEntry{}, &obj.Spec) {
+			return nil
+		}
 		_, err = rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(namespace).Create(obj)
 		op = "Add"
 		log.Infof(LogFormat+" SE=%s", op, "ServiceEntry", obj.Name, rc.ClusterID, "New SE", obj.Spec.String())
 	} else {
+		if isUpToDate(&obj.ObjectMeta, &exist.ObjectMeta, &exist.Spec) {
+			skipWrite(rc, common.ServiceEntry, namespace, obj.Name)
+			return nil
+		}
 		exist.Labels = obj.Labels
 		exist.Annotations = obj.Annotations
 		op = "Update"
@@ -517,7 +656,9 @@ func addUpdateServiceEntry(obj *v1alpha3.ServiceEntry, exist *v1alpha3.ServiceEn
 		}
 		if skipUpdate {
 			log.Infof(LogFormat, op, "ServiceEntry", obj.Name, rc.ClusterID, "Update skipped as it was destructive during Admiral's bootup phase")
-			return
+			return nil
+		} else if recordPendingChange(rc, common.ServiceEntry, &obj.ObjectMeta, namespace, op, getServiceEntryHost(obj), &exist.Spec, &obj.Spec) {
+			return nil
 		} else {
 			exist.Spec = obj.Spec
 			_, err = rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(namespace).Update(exist)
@@ -528,8 +669,10 @@ func addUpdateServiceEntry(obj *v1alpha3.ServiceEntry, exist *v1alpha3.ServiceEn
 	if err != nil {
 		log.Errorf(LogErrFormat, op, "ServiceEntry", obj.Name, rc.ClusterID, err)
 	} else {
+		common.ClusterWrites.With(rc.ClusterID, string(common.ServiceEntry), op).Inc()
 		log.Infof(LogFormat, op, "ServiceEntry", obj.Name, rc.ClusterID, "Success")
 	}
+	return err
 }
 
 func skipDestructiveUpdate(rc *RemoteController, new *v1alpha3.ServiceEntry, old *v1alpha3.ServiceEntry) (skipDestructive bool, diff string) {
@@ -584,30 +727,47 @@ func getServiceEntryDiff(new *v1alpha3.ServiceEntry, old *v1alpha3.ServiceEntry)
 	return destructive, diff
 }
 
-func deleteServiceEntry(exist *v1alpha3.ServiceEntry, namespace string, rc *RemoteController) {
+func deleteServiceEntry(exist *v1alpha3.ServiceEntry, namespace string, rc *RemoteController) error {
 	if exist != nil {
+		if recordPendingChange(rc, common.ServiceEntry, &exist.ObjectMeta, namespace, "Delete", getServiceEntryHost(exist), &exist.Spec, &v1alpha32.ServiceEntry{}) {
+			return nil
+		}
+		forget := markAdmiralDelete(rc, common.ServiceEntry, namespace, exist.Name)
 		err := rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(namespace).Delete(exist.Name, &v12.DeleteOptions{})
 		if err != nil {
+			forget()
 			log.Errorf(LogErrFormat, "Delete", "ServiceEntry", exist.Name, rc.ClusterID, err)
-		} else {
-			log.Infof(LogFormat, "Delete", "ServiceEntry", exist.Name, rc.ClusterID, "Success")
+			return err
 		}
+		log.Infof(LogFormat, "Delete", "ServiceEntry", exist.Name, rc.ClusterID, "Success")
 	}
+	return nil
 }
 
-func addUpdateDestinationRule(obj *v1alpha3.DestinationRule, exist *v1alpha3.DestinationRule, namespace string, rc *RemoteController) {
+func addUpdateDestinationRule(obj *v1alpha3.DestinationRule, exist *v1alpha3.DestinationRule, namespace string, rc *RemoteController) error {
 	var err error
 	var op string
 	if obj.Annotations == nil {
 		obj.Annotations = map[string]string{}
 	}
-	obj.Annotations["app.kubernetes.io/created-by"] = "admiral"
+	obj.Annotations[common.CreatedByAnnotation] = common.CreatedByAdmiral
+	setSpecHash(&obj.ObjectMeta, &obj.Spec)
 	if exist == nil || exist.Name == "" || exist.Spec.Host == "" {
 		obj.Namespace = namespace
 		obj.ResourceVersion = ""
+		if recordPendingChange(rc, common.DestinationRule, &obj.ObjectMeta, namespace, "Add", obj.Spec.Host, &v1alpha32.DestinationRule{}, &obj.Spec) {
+			return nil
+		}
 		_, err = rc.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules(namespace).Create(obj)
 		op = "Add"
 	} else {
+		if isUpToDate(&obj.ObjectMeta, &exist.ObjectMeta, &exist.Spec) {
+			skipWrite(rc, common.DestinationRule, namespace, obj.Name)
+			return nil
+		}
+		if recordPendingChange(rc, common.DestinationRule, &obj.ObjectMeta, namespace, "Update", obj.Spec.Host, &exist.Spec, &obj.Spec) {
+			return nil
+		}
 		exist.Labels = obj.Labels
 		exist.Annotations = obj.Annotations
 		exist.Spec = obj.Spec
@@ -618,20 +778,58 @@ func addUpdateDestinationRule(obj *v1alpha3.DestinationRule, exist *v1alpha3.Des
 	if err != nil {
 		log.Errorf(LogErrFormat, op, "DestinationRule", obj.Name, rc.ClusterID, err)
 	} else {
+		common.ClusterWrites.With(rc.ClusterID, string(common.DestinationRule), op).Inc()
 		log.Infof(LogFormat, op, "DestinationRule", obj.Name, rc.ClusterID, "Success")
 	}
+	return err
 }
 
-func deleteDestinationRule(exist *v1alpha3.DestinationRule, namespace string, rc *RemoteController) {
+func deleteDestinationRule(exist *v1alpha3.DestinationRule, namespace string, rc *RemoteController) error {
 	if exist != nil {
+		if recordPendingChange(rc, common.DestinationRule, &exist.ObjectMeta, namespace, "Delete", exist.Spec.Host, &exist.Spec, &v1alpha32.DestinationRule{}) {
+			return nil
+		}
+		forget := markAdmiralDelete(rc, common.DestinationRule, namespace, exist.Name)
 		err := rc.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules(namespace).Delete(exist.Name, &v12.DeleteOptions{})
 		if err != nil {
+			forget()
 			log.Errorf(LogErrFormat, "Delete", "DestinationRule", exist.Name, rc.ClusterID, err)
-		} else {
-			log.Infof(LogFormat, "Delete", "DestinationRule", exist.Name, rc.ClusterID, "Success")
+			return err
 		}
+		log.Infof(LogFormat, "Delete", "DestinationRule", exist.Name, rc.ClusterID, "Success")
+	}
+	return nil
+}
+//stores the hash of the desired spec in the annotations of the object, see isUpToDate
+func setSpecHash(obj *v12.ObjectMeta, spec interface{}) {
+	hash, err := common.GetSpecHash(spec)
+	if err != nil {
+		log.Errorf(LogErrFormat, "Hash", "spec", obj.Name, "", err)
+		delete(obj.Annotations, common.SpecHashAnnotation)
+		return
+	}
+	obj.Annotations[common.SpecHashAnnotation] = hash
+}
+
+/*
+returns true when the existing object was written with the same spec and labels as the desired object, and its spec wasn't changed since,
+updating it would be a no-op
+*/
+func isUpToDate(obj *v12.ObjectMeta, exist *v12.ObjectMeta, existSpec interface{}) bool {
+	hash := obj.Annotations[common.SpecHashAnnotation]
+	if len(hash) == 0 || exist.Annotations[common.SpecHashAnnotation] != hash || !reflect.DeepEqual(obj.Labels, exist.Labels) {
+		return false
 	}
+	existHash, err := common.GetSpecHash(existSpec)
+	return err == nil && existHash == hash
+}
+
+func skipWrite(rc *RemoteController, objectType common.ResourceType, namespace string, name string) {
+	common.ClusterWritesSkipped.With(rc.ClusterID, string(objectType)).Inc()
+	clearPendingChange(rc, objectType, namespace, name)
+	log.Debugf(LogFormat, "Update", objectType, name, rc.ClusterID, "Skipped as the spec is unchanged")
 }
+
 func createServiceEntrySkeletion(se v1alpha32.ServiceEntry, name string, namespace string) *v1alpha3.ServiceEntry {
 	return &v1alpha3.ServiceEntry{Spec: se, ObjectMeta: v12.ObjectMeta{Name: name, Namespace: namespace}}
 }
@@ -670,6 +868,35 @@ func getServiceForDeployment(rc *RemoteController, deployment *k8sAppsV1.Deploym
 	return matchedService
 }
 
+//prefers the governing (headless) service of the statefulset, as the per pod dns names are only resolvable through it
+func getServiceForStatefulSet(rc *RemoteController, statefulSet *k8sAppsV1.StatefulSet) *k8sV1.Service {
+
+	if statefulSet == nil {
+		return nil
+	}
+
+	cachedServices := rc.ServiceController.Cache.Get(statefulSet.Namespace)
+
+	if cachedServices == nil {
+		return nil
+	}
+	var matchedService *k8sV1.Service
+	for _, service := range cachedServices {
+		var match = common.IsServiceMatch(service.Spec.Selector, statefulSet.Spec.Selector)
+		//make sure the service matches the statefulset Selector and also has a mesh port in the port spec
+		if match {
+			ports := GetMeshPortsForWorkload(rc.ClusterID, service, newStatefulSetWorkload(statefulSet))
+			if len(ports) > 0 {
+				matchedService = service
+				if service.Name == statefulSet.Spec.ServiceName {
+					break
+				}
+			}
+		}
+	}
+	return matchedService
+}
+
 func getDependentClusters(dependents map[string]string, identityClusterCache *common.MapOfMaps, sourceServices map[string]*k8sV1.Service) map[string]string {
 	var dependentClusters = make(map[string]string)
 
@@ -697,7 +924,7 @@ func copyEndpoint(e *v1alpha32.ServiceEntry_Endpoint) *v1alpha32.ServiceEntry_En
 	util.MapCopy(labels, e.Labels)
 	ports := make(map[string]uint32)
 	util.MapCopy(ports, e.Ports)
-	return &v1alpha32.ServiceEntry_Endpoint{Address: e.Address, Ports: ports, Locality: e.Locality, Labels: labels}
+	return &v1alpha32.ServiceEntry_Endpoint{Address: e.Address, Ports: ports, Locality: e.Locality, Labels: labels, Network: e.Network, Weight: e.Weight}
 }
 
 // A rollout can use one of 2 stratergies :-
@@ -18,6 +18,7 @@ import (
 	"istio.io/api/networking/v1alpha3"
 	v1alpha32 "istio.io/client-go/pkg/apis/networking/v1alpha3"
 	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
+	k8sAppsV1 "k8s.io/api/apps/v1"
 	coreV1 "k8s.io/api/core/v1"
 	k8sV1 "k8s.io/api/core/v1"
 	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -221,6 +222,66 @@ func TestGetDestinationRule(t *testing.T) {
 		},
 	}
 
+	failoverZoneGtpDr := v1alpha3.DestinationRule{
+		Host: "qa.myservice.global",
+		TrafficPolicy: &v1alpha3.TrafficPolicy{
+			Tls: &v1alpha3.TLSSettings{Mode: v1alpha3.TLSSettings_ISTIO_MUTUAL},
+			LoadBalancer: &v1alpha3.LoadBalancerSettings{
+				LbPolicy: &v1alpha3.LoadBalancerSettings_Simple{Simple: v1alpha3.LoadBalancerSettings_ROUND_ROBIN},
+				LocalityLbSetting: &v1alpha3.LocalityLoadBalancerSetting{
+					Distribute: []*v1alpha3.LocalityLoadBalancerSetting_Distribute{
+						{
+							From: "us-west-2/us-west-2a/*",
+							To:   map[string]uint32{"us-west-2/us-west-2a/*": 80, "us-west-2/us-west-2b/*": 20},
+						},
+					},
+				},
+			},
+			OutlierDetection: outlierDetection,
+		},
+	}
+
+	failoverPriorityGtpDr := func(from string, to string) *v1alpha3.DestinationRule {
+		return &v1alpha3.DestinationRule{
+			Host: "qa.myservice.global",
+			TrafficPolicy: &v1alpha3.TrafficPolicy{
+				Tls: &v1alpha3.TLSSettings{Mode: v1alpha3.TLSSettings_ISTIO_MUTUAL},
+				LoadBalancer: &v1alpha3.LoadBalancerSettings{
+					LbPolicy: &v1alpha3.LoadBalancerSettings_Simple{Simple: v1alpha3.LoadBalancerSettings_ROUND_ROBIN},
+					LocalityLbSetting: &v1alpha3.LocalityLoadBalancerSetting{
+						Failover: []*v1alpha3.LocalityLoadBalancerSetting_Failover{{From: from, To: to}},
+					},
+				},
+				OutlierDetection: outlierDetection,
+			},
+		}
+	}
+
+	failoverPriorityGTPPolicy := &model.TrafficPolicy{
+		LbType: model.TrafficPolicy_FAILOVER_PRIORITY,
+		Target: []*model.TrafficGroup{
+			{Region: "us-west-2"},
+			{Region: "us-east-2"},
+			{Region: "eu-west-1"},
+		},
+	}
+
+	failoverZoneGTPPolicy := &model.TrafficPolicy{
+		LbType: model.TrafficPolicy_FAILOVER,
+		Target: []*model.TrafficGroup{
+			{
+				Region: "us-west-2",
+				Zone:   "us-west-2a",
+				Weight: 80,
+			},
+			{
+				Region: "us-west-2",
+				Zone:   "us-west-2b",
+				Weight: 20,
+			},
+		},
+	}
+
 	topologyGTPPolicy := &model.TrafficPolicy{
 		LbType: model.TrafficPolicy_TOPOLOGY,
 		Target: []*model.TrafficGroup{
@@ -245,6 +306,44 @@ func TestGetDestinationRule(t *testing.T) {
 		},
 	}
 
+	tcpOutlierDetection := &v1alpha3.OutlierDetection{
+		BaseEjectionTime:      &types.Duration{Seconds: 300},
+		Consecutive_5XxErrors: &types.UInt32Value{Value: 50},
+		Interval:              &types.Duration{Seconds: 60},
+		MaxEjectionPercent:    100,
+	}
+	tcpConnectionPool := &v1alpha3.ConnectionPoolSettings{
+		Tcp: &v1alpha3.ConnectionPoolSettings_TCPSettings{TcpKeepalive: &v1alpha3.ConnectionPoolSettings_TCPSettings_TcpKeepalive{}},
+	}
+
+	tcpSe := &v1alpha3.ServiceEntry{Hosts: []string{"qa.myservice.global"}, Endpoints: se.Endpoints,
+		Ports: []*v1alpha3.Port{{Number: 80, Name: "mongo", Protocol: "mongo"}}}
+	tcpDr := v1alpha3.DestinationRule{
+		Host: "qa.myservice.global",
+		TrafficPolicy: &v1alpha3.TrafficPolicy{
+			Tls:              &v1alpha3.TLSSettings{Mode: v1alpha3.TLSSettings_ISTIO_MUTUAL},
+			OutlierDetection: tcpOutlierDetection,
+			ConnectionPool:   tcpConnectionPool,
+		},
+	}
+
+	mixedSe := &v1alpha3.ServiceEntry{Hosts: []string{"qa.myservice.global"}, Endpoints: se.Endpoints,
+		Ports: []*v1alpha3.Port{{Number: 80, Name: "http", Protocol: "http"}, {Number: 6379, Name: "redis-6379", Protocol: "redis"}}}
+	mixedDr := v1alpha3.DestinationRule{
+		Host: "qa.myservice.global",
+		TrafficPolicy: &v1alpha3.TrafficPolicy{
+			Tls:              &v1alpha3.TLSSettings{Mode: v1alpha3.TLSSettings_ISTIO_MUTUAL},
+			OutlierDetection: outlierDetection,
+			PortLevelSettings: []*v1alpha3.TrafficPolicy_PortTrafficPolicy{
+				{
+					Port:             &v1alpha3.PortSelector{Number: 6379},
+					OutlierDetection: tcpOutlierDetection,
+					ConnectionPool:   tcpConnectionPool,
+				},
+			},
+		},
+	}
+
 	//Struct of test case info. Name is required.
 	testCases := []struct {
 		name            string
@@ -281,6 +380,58 @@ func TestGetDestinationRule(t *testing.T) {
 			gtpPolicy:       failoverGTPPolicy,
 			destinationRule: &failoverGtpDr,
 		},
+		{
+			name:            "Should distribute from the region of a cluster with a zone locality",
+			se:              se,
+			locality:        "uswest2/uswest2a/rack1",
+			gtpPolicy:       failoverGTPPolicy,
+			destinationRule: &failoverGtpDr,
+		},
+		{
+			name:            "Should distribute from the zone when the failover GTP targets zones",
+			se:              se,
+			locality:        "us-west-2/us-west-2a/rack1",
+			gtpPolicy:       failoverZoneGTPPolicy,
+			destinationRule: &failoverZoneGtpDr,
+		},
+		{
+			name:            "Should fail over to the first region of a failover priority GTP",
+			se:              se,
+			locality:        "us-east-2/us-east-2a",
+			gtpPolicy:       failoverPriorityGTPPolicy,
+			destinationRule: failoverPriorityGtpDr("us-east-2", "us-west-2"),
+		},
+		{
+			name:            "Should fail over to the next region of a failover priority GTP from its first region",
+			se:              se,
+			locality:        "us-west-2",
+			gtpPolicy:       failoverPriorityGTPPolicy,
+			destinationRule: failoverPriorityGtpDr("us-west-2", "us-east-2"),
+		},
+		{
+			name:     "Should leave the failover to istio when a failover priority GTP only targets the region of the cluster",
+			se:       se,
+			locality: "us-west-2",
+			gtpPolicy: &model.TrafficPolicy{
+				LbType: model.TrafficPolicy_FAILOVER_PRIORITY,
+				Target: []*model.TrafficGroup{{Region: "us-west-2"}},
+			},
+			destinationRule: &basicGtpDr,
+		},
+		{
+			name:            "Should use tcp settings for a tcp only service entry",
+			se:              tcpSe,
+			locality:        "uswest2",
+			gtpPolicy:       nil,
+			destinationRule: &tcpDr,
+		},
+		{
+			name:            "Should use port level tcp settings for a service entry with http and tcp ports",
+			se:              mixedSe,
+			locality:        "uswest2",
+			gtpPolicy:       nil,
+			destinationRule: &mixedDr,
+		},
 	}
 
 	//Run the test for every provided case
@@ -650,6 +801,74 @@ func TestHandleVirtualServiceEvent(t *testing.T) {
 	}
 }
 
+func TestGetServiceForStatefulSet(t *testing.T) {
+	config := rest.Config{
+		Host: "localhost",
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+
+	s, e := admiral.NewServiceController("test", stop, &test.MockServiceHandler{}, &config, time.Second*time.Duration(300))
+	if e != nil {
+		t.Fatalf("Inititalization failed")
+	}
+	rc := &RemoteController{ServiceController: s}
+
+	selector := map[string]string{"app": "mongo"}
+	ports := []coreV1.ServicePort{{Name: "mongo", Port: 27017}}
+	clientService := &coreV1.Service{
+		ObjectMeta: v12.ObjectMeta{Name: "mongo-client", Namespace: "ns"},
+		Spec:       coreV1.ServiceSpec{Selector: selector, Ports: ports},
+	}
+	headlessService := &coreV1.Service{
+		ObjectMeta: v12.ObjectMeta{Name: "mongo", Namespace: "ns"},
+		Spec:       coreV1.ServiceSpec{Selector: selector, Ports: ports, ClusterIP: coreV1.ClusterIPNone},
+	}
+	rc.ServiceController.Cache.Put(clientService)
+	rc.ServiceController.Cache.Put(headlessService)
+
+	statefulSet := func(serviceName string, selector map[string]string) *k8sAppsV1.StatefulSet {
+		return &k8sAppsV1.StatefulSet{
+			ObjectMeta: v12.ObjectMeta{Name: "mongo", Namespace: "ns"},
+			Spec: k8sAppsV1.StatefulSetSpec{
+				ServiceName: serviceName,
+				Selector:    &v12.LabelSelector{MatchLabels: selector},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name            string
+		statefulSet     *k8sAppsV1.StatefulSet
+		expectedService *coreV1.Service
+	}{
+		{
+			name:            "Should return nil for a nil statefulset",
+			statefulSet:     nil,
+			expectedService: nil,
+		},
+		{
+			name:            "Should prefer the governing service of the statefulset",
+			statefulSet:     statefulSet("mongo", selector),
+			expectedService: headlessService,
+		},
+		{
+			name:            "Should return nil when no service matches the selector",
+			statefulSet:     statefulSet("mongo", map[string]string{"app": "redis"}),
+			expectedService: nil,
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			service := getServiceForStatefulSet(rc, c.statefulSet)
+			if service != c.expectedService {
+				t.Errorf("Wanted service: %v, got: %v", c.expectedService, service)
+			}
+		})
+	}
+}
+
 func TestGetServiceForRolloutCanary(t *testing.T) {
 	//Struct of test case info. Name is required.
 	const Namespace = "namespace"
@@ -1601,3 +1820,77 @@ func TestAddUpdateServiceEntry(t *testing.T) {
 		})
 	}
 }
+
+func TestAddUpdateSkipsUnchangedSpec(t *testing.T) {
+	fakeIstioClient := istiofake.NewSimpleClientset()
+	rc := &RemoteController{
+		ClusterID:                 "cluster1",
+		ServiceEntryController:    &istio.ServiceEntryController{IstioClient: fakeIstioClient},
+		DestinationRuleController: &istio.DestinationRuleController{IstioClient: fakeIstioClient},
+		StartTime:                 time.Now().Add(-time.Hour),
+	}
+	newServiceEntry := func(address string) *v1alpha32.ServiceEntry {
+		return &v1alpha32.ServiceEntry{
+			ObjectMeta: v12.ObjectMeta{Name: "e2e.bar.mesh-se", Labels: map[string]string{"identity": "bar"}},
+			Spec: v1alpha3.ServiceEntry{
+				Hosts:     []string{"e2e.bar.mesh"},
+				Endpoints: []*v1alpha3.ServiceEntry_Endpoint{{Address: address, Ports: map[string]uint32{"http": 15443}}},
+			},
+		}
+	}
+	newDestinationRule := func() *v1alpha32.DestinationRule {
+		return &v1alpha32.DestinationRule{
+			ObjectMeta: v12.ObjectMeta{Name: "e2e.bar.mesh-default-dr"},
+			Spec:       v1alpha3.DestinationRule{Host: "e2e.bar.mesh"},
+		}
+	}
+	countUpdates := func() int {
+		updates := 0
+		for _, action := range fakeIstioClient.Actions() {
+			if action.GetVerb() == "update" {
+				updates++
+			}
+		}
+		return updates
+	}
+	write := func(address string) {
+		exist, _ := rc.ServiceEntryController.Get("ns", "e2e.bar.mesh-se")
+		if err := addUpdateServiceEntry(newServiceEntry(address), exist, "ns", rc); err != nil {
+			t.Fatalf("%v", err)
+		}
+		existDr, _ := rc.DestinationRuleController.Get("ns", "e2e.bar.mesh-default-dr")
+		if err := addUpdateDestinationRule(newDestinationRule(), existDr, "ns", rc); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+
+	write("east.mesh")
+	write("east.mesh")
+	if updates := countUpdates(); updates != 0 {
+		t.Errorf("Wanted the update of the unchanged spec skipped, got %v updates", updates)
+	}
+
+	write("west.mesh")
+	if updates := countUpdates(); updates != 1 {
+		t.Errorf("Wanted the ServiceEntry updated, got %v updates", updates)
+	}
+	se, _ := rc.ServiceEntryController.Get("ns", "e2e.bar.mesh-se")
+	if se.Spec.Endpoints[0].Address != "west.mesh" {
+		t.Errorf("Wanted the ServiceEntry updated, got %v", se.Spec.String())
+	}
+	if hash, _ := common.GetSpecHash(&se.Spec); se.Annotations[common.SpecHashAnnotation] != hash {
+		t.Errorf("Wanted the hash of the spec in the annotations, got %v", se.Annotations)
+	}
+
+	//edited outside of Admiral, the annotation no longer matches the spec
+	se.Spec.Endpoints[0].Address = "hand-edited.mesh"
+	fakeIstioClient.NetworkingV1alpha3().ServiceEntries("ns").Update(se)
+	write("west.mesh")
+	if updates := countUpdates(); updates != 3 {
+		t.Errorf("Wanted the drifted ServiceEntry written back, got %v updates", updates)
+	}
+	se, _ = rc.ServiceEntryController.Get("ns", "e2e.bar.mesh-se")
+	if se.Spec.Endpoints[0].Address != "west.mesh" {
+		t.Errorf("Wanted the drifted ServiceEntry written back, got %v", se.Spec.String())
+	}
+}
@@ -0,0 +1,141 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	identityReconcileOp = "IdentityReconcile"
+	//number of times an identity/env whose writes failed is retried before waiting for its next event
+	identityReconcileMaxRetries = 5
+)
+
+/*
+identityReconcileQueue coalesces the workload, service and GTP events of an identity/env into a single reconcile.
+The first event of an identity/env waits --reconcile_debounce in the queue, the events received meanwhile are counted as coalesced,
+so a rollout replacing many pods rebuilds the SEs and DRs of the identity once instead of once per pod.
+The reconciles are rate limited by --reconcile_qps and run on --reconcile_workers workers, an identity/env is never reconciled concurrently.
+An identity/env that couldn't be written to some clusters is retried with an exponential backoff.
+*/
+type identityReconcileQueue struct {
+	ctx       context.Context
+	queue     workqueue.RateLimitingInterface
+	limiter   flowcontrol.RateLimiter
+	debounce  time.Duration
+	workers   int
+	reconcile func(identity string, env string) error
+	//identity/env pairs added to the queue and not picked up by a worker yet
+	pending map[identityEnv]bool
+	mutex   sync.Mutex
+}
+
+func newIdentityReconcileQueue(ctx context.Context, reconcile func(identity string, env string) error) *identityReconcileQueue {
+	return &identityReconcileQueue{
+		ctx:       ctx,
+		queue:     workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "identity-reconcile"),
+		limiter:   flowcontrol.NewTokenBucketRateLimiter(common.GetReconcileQPS(), 1),
+		debounce:  common.GetReconcileDebounce(),
+		workers:   common.GetReconcileWorkers(),
+		reconcile: reconcile,
+		pending:   make(map[identityEnv]bool),
+	}
+}
+
+//adds the identity/env to the queue, unless it's already waiting in it
+func (q *identityReconcileQueue) add(identity string, env string) {
+	ie := identityEnv{identity: identity, env: env}
+	q.mutex.Lock()
+	if q.pending[ie] {
+		q.mutex.Unlock()
+		common.ReconcileCoalesced.Inc()
+		log.Debugf(LogFormat, identityReconcileOp, env, identity, "", "Coalesced with the reconcile waiting in the queue")
+		return
+	}
+	q.pending[ie] = true
+	common.ReconcileQueueDepth.Set(float64(len(q.pending)))
+	q.mutex.Unlock()
+	q.queue.AddAfter(ie, q.debounce)
+}
+
+//runs the workers until the context is done
+func (q *identityReconcileQueue) run() {
+	defer q.limiter.Stop()
+	for i := 0; i < q.workers; i++ {
+		go wait.Until(q.runWorker, time.Second, q.ctx.Done())
+	}
+	<-q.ctx.Done()
+	q.queue.ShutDown()
+}
+
+func (q *identityReconcileQueue) runWorker() {
+	for q.processNextItem() {
+		// continue looping
+	}
+}
+
+func (q *identityReconcileQueue) processNextItem() bool {
+	item, quit := q.queue.Get()
+	if quit {
+		return false
+	}
+	defer q.queue.Done(item)
+
+	ie := item.(identityEnv)
+	//events received from now on need another reconcile, the workqueue runs it once this one is done
+	q.mutex.Lock()
+	delete(q.pending, ie)
+	common.ReconcileQueueDepth.Set(float64(len(q.pending)))
+	q.mutex.Unlock()
+
+	if err := q.limiter.Wait(q.ctx); err != nil {
+		log.Infof(LogFormat, identityReconcileOp, ie.env, ie.identity, "", fmt.Sprintf("Stopped: %v", err))
+		return false
+	}
+	start := time.Now()
+	err := q.reconcile(ie.identity, ie.env)
+	common.ReconcileDuration.Observe(time.Since(start).Seconds())
+	if err == nil {
+		q.queue.Forget(item)
+	} else if q.queue.NumRequeues(item) < identityReconcileMaxRetries {
+		log.Errorf(LogErrFormat, identityReconcileOp, ie.env, ie.identity, "", fmt.Sprintf("%v (will retry)", err))
+		q.requeue(ie)
+	} else {
+		log.Errorf(LogErrFormat, identityReconcileOp, ie.env, ie.identity, "", fmt.Sprintf("%v (giving up)", err))
+		q.queue.Forget(item)
+	}
+	return true
+}
+
+//adds the identity/env back to the queue after the backoff, the events received meanwhile are coalesced with the retry
+func (q *identityReconcileQueue) requeue(ie identityEnv) {
+	q.mutex.Lock()
+	q.pending[ie] = true
+	common.ReconcileQueueDepth.Set(float64(len(q.pending)))
+	q.mutex.Unlock()
+	q.queue.AddRateLimited(ie)
+}
+
+/*
+Reconciles the SEs and DRs of the identity/env through the reconcile queue when it's enabled.
+Deletes are processed right away, the deleted workload is still in the cache when the handler is called and its endpoints are removed based on it,
+by the time a worker picks up the identity/env the workload is gone and there would be nothing left to remove.
+*/
+func (r *RemoteRegistry) reconcileIdentityEnv(event admiral.EventType, identity string, env string) {
+	if r.reconcileQueue == nil || event == admiral.Delete {
+		if _, err := modifyServiceEntryForNewServiceOrPod(event, env, identity, r); err != nil {
+			log.Errorf(LogErrFormat, event, env, identity, "", err)
+		}
+		return
+	}
+	r.reconcileQueue.add(identity, env)
+}
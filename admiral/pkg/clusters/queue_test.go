@@ -0,0 +1,69 @@
+package clusters
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIdentityReconcileQueueCoalescesEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mutex sync.Mutex
+	reconciles := make(map[identityEnv]int)
+	done := make(chan struct{}, 10)
+	q := newIdentityReconcileQueue(ctx, func(identity string, env string) error {
+		mutex.Lock()
+		reconciles[identityEnv{identity: identity, env: env}]++
+		mutex.Unlock()
+		done <- struct{}{}
+		return nil
+	})
+	q.debounce = 50 * time.Millisecond
+	go q.run()
+
+	waitForReconciles := func(count int) {
+		for i := 0; i < count; i++ {
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("Timed out waiting for %v reconciles", count)
+			}
+		}
+	}
+
+	//a burst of events for foo and a single event for bar
+	for i := 0; i < 5; i++ {
+		q.add("foo", "e2e")
+	}
+	q.add("bar", "e2e")
+	waitForReconciles(2)
+
+	//events received after the reconcile of a burst need a new reconcile
+	q.add("foo", "e2e")
+	waitForReconciles(1)
+
+	select {
+	case <-done:
+		t.Errorf("Wanted no other reconcile")
+	case <-time.After(2 * q.debounce):
+	}
+
+	expected := map[identityEnv]int{
+		{identity: "foo", env: "e2e"}: 2,
+		{identity: "bar", env: "e2e"}: 1,
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if !reflect.DeepEqual(reconciles, expected) {
+		t.Errorf("Wanted reconciles %v, got %v", expected, reconciles)
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if len(q.pending) != 0 {
+		t.Errorf("Wanted no pending identity/env pairs, got %v", q.pending)
+	}
+}
@@ -0,0 +1,166 @@
+package clusters
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+const (
+	fullReconcileOp    = "FullReconcile"
+	clusterReconcileOp = "ClusterReconcile"
+)
+
+type identityEnv struct {
+	identity string
+	env      string
+}
+
+/*
+Transition hook registered by InitAdmiral. Events received while Admiral was read-only were skipped,
+so on moving to read-write every identity/env known to Admiral is reconciled again instead of waiting for the next informer resync.
+*/
+func (r *RemoteRegistry) onAdmiralStateTransition(previous AdmiralState, current AdmiralState) {
+	if current.ReadOnly || !previous.ReadOnly {
+		return
+	}
+	r.promotionMutex.Lock()
+	r.promotions++
+	r.promotionMutex.Unlock()
+	if r.ctx == nil || r.ctx.Err() != nil {
+		return
+	}
+	go r.fullReconcile()
+}
+
+/*
+Reconciles the SEs and DRs of every identity/env present in the deployment, rollout and statefulset caches of all the remote controllers.
+The reconcile is rate limited by --full_reconcile_qps and stops as soon as Admiral goes back to read-only mode.
+A promotion during the cache warm up is reconciled once the warm up is over.
+*/
+func (r *RemoteRegistry) fullReconcile() {
+	r.promotionMutex.Lock()
+	promotion := r.promotions
+	r.promotionMutex.Unlock()
+
+	if warmup := common.GetAdmiralParams().CacheRefreshDuration - time.Since(r.StartTime); warmup > 0 {
+		select {
+		case <-time.After(warmup):
+		case <-r.ctx.Done():
+			return
+		}
+	}
+	if !r.reconcileIdentityEnvs(fullReconcileOp, "", r.getIdentityEnvs) {
+		return
+	}
+	r.promotionMutex.Lock()
+	defer r.promotionMutex.Unlock()
+	if promotion > r.reconciledPromotions {
+		r.reconciledPromotions = promotion
+	}
+}
+
+//returns true once a full reconcile completed after the last move to read-write, the caches miss what happened while Admiral was read-only until then
+func (r *RemoteRegistry) isPromotionReconciled() bool {
+	r.promotionMutex.Lock()
+	defer r.promotionMutex.Unlock()
+	return r.reconciledPromotions == r.promotions
+}
+
+//reconciles the cluster in the background, unless Admiral is read-only or shutting down
+func (r *RemoteRegistry) triggerClusterReconcile(clusterID string) {
+	if IsAdmiralReadOnly() {
+		log.Infof(LogFormat, clusterReconcileOp, "", "", clusterID, "Admiral is in read-only mode. Skipping the reconcile of the cluster")
+		return
+	}
+	if r.ctx == nil || r.ctx.Err() != nil {
+		return
+	}
+	go r.reconcileCluster(clusterID)
+}
+
+/*
+Reconciles the SEs and DRs of the identities that run in the cluster or have a dependent running in it.
+The locality and the gateways of the cluster are in the endpoints of the SEs of the former, and the locality in the DRs of the latter.
+*/
+func (r *RemoteRegistry) reconcileCluster(clusterID string) {
+	r.reconcileIdentityEnvs(clusterReconcileOp, clusterID, func() []identityEnv {
+		cache := r.AdmiralCache
+		localIdentities := make(map[string]bool)
+		if rc := r.GetRemoteController(clusterID); rc != nil {
+			for _, source := range GetWorkloadSources() {
+				source.Range(rc, func(identity string, env string) {
+					localIdentities[identity] = true
+				})
+			}
+		}
+		identityEnvs := make([]identityEnv, 0)
+		for _, ie := range r.getIdentityEnvs() {
+			if localIdentities[ie.identity] {
+				identityEnvs = append(identityEnvs, ie)
+				continue
+			}
+			dependentClusters := getDependentClusters(cache.IdentityDependencyCache.Get(ie.identity).Copy(), cache.IdentityClusterCache, nil)
+			if _, ok := dependentClusters[clusterID]; ok {
+				identityEnvs = append(identityEnvs, ie)
+			}
+		}
+		return identityEnvs
+	})
+}
+
+//returns false when the reconcile is skipped or stopped before going through every identity/env
+func (r *RemoteRegistry) reconcileIdentityEnvs(op string, clusterID string, getIdentityEnvs func() []identityEnv) bool {
+	r.reconcileMutex.Lock()
+	defer r.reconcileMutex.Unlock()
+
+	if IsCacheWarmupTime(r) {
+		log.Infof(LogFormat, op, "", "", clusterID, "Skipped during cache warm up state")
+		return false
+	}
+
+	start := time.Now()
+	identityEnvs := getIdentityEnvs()
+	log.Infof(LogFormat, op, "", "", clusterID, fmt.Sprintf("Starting reconcile of %v identity/env pairs", len(identityEnvs)))
+
+	limiter := flowcontrol.NewTokenBucketRateLimiter(common.GetFullReconcileQPS(), 1)
+	defer limiter.Stop()
+
+	for _, ie := range identityEnvs {
+		if IsAdmiralReadOnly() {
+			log.Infof(LogFormat, op, "", "", clusterID, "Stopped as Admiral moved to Read-only mode")
+			return false
+		}
+		if err := limiter.Wait(r.ctx); err != nil {
+			log.Infof(LogFormat, op, "", "", clusterID, fmt.Sprintf("Stopped: %v", err))
+			return false
+		}
+		if _, err := modifyServiceEntryForNewServiceOrPod(admiral.Update, ie.env, ie.identity, r); err != nil {
+			log.Errorf(LogErrFormat, op, ie.env, ie.identity, clusterID, err)
+		}
+	}
+	log.Infof(LogFormat, op, "", "", clusterID, fmt.Sprintf("Completed reconcile of %v identity/env pairs in %v ms", len(identityEnvs), time.Since(start).Milliseconds()))
+	return true
+}
+
+func (r *RemoteRegistry) getIdentityEnvs() []identityEnv {
+	seen := make(map[identityEnv]bool)
+	identityEnvs := make([]identityEnv, 0)
+	add := func(identity string, env string) {
+		ie := identityEnv{identity: identity, env: env}
+		if !seen[ie] {
+			seen[ie] = true
+			identityEnvs = append(identityEnvs, ie)
+		}
+	}
+	r.RangeRemoteControllers(func(k string, rc *RemoteController) {
+		for _, source := range GetWorkloadSources() {
+			source.Range(rc, add)
+		}
+	})
+	return identityEnvs
+}
@@ -0,0 +1,159 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/test"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+func newReconcileTestRemoteController(t *testing.T, clusterID string, stop chan struct{}) *RemoteController {
+	config := rest.Config{
+		Host: "localhost",
+	}
+	d, err := admiral.NewDeploymentController(clusterID, stop, &test.MockDeploymentHandler{}, &config, time.Second*time.Duration(300))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	r, err := admiral.NewRolloutsController(clusterID, stop, &test.MockRolloutHandler{}, &config, time.Second*time.Duration(300))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	s, err := admiral.NewServiceController(clusterID, stop, &test.MockServiceHandler{}, &config, time.Second*time.Duration(300))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return &RemoteController{
+		ClusterID:            clusterID,
+		DeploymentController: d,
+		RolloutController:    r,
+		ServiceController:    s,
+	}
+}
+
+func newReconcileTestRemoteRegistry(t *testing.T, stop chan struct{}) *RemoteRegistry {
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	rr.StartTime = time.Now().Add(-time.Hour)
+
+	deployment := &k8sAppsV1.Deployment{
+		ObjectMeta: metaV1.ObjectMeta{Name: "bar", Namespace: "bar-ns"},
+		Spec: k8sAppsV1.DeploymentSpec{
+			Template: coreV1.PodTemplateSpec{
+				ObjectMeta: metaV1.ObjectMeta{Labels: map[string]string{"identity": "bar", "env": "test"}},
+			},
+		},
+	}
+	rollout := &argo.Rollout{
+		ObjectMeta: metaV1.ObjectMeta{Name: "foo", Namespace: "foo-ns"},
+		Spec: argo.RolloutSpec{
+			Template: coreV1.PodTemplateSpec{
+				ObjectMeta: metaV1.ObjectMeta{Labels: map[string]string{"identity": "foo", "env": "test"}},
+			},
+		},
+	}
+
+	rc1 := newReconcileTestRemoteController(t, "cluster1", stop)
+	rc1.DeploymentController.Cache.UpdateDeploymentToClusterCache("bar", deployment)
+	rr.PutRemoteController("cluster1", rc1)
+
+	rc2 := newReconcileTestRemoteController(t, "cluster2", stop)
+	rc2.DeploymentController.Cache.UpdateDeploymentToClusterCache("bar", deployment)
+	rc2.RolloutController.Cache.UpdateRolloutToClusterCache("foo", rollout)
+	rr.PutRemoteController("cluster2", rc2)
+
+	return rr
+}
+
+func TestGetIdentityEnvs(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+	rr := newReconcileTestRemoteRegistry(t, stop)
+
+	identityEnvs := rr.getIdentityEnvs()
+
+	if len(identityEnvs) != 2 {
+		t.Fatalf("Expected 2 identity/env pairs, got %v", identityEnvs)
+	}
+	found := make(map[identityEnv]bool)
+	for _, ie := range identityEnvs {
+		found[ie] = true
+	}
+	for _, expected := range []identityEnv{{identity: "bar", env: "test"}, {identity: "foo", env: "test"}} {
+		if !found[expected] {
+			t.Errorf("Expected %v in %v", expected, identityEnvs)
+		}
+	}
+}
+
+func TestFullReconcileOnPromotion(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+	rr := newReconcileTestRemoteRegistry(t, stop)
+
+	hooks := stateTransitionHooks
+	defer func() {
+		stateTransitionHooks = hooks
+		setAdmiralState(AdmiralState{ReadOnly: ReadWriteEnabled, IsStateInitialized: StateInitialized})
+	}()
+	stateTransitionHooks = nil
+	RegisterStateTransitionHook(rr.onAdmiralStateTransition)
+
+	SetAdmiralReadOnly(ReadOnlyEnabled)
+	if rr.AdmiralCache.IdentityClusterCache.Get("bar") != nil {
+		t.Fatalf("Full reconcile should not run when moving to read-only")
+	}
+
+	SetAdmiralReadOnly(ReadWriteEnabled)
+	test.NewEventualOpts(10*time.Millisecond, 5*time.Second).Eventually(t, "full reconcile", func() bool {
+		bar := rr.AdmiralCache.IdentityClusterCache.Get("bar")
+		foo := rr.AdmiralCache.IdentityClusterCache.Get("foo")
+		return bar != nil && len(bar.Copy()) == 2 && foo != nil && len(foo.Copy()) == 1
+	})
+	test.NewEventualOpts(10*time.Millisecond, 5*time.Second).Eventually(t, "promotion reconciled", rr.isPromotionReconciled)
+}
+
+func TestFullReconcileStopsWhenReadOnly(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+	rr := newReconcileTestRemoteRegistry(t, stop)
+
+	setAdmiralState(AdmiralState{ReadOnly: ReadOnlyEnabled, IsStateInitialized: StateInitialized})
+	defer func() {
+		setAdmiralState(AdmiralState{ReadOnly: ReadWriteEnabled, IsStateInitialized: StateInitialized})
+	}()
+	rr.fullReconcile()
+
+	if rr.AdmiralCache.IdentityClusterCache.Get("bar") != nil || rr.AdmiralCache.IdentityClusterCache.Get("foo") != nil {
+		t.Errorf("Full reconcile should not process identities while Admiral is read-only")
+	}
+}
+
+func TestReconcileCluster(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+	rr := newReconcileTestRemoteRegistry(t, stop)
+
+	//only bar runs in cluster1
+	rr.reconcileCluster("cluster1")
+	if bar := rr.AdmiralCache.IdentityClusterCache.Get("bar"); bar == nil || len(bar.Copy()) != 2 {
+		t.Errorf("Expected bar to be reconciled, got %v", bar)
+	}
+	if foo := rr.AdmiralCache.IdentityClusterCache.Get("foo"); foo != nil {
+		t.Errorf("Expected foo not to be reconciled, got %v", foo.Copy())
+	}
+
+	//bar depends on foo, so the DRs of foo in cluster1 depend on the locality of cluster1
+	rr.AdmiralCache.IdentityDependencyCache.Put("foo", "bar", "bar")
+	rr.reconcileCluster("cluster1")
+	if foo := rr.AdmiralCache.IdentityClusterCache.Get("foo"); foo == nil || len(foo.Copy()) != 1 {
+		t.Errorf("Expected foo to be reconciled, got %v", foo)
+	}
+}
@@ -25,26 +25,34 @@ func InitAdmiral(ctx context.Context, params common.AdmiralParams) (*RemoteRegis
 
 	common.InitializeConfig(params)
 
-	CurrentAdmiralState = AdmiralState{ReadOnly: ReadOnlyEnabled, IsStateInitialized: StateNotInitialized}
-	startAdmiralStateChecker(ctx, params)
+	err := startAdmiralStateChecker(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf(" Error with admiral state checker init: %v", err)
+	}
 	pauseForAdmiralToInitializeState()
 
 	w := NewRemoteRegistry(ctx, params)
+	RegisterStateTransitionHook(w.onAdmiralStateTransition)
 
 	wd := DependencyHandler{
 		RemoteRegistry: w,
 	}
 
-	var err error
 	wd.DepController, err = admiral.NewDependencyController(ctx.Done(), &wd, params.KubeconfigPath, params.DependenciesNamespace, params.CacheRefreshDuration)
 	if err != nil {
 		return nil, fmt.Errorf(" Error with dependency controller init: %v", err)
 	}
+	w.dependencyController = wd.DepController
+	w.dependencyEventRecorder = newEventRecorder("", wd.DepController.K8sClient)
 
 	if !params.ArgoRolloutsEnabled {
 		log.Info("argo rollouts disabled")
 	}
 
+	if !params.StatefulSetsEnabled {
+		log.Info("statefulsets disabled")
+	}
+
 	configMapController, err := admiral.NewConfigMapController(params.ServiceEntryIPPrefix)
 	if err != nil {
 		return nil, fmt.Errorf(" Error with configmap controller init: %v", err)
@@ -52,6 +60,19 @@ func InitAdmiral(ctx context.Context, params common.AdmiralParams) (*RemoteRegis
 	w.AdmiralCache.ConfigMapController = configMapController
 	loadServiceEntryCacheData(w.AdmiralCache.ConfigMapController, w.AdmiralCache)
 
+	if common.GetReconcileDebounce() > 0 {
+		w.reconcileQueue = newIdentityReconcileQueue(ctx, func(identity string, env string) error {
+			_, err := modifyServiceEntryForNewServiceOrPod(admiral.Update, env, identity, w)
+			return err
+		})
+		go w.reconcileQueue.run()
+	}
+
+	if common.GetStatusUpdateQPS() > 0 && !common.GetDryRun() {
+		w.statusWriter = newStatusWriter(ctx, w)
+		go w.statusWriter.run()
+	}
+
 	err = createSecretController(ctx, w)
 	if err != nil {
 		return nil, fmt.Errorf(" Error with secret control init: %v", err)
@@ -67,7 +88,7 @@ func pauseForAdmiralToInitializeState() {
 	start := time.Now()
 	log.Info("Pausing thread to let Admiral determine it's READ-WRITE state. This is to let Admiral determine it's state during startup")
 	for {
-		if CurrentAdmiralState.IsStateInitialized {
+		if IsAdmiralStateInitialized() {
 			log.Infof("Time taken for Admiral to complete state initialization =%v ms", time.Since(start).Milliseconds())
 			break
 		}
@@ -125,6 +146,7 @@ func (r *RemoteRegistry) createCacheController(clientConfig *rest.Config, cluste
 	if err != nil {
 		return fmt.Errorf("error with ServiceController controller init: %v", err)
 	}
+	rc.eventRecorder = newEventRecorder(clusterID, rc.ServiceController.K8sClient)
 
 	log.Infof("starting global traffic policy controller custerID: %v", clusterID)
 
@@ -186,8 +208,22 @@ func (r *RemoteRegistry) createCacheController(clientConfig *rest.Config, cluste
 		}
 	}
 
+	if r.AdmiralCache != nil && r.AdmiralCache.statefulSetsEnabled {
+		log.Infof("starting statefulset controller clusterID: %v", clusterID)
+		rc.StatefulSetController, err = admiral.NewStatefulSetController(clusterID, stop, &StatefulSetHandler{RemoteRegistry: r, ClusterID: clusterID}, clientConfig, resyncPeriod)
+
+		if err != nil {
+			return fmt.Errorf("error with StatefulSet controller init: %v", err)
+		}
+	}
+
 	r.PutRemoteController(clusterID, &rc)
 
+	if common.GetOrphanSweepInterval() > 0 {
+		log.Infof("starting orphan sweeper clusterID: %v", clusterID)
+		go newOrphanSweeper(r, clusterID).run(stop)
+	}
+
 	log.Infof("Create Controller %s", clusterID)
 
 	return nil
@@ -217,6 +253,7 @@ func (r *RemoteRegistry) deleteCacheController(clusterID string) error {
 
 	if controller != nil {
 		close(controller.stop)
+		controller.eventRecorder.shutdown()
 	}
 
 	r.DeleteRemoteController(clusterID)
@@ -0,0 +1,79 @@
+package clusters
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+)
+
+/*
+IdentityRender is the configuration Admiral generates for an identity/env, rendered with the same logic as a reconcile
+but without writing it to the clusters or updating the caches.
+*/
+type IdentityRender struct {
+	Identity string `json:"Identity"`
+	Env      string `json:"Env"`
+	//GTP selected for the identity/env among the GTPs of all the clusters, nil when there is none
+	GlobalTrafficPolicy *v1.GlobalTrafficPolicy `json:"GlobalTrafficPolicy"`
+	//SEs and DRs Admiral would write, by cluster
+	Clusters map[string]*ClusterRender `json:"Clusters"`
+	//reasons a cluster, or a part of its configuration, was left out, by cluster
+	SkippedClusters map[string][]string `json:"SkippedClusters"`
+}
+
+// ClusterRender is the configuration Admiral would write to a cluster for an identity/env
+type ClusterRender struct {
+	ServiceEntries   []*v1alpha3.ServiceEntry    `json:"ServiceEntries"`
+	DestinationRules []*v1alpha3.DestinationRule `json:"DestinationRules"`
+}
+
+// RenderIdentityEnv returns the SEs and DRs Admiral would write for the identity/env, along with the selected GTP and the clusters left out
+func (r *RemoteRegistry) RenderIdentityEnv(identity string, env string) *IdentityRender {
+	render := &IdentityRender{
+		Identity:        identity,
+		Env:             env,
+		Clusters:        make(map[string]*ClusterRender),
+		SkippedClusters: make(map[string][]string),
+	}
+	generateServiceEntriesForIdentity(admiral.Add, env, identity, r, render)
+	for _, clusterRender := range render.Clusters {
+		sort.Slice(clusterRender.ServiceEntries, func(i, j int) bool {
+			return clusterRender.ServiceEntries[i].Name < clusterRender.ServiceEntries[j].Name
+		})
+		sort.Slice(clusterRender.DestinationRules, func(i, j int) bool {
+			return clusterRender.DestinationRules[i].Name < clusterRender.DestinationRules[j].Name
+		})
+	}
+	return render
+}
+
+//records why the cluster was left out, a no-op when not rendering
+func (render *IdentityRender) skip(clusterID string, reason string) {
+	if render == nil {
+		return
+	}
+	render.SkippedClusters[clusterID] = append(render.SkippedClusters[clusterID], reason)
+}
+
+//adds the SE/DR pairs the way addServiceEntriesWithDrToCluster writes them, the SEs without endpoints would be deleted and are left out
+func (render *IdentityRender) add(clusterID string, seDrs []*SeDrTuple, identities map[string]string) {
+	syncNamespace := common.GetSyncNamespace()
+	clusterRender := render.Clusters[clusterID]
+	if clusterRender == nil {
+		clusterRender = &ClusterRender{}
+		render.Clusters[clusterID] = clusterRender
+	}
+	for _, seDr := range seDrs {
+		if len(seDr.ServiceEntry.Endpoints) == 0 {
+			continue
+		}
+		serviceEntry := createServiceEntrySkeletion(*seDr.ServiceEntry, seDr.SeName, syncNamespace)
+		serviceEntry.Labels = map[string]string{common.GetWorkloadIdentifier(): fmt.Sprintf("%v", identities[seDr.ServiceEntry.Hosts[0]])}
+		clusterRender.ServiceEntries = append(clusterRender.ServiceEntries, serviceEntry)
+		clusterRender.DestinationRules = append(clusterRender.DestinationRules, createDestinationRuleSkeletion(*seDr.DestinationRule, seDr.DrName, syncNamespace))
+	}
+}
@@ -0,0 +1,127 @@
+package clusters
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/model"
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/test"
+	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestRenderIdentityEnv(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	rr := NewRemoteRegistry(nil, common.AdmiralParams{})
+	rr.AdmiralCache.ServiceEntryAddressStore.EntryAddresses["test.bar.mesh-se"] = common.LocalAddressPrefix + ".10.1"
+	rr.AdmiralCache.ServiceEntryAddressStore.Addresses = []string{common.LocalAddressPrefix + ".10.1"}
+
+	deployment := &k8sAppsV1.Deployment{
+		ObjectMeta: metaV1.ObjectMeta{Name: "bar", Namespace: "bar-ns"},
+		Spec: k8sAppsV1.DeploymentSpec{
+			Selector: &metaV1.LabelSelector{MatchLabels: map[string]string{"app": "bar"}},
+			Template: coreV1.PodTemplateSpec{
+				ObjectMeta: metaV1.ObjectMeta{Labels: map[string]string{"identity": "bar", "env": "test", "app": "bar"}},
+			},
+		},
+	}
+	service := &coreV1.Service{
+		ObjectMeta: metaV1.ObjectMeta{Name: "bar", Namespace: "bar-ns"},
+		Spec: coreV1.ServiceSpec{
+			Selector: map[string]string{"app": "bar"},
+			Ports:    []coreV1.ServicePort{{Name: "http", Port: 8080}},
+		},
+	}
+	gtp := &v1.GlobalTrafficPolicy{
+		ObjectMeta: metaV1.ObjectMeta{Name: "bar-gtp", Namespace: "bar-ns", Labels: map[string]string{"identity": "bar", "env": "test"}},
+		Spec: model.GlobalTrafficPolicy{
+			Policy: []*model.TrafficPolicy{{DnsPrefix: "west", LbType: model.TrafficPolicy_FAILOVER, Target: []*model.TrafficGroup{{Region: "us-west-2", Weight: 100}}}},
+		},
+	}
+
+	fakeIstioClient := istiofake.NewSimpleClientset()
+	config := rest.Config{Host: "localhost"}
+	gtpc, err := admiral.NewGlobalTrafficController("cluster1", stop, &test.MockGlobalTrafficHandler{}, &config, time.Second*time.Duration(300))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	gtpc.Cache.Put(gtp)
+
+	rc1 := newReconcileTestRemoteController(t, "cluster1", stop)
+	rc1.ServiceEntryController = &istio.ServiceEntryController{IstioClient: fakeIstioClient}
+	rc1.DestinationRuleController = &istio.DestinationRuleController{IstioClient: fakeIstioClient}
+	rc1.NodeController = &admiral.NodeController{Locality: &admiral.Locality{Region: "us-west-2"}}
+	rc1.GlobalTraffic = gtpc
+	rc1.DeploymentController.Cache.UpdateDeploymentToClusterCache("bar", deployment)
+	rc1.ServiceController.Cache.Put(service)
+	rr.PutRemoteController("cluster1", rc1)
+
+	//runs the workload without a service
+	rc2 := newReconcileTestRemoteController(t, "cluster2", stop)
+	rc2.DeploymentController.Cache.UpdateDeploymentToClusterCache("bar", deployment)
+	rr.PutRemoteController("cluster2", rc2)
+
+	render := rr.RenderIdentityEnv("bar", "test")
+
+	if render.GlobalTrafficPolicy == nil || render.GlobalTrafficPolicy.Name != "bar-gtp" {
+		t.Errorf("Wanted the GTP bar-gtp selected, got %v", render.GlobalTrafficPolicy)
+	}
+
+	cluster1 := render.Clusters["cluster1"]
+	if cluster1 == nil {
+		t.Fatalf("Wanted the configuration of cluster1, got %v", render.Clusters)
+	}
+	seNames := make([]string, 0)
+	for _, se := range cluster1.ServiceEntries {
+		seNames = append(seNames, se.Name)
+	}
+	drNames := make([]string, 0)
+	for _, dr := range cluster1.DestinationRules {
+		drNames = append(drNames, dr.Name)
+	}
+	if strings.Join(seNames, ",") != "test.bar.mesh-se,west.test.bar.mesh-se" {
+		t.Errorf("Wanted the ServiceEntries of the host and of the GTP dns prefix, got %v", seNames)
+	}
+	if strings.Join(drNames, ",") != "test.bar.mesh-default-dr,west.test.bar.mesh-dr" {
+		t.Errorf("Wanted the DestinationRules of the host and of the GTP dns prefix, got %v", drNames)
+	}
+	se := cluster1.ServiceEntries[0]
+	if se.Spec.Addresses[0] != common.LocalAddressPrefix+".10.1" || se.Spec.Endpoints[0].Address != "bar.bar-ns.svc.cluster.local" {
+		t.Errorf("Wanted the allocated address and the local endpoint, got %v", se.Spec.String())
+	}
+	if west := cluster1.ServiceEntries[1]; west.Spec.Addresses[0] != "" {
+		t.Errorf("Wanted no address allocated for the GTP dns prefix, got %v", west.Spec.Addresses)
+	}
+
+	if reasons := render.SkippedClusters["cluster2"]; len(reasons) != 1 || !strings.Contains(reasons[0], "no service") {
+		t.Errorf("Wanted cluster2 skipped as no service matches the workload, got %v", reasons)
+	}
+	if render.Clusters["cluster2"] != nil {
+		t.Errorf("Wanted no configuration for cluster2, got %v", render.Clusters["cluster2"])
+	}
+
+	//nothing written or cached
+	if actions := fakeIstioClient.Actions(); len(actions) != 0 {
+		t.Errorf("Wanted nothing written to the cluster, got %v", actions)
+	}
+	if len(rr.AdmiralCache.ServiceEntryAddressStore.EntryAddresses) != 1 {
+		t.Errorf("Wanted no address allocated, got %v", rr.AdmiralCache.ServiceEntryAddressStore.EntryAddresses)
+	}
+	if rr.AdmiralCache.IdentityClusterCache.Get("bar") != nil || rr.AdmiralCache.CnameClusterCache.Get("test.bar.mesh") != nil ||
+		rr.AdmiralCache.GlobalTrafficCache.GetFromIdentity("bar", "test") != nil {
+		t.Errorf("Wanted the caches left untouched")
+	}
+	if _, ok := rr.AdmiralCache.HostIdentityEnvCache.Load("test.bar.mesh"); ok {
+		t.Errorf("Wanted the caches left untouched")
+	}
+}
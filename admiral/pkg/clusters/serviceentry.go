@@ -1,6 +1,7 @@
 package clusters
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math"
@@ -20,7 +21,10 @@ import (
 	"istio.io/client-go/pkg/apis/networking/v1alpha3"
 	k8sAppsV1 "k8s.io/api/apps/v1"
 	k8sV1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
@@ -34,53 +38,47 @@ type SeDrTuple struct {
 	DestinationRule *networking.DestinationRule
 }
 
-func createServiceEntry(event admiral.EventType, rc *RemoteController, admiralCache *AdmiralCache,
-	meshPorts map[string]uint32, destDeployment *k8sAppsV1.Deployment, serviceEntries map[string]*networking.ServiceEntry) *networking.ServiceEntry {
-
-	workloadIdentityKey := common.GetWorkloadIdentifier()
-	globalFqdn := common.GetCname(destDeployment, workloadIdentityKey, common.GetHostnameSuffix())
-
-	//Handling retries for getting/putting service entries from/in cache
-
-	address := getUniqueAddress(admiralCache, globalFqdn)
-
-	if len(globalFqdn) == 0 || len(address) == 0 {
-		return nil
-	}
-
-	san := getSanForDeployment(destDeployment, workloadIdentityKey)
-
-	tmpSe := generateServiceEntry(event, admiralCache, meshPorts, globalFqdn, rc, serviceEntries, address, san)
-	return tmpSe
-}
-
-func modifyServiceEntryForNewServiceOrPod(event admiral.EventType, env string, sourceIdentity string, remoteRegistry *RemoteRegistry) map[string]*networking.ServiceEntry {
+/*
+modifyServiceEntryForNewServiceOrPod generates the SEs and DRs of the identity/env and writes them to the source and dependent clusters.
+The returned error aggregates the ClusterErrors of the clusters that couldn't be written to, the identity/env needs to be reconciled again for them.
+*/
+func modifyServiceEntryForNewServiceOrPod(event admiral.EventType, env string, sourceIdentity string, remoteRegistry *RemoteRegistry) (map[string]*networking.ServiceEntry, error) {
 
 	defer util.LogElapsedTime("modifyServiceEntryForNewServiceOrPod", sourceIdentity, env, "")()
 
-	if CurrentAdmiralState.ReadOnly {
+	if IsAdmiralReadOnly() {
 		log.Infof(LogFormat, event, env, sourceIdentity, "", "Processing skipped as Admiral is in Read-only mode")
-		return nil
+		return nil, nil
 	}
 
 	if IsCacheWarmupTime(remoteRegistry) {
 		log.Infof(LogFormat, event, env, sourceIdentity, "", "Processing skipped during cache warm up state")
-		return nil
+		return nil, nil
 	}
+	return generateServiceEntriesForIdentity(event, env, sourceIdentity, remoteRegistry, nil)
+}
+
+/*
+generateServiceEntriesForIdentity is the body of modifyServiceEntryForNewServiceOrPod.
+With a render, the SEs and DRs are collected in it instead of being written to the clusters, and the caches are left untouched.
+*/
+func generateServiceEntriesForIdentity(event admiral.EventType, env string, sourceIdentity string, remoteRegistry *RemoteRegistry, render *IdentityRender) (map[string]*networking.ServiceEntry, error) {
 	//create a service entry, destination rule and virtual service in the local cluster
 	sourceServices := make(map[string]*k8sV1.Service)
 	sourceWeightedServices := make(map[string]map[string]*WeightedService)
-	sourceDeployments := make(map[string]*k8sAppsV1.Deployment)
-	sourceRollouts := make(map[string]*argo.Rollout)
+	sourceWorkloads := make(map[string]Workload)
+	//hosts with an endpoint specific to the cluster running the workload, key=cluster value=map of host to local endpoint
+	sourceLocalEndpoints := make(map[string]map[string]*LocalEndpoint)
+	//hosts, other than the cname, that only have local endpoints (like statefulset pod hostnames)
+	localOnlyHosts := make(map[string]bool)
 
 	var serviceEntries = make(map[string]*networking.ServiceEntry)
+	//errors of the writes to the clusters
+	errs := make([]error, 0)
 
 	var cname string
 	cnames := make(map[string]string)
 	var serviceInstance *k8sV1.Service
-	var weightedServices map[string]*WeightedService
-	var rollout *argo.Rollout
-	var deployment *k8sAppsV1.Deployment
 	var gtps = make(map[string][]*v1.GlobalTrafficPolicy)
 
 	var namespace string
@@ -89,6 +87,9 @@ func modifyServiceEntryForNewServiceOrPod(event admiral.EventType, env string, s
 
 	start := time.Now()
 
+	//the workloads and GTPs left out are reported with events, unless the workload is going away
+	recordEvents := render == nil && event != admiral.Delete
+
 	clusters := remoteRegistry.GetClusterIds()
 
 	for _, clusterId := range clusters {
@@ -97,54 +98,69 @@ func modifyServiceEntryForNewServiceOrPod(event admiral.EventType, env string, s
 
 		if rc == nil {
 			log.Warnf(LogFormat, "Find", "remote-controller", clusterId, clusterId, "remote controller not available/initialized for the cluster")
+			render.skip(clusterId, "remote controller not available/initialized for the cluster")
 			continue
 		}
 
-		if rc.DeploymentController != nil {
-			deployment = rc.DeploymentController.Cache.Get(sourceIdentity, env)
-		}
-
-		if rc.RolloutController != nil {
-			rollout = rc.RolloutController.Cache.Get(sourceIdentity, env)
+		workload := getWorkload(rc, sourceIdentity, env)
+		if workload == nil {
+			continue
 		}
 
-		if deployment != nil {
+		if render == nil {
 			remoteRegistry.AdmiralCache.IdentityClusterCache.Put(sourceIdentity, rc.ClusterID, rc.ClusterID)
-			serviceInstance = getServiceForDeployment(rc, deployment)
-			if serviceInstance == nil {
-				continue
+		}
+		weightedServices := workload.GetServices(rc)
+		if len(weightedServices) == 0 {
+			render.skip(clusterId, fmt.Sprintf("no service with a mesh port matches %s %s/%s", workload.GetKind(), workload.GetObjectMeta().Namespace, workload.GetObjectMeta().Name))
+			if recordEvents {
+				rc.eventRecorder.workloadEvent(workload, k8sV1.EventTypeWarning, serviceNotFoundReason,
+					"No service with a mesh port matches the pod labels, the workload is not added to the mesh")
 			}
-			namespace = deployment.Namespace
-			localMeshPorts := GetMeshPorts(rc.ClusterID, serviceInstance, deployment)
+			continue
+		}
 
-			cname = common.GetCname(deployment, common.GetWorkloadIdentifier(), common.GetHostnameSuffix())
-			sourceDeployments[rc.ClusterID] = deployment
-			createServiceEntry(event, rc, remoteRegistry.AdmiralCache, localMeshPorts, deployment, serviceEntries)
-		} else if rollout != nil {
-			remoteRegistry.AdmiralCache.IdentityClusterCache.Put(sourceIdentity, rc.ClusterID, rc.ClusterID)
-			weightedServices = getServiceForRollout(rc, rollout)
-			if len(weightedServices) == 0 {
-				continue
+		//use any service within the weightedServices for determining ports etc.
+		for _, sInstance := range weightedServices {
+			serviceInstance = sInstance.Service
+			break
+		}
+		namespace = workload.GetObjectMeta().Namespace
+		localMeshPorts := GetMeshPortsForWorkload(rc.ClusterID, serviceInstance, workload)
+		if len(localMeshPorts) == 0 {
+			render.skip(clusterId, fmt.Sprintf("no mesh ports found in service %s/%s, the service entries have no port for the cluster", serviceInstance.Namespace, serviceInstance.Name))
+			if recordEvents {
+				rc.eventRecorder.workloadEvent(workload, k8sV1.EventTypeWarning, meshPortsNotFoundReason,
+					fmt.Sprintf("No mesh ports found in service %s/%s, the ServiceEntries have no port for the cluster", serviceInstance.Namespace, serviceInstance.Name))
 			}
+		}
+		if render != nil && len(makeRemoteEndpointsForServiceEntry(rc, nil)) == 0 {
+			render.skip(clusterId, "no east west gateway address found, the endpoints of the cluster are left out of the other clusters")
+		}
 
-			//use any service within the weightedServices for determining ports etc.
-			for _, sInstance := range weightedServices {
-				serviceInstance = sInstance.Service
-				break
+		cname = getCnameForWorkload(workload)
+		sourceWorkloads[rc.ClusterID] = workload
+		localEndpoints := workload.GetLocalEndpoints(rc.ClusterID, cname, weightedServices)
+		//the dependents reach the global cname, along with the local services the endpoints point to (like the active and preview services of a blue green rollout)
+		cnames[cname] = "1"
+		for host, localEndpoint := range localEndpoints {
+			if host != cname {
+				localOnlyHosts[host] = true
+				if render == nil {
+					remoteRegistry.AdmiralCache.CnameIdentityCache.Store(host, sourceIdentity)
+				}
 			}
-			namespace = rollout.Namespace
-			localMeshPorts := GetMeshPortsForRollout(rc.ClusterID, serviceInstance, rollout)
-
-			cname = common.GetCnameForRollout(rollout, common.GetWorkloadIdentifier(), common.GetHostnameSuffix())
-			cnames[cname] = "1"
-			sourceRollouts[rc.ClusterID] = rollout
-			createServiceEntryForRollout(event, rc, remoteRegistry.AdmiralCache, localMeshPorts, rollout, serviceEntries)
-		} else {
-			continue
+			cnames[localEndpoint.Address] = "1"
 		}
+		sourceLocalEndpoints[rc.ClusterID] = localEndpoints
+		createServiceEntryForWorkload(event, rc, remoteRegistry.AdmiralCache, localMeshPorts, workload, localEndpoints, serviceEntries, render)
 
 		gtpsInNamespace := rc.GlobalTraffic.Cache.Get(gtpKey, namespace)
 		if len(gtpsInNamespace) > 0 {
+			if recordEvents && len(getClusterLocality(rc)) == 0 {
+				rc.eventRecorder.workloadEvent(workload, k8sV1.EventTypeWarning, localityNotFoundReason,
+					"The locality of the cluster nodes cannot be determined, the GTPs are not applied to the DestinationRules of the cluster")
+			}
 			if log.IsLevelEnabled(log.DebugLevel) {
 				log.Debugf("GTPs found for identity=%s in env=%s namespace=%s gtp=%v", sourceIdentity, env, namespace, gtpsInNamespace)
 			}
@@ -153,16 +169,26 @@ func modifyServiceEntryForNewServiceOrPod(event admiral.EventType, env string, s
 			log.Debugf("No GTPs found for identity=%s in env=%s namespace=%s with key=%s", sourceIdentity, env, namespace, gtpKey)
 		}
 
-		remoteRegistry.AdmiralCache.CnameClusterCache.Put(cname, rc.ClusterID, rc.ClusterID)
-		remoteRegistry.AdmiralCache.CnameIdentityCache.Store(cname, sourceIdentity)
+		if render == nil {
+			remoteRegistry.AdmiralCache.CnameClusterCache.Put(cname, rc.ClusterID, rc.ClusterID)
+			remoteRegistry.AdmiralCache.CnameIdentityCache.Store(cname, sourceIdentity)
+		}
 		sourceServices[rc.ClusterID] = serviceInstance
 		sourceWeightedServices[rc.ClusterID] = weightedServices
 	}
 
 	util.LogElapsedTimeSince("BuildServiceEntry", sourceIdentity, env, "", start)
 
-	//cache the latest GTP in global cache to be reused during DR creation
-	updateGlobalGtpCache(remoteRegistry.AdmiralCache, sourceIdentity, env, gtps)
+	if render == nil {
+		for host := range serviceEntries {
+			remoteRegistry.AdmiralCache.HostIdentityEnvCache.Store(host, identityEnv{identity: sourceIdentity, env: env})
+		}
+
+		//cache the latest GTP in global cache to be reused during DR creation
+		updateGlobalGtpCache(remoteRegistry.AdmiralCache, sourceIdentity, env, gtps)
+	} else {
+		render.GlobalTrafficPolicy = getMostRecentGtp(sourceIdentity, env, gtps)
+	}
 
 	dependents := remoteRegistry.AdmiralCache.IdentityDependencyCache.Get(sourceIdentity).Copy()
 
@@ -174,55 +200,55 @@ func modifyServiceEntryForNewServiceOrPod(event admiral.EventType, env string, s
 	for sourceCluster, serviceInstance := range sourceServices {
 		localFqdn := serviceInstance.Name + common.Sep + serviceInstance.Namespace + common.DotLocalDomainSuffix
 		rc := remoteRegistry.GetRemoteController(sourceCluster)
-		var meshPorts map[string]uint32
-		blueGreenStrategy := isBlueGreenStrategy(sourceRollouts[sourceCluster])
-
-		if len(sourceDeployments) > 0 {
-			meshPorts = GetMeshPorts(sourceCluster, serviceInstance, sourceDeployments[sourceCluster])
-		} else {
-			meshPorts = GetMeshPortsForRollout(sourceCluster, serviceInstance, sourceRollouts[sourceCluster])
+		meshPorts := GetMeshPortsForWorkload(sourceCluster, serviceInstance, sourceWorkloads[sourceCluster])
+		gatewayAddresses := make(map[string]bool)
+		for _, loadBalancer := range getLoadBalancers(rc) {
+			gatewayAddresses[loadBalancer.Address] = true
 		}
 
 		for key, serviceEntry := range serviceEntries {
-			if len(serviceEntry.Endpoints) == 0 {
-				AddServiceEntriesWithDr(remoteRegistry, map[string]string{sourceCluster: sourceCluster},
-					map[string]*networking.ServiceEntry{key: serviceEntry})
+			localEndpoint, isLocalHost := sourceLocalEndpoints[sourceCluster][key]
+			//hosts that only have local endpoints are not written to the other source clusters
+			if localOnlyHosts[key] && !isLocalHost {
+				continue
+			}
+			//replace the istio ingress-gateway addresses with the local fqdn
+			var se, ep = removeGatewayEndpoints(serviceEntry, gatewayAddresses)
+			if ep == nil && len(gatewayAddresses) == 0 && event != admiral.Delete {
+				//the gateway of the cluster is not found, so the service entry doesn't have an endpoint of the cluster to replace
+				ep = &networking.ServiceEntry_Endpoint{Locality: getClusterLocality(rc), Network: rc.ServiceController.GetClusterNetwork()}
+				se.Endpoints = append(se.Endpoints, ep)
 			}
-			clusterIngress, _ := rc.ServiceController.Cache.GetLoadBalancer(common.GetAdmiralParams().LabelSet.GatewayApp, common.NamespaceIstioSystem)
-			for _, ep := range serviceEntry.Endpoints {
-				//replace istio ingress-gateway address with local fqdn, note that ingress-gateway can be empty (not provisoned, or is not up)
-				if ep.Address == clusterIngress || ep.Address == "" {
-					// Update endpoints with locafqdn for active and preview se of bluegreen rollout
-					if blueGreenStrategy {
-						oldPorts := ep.Ports
-						updateEndpointsForBlueGreen(sourceRollouts[sourceCluster], sourceWeightedServices[sourceCluster], cnames, ep, sourceCluster, key)
-
-						AddServiceEntriesWithDr(remoteRegistry, map[string]string{sourceCluster: sourceCluster},
-							map[string]*networking.ServiceEntry{key: serviceEntry})
-						//swap it back to use for next iteration
-						ep.Address = clusterIngress
-						ep.Ports = oldPorts
-						// see if we have weighted services (rollouts with canary strategy)
-					} else if len(sourceWeightedServices[sourceCluster]) > 1 {
-						//add one endpoint per each service, may be modify
-						var se = copyServiceEntry(serviceEntry)
-						updateEndpointsForWeightedServices(se, sourceWeightedServices[sourceCluster], clusterIngress, meshPorts)
-						AddServiceEntriesWithDr(remoteRegistry, map[string]string{sourceCluster: sourceCluster},
-							map[string]*networking.ServiceEntry{key: se})
-					} else {
-						ep.Address = localFqdn
-						oldPorts := ep.Ports
-						ep.Ports = meshPorts
-						AddServiceEntriesWithDr(remoteRegistry, map[string]string{sourceCluster: sourceCluster},
-							map[string]*networking.ServiceEntry{key: serviceEntry})
-						//swap it back to use for next iteration
-						ep.Address = clusterIngress
-						ep.Ports = oldPorts
+			if ep == nil {
+				if len(serviceEntry.Endpoints) == 0 {
+					if err := addServiceEntriesWithDr(remoteRegistry, map[string]string{sourceCluster: sourceCluster},
+						map[string]*networking.ServiceEntry{key: serviceEntry}, render); err != nil {
+						errs = append(errs, err)
 					}
 				}
+				continue
+			}
+			if isLocalHost {
+				// Update endpoints with the endpoint the workload picked for the host (like the active and preview se of bluegreen rollout)
+				ep.Address = localEndpoint.Address
+				ep.Ports = localEndpoint.Ports
+			} else if len(sourceWeightedServices[sourceCluster]) > 1 {
+				// see if we have weighted services (rollouts with canary strategy), add one endpoint per each service
+				updateEndpointsForWeightedServices(se, sourceWeightedServices[sourceCluster], ep.Address, meshPorts)
+			} else {
+				ep.Address = localFqdn
+				ep.Ports = meshPorts
+			}
+			if err := addServiceEntriesWithDr(remoteRegistry, map[string]string{sourceCluster: sourceCluster},
+				map[string]*networking.ServiceEntry{key: se}, render); err != nil {
+				errs = append(errs, err)
 			}
 		}
 
+		if render != nil {
+			continue
+		}
+
 		if common.GetWorkloadSidecarUpdate() == "enabled" {
 			modifySidecarForLocalClusterCommunication(serviceInstance.Namespace, remoteRegistry.AdmiralCache.DependencyNamespaceCache.Get(sourceIdentity), rc)
 		}
@@ -242,15 +268,36 @@ func modifyServiceEntryForNewServiceOrPod(event admiral.EventType, env string, s
 	dependentClusters := getDependentClusters(dependents, remoteRegistry.AdmiralCache.IdentityClusterCache, sourceServices)
 
 	//update cname dependent cluster cache
-	for clusterId := range dependentClusters {
-		remoteRegistry.AdmiralCache.CnameDependentClusterCache.Put(cname, clusterId, clusterId)
+	if render == nil {
+		for clusterId := range dependentClusters {
+			remoteRegistry.AdmiralCache.CnameDependentClusterCache.Put(cname, clusterId, clusterId)
+		}
 	}
 
-	AddServiceEntriesWithDr(remoteRegistry, dependentClusters, serviceEntries)
+	if err := addServiceEntriesWithDr(remoteRegistry, dependentClusters, serviceEntries, render); err != nil {
+		errs = append(errs, err)
+	}
 
 	util.LogElapsedTimeSince("WriteServiceEntryToDependentClusters", sourceIdentity, env, "", start)
 
-	return serviceEntries
+	err := utilerrors.Flatten(utilerrors.NewAggregate(errs))
+	if render == nil {
+		writtenClusters := make(map[string]bool)
+		for clusterId := range sourceServices {
+			writtenClusters[clusterId] = true
+		}
+		for clusterId := range dependentClusters {
+			writtenClusters[clusterId] = true
+		}
+		activeGtp := getMostRecentGtp(sourceIdentity, env, gtps)
+		if recordEvents && activeGtp != nil {
+			recordGtpEvents(remoteRegistry, sourceIdentity, env, gtps, activeGtp)
+		}
+		remoteRegistry.statusWriter.reconciled(sourceIdentity, env, gtps, activeGtp, getSyncedClusters(writtenClusters, err),
+			getGeneratedHosts(env, serviceEntries, activeGtp), err)
+	}
+
+	return serviceEntries, err
 }
 
 //Does two things;
@@ -258,22 +305,13 @@ func modifyServiceEntryForNewServiceOrPod(event admiral.EventType, env string, s
 //ii) Updates the global GTP cache with the selected GTP in i)
 func updateGlobalGtpCache(cache *AdmiralCache, identity, env string, gtps map[string][]*v1.GlobalTrafficPolicy) {
 	defer util.LogElapsedTime("updateGlobalGtpCache", identity, env, "")()
-	gtpsOrdered := make([]*v1.GlobalTrafficPolicy, 0)
-	for _, gtpsInCluster := range gtps {
-		gtpsOrdered = append(gtpsOrdered, gtpsInCluster...)
-	}
-	if len(gtpsOrdered) == 0 {
+	mostRecentGtp := getMostRecentGtp(identity, env, gtps)
+	if mostRecentGtp == nil {
 		log.Debugf("No GTPs found for identity=%s in env=%s. Deleting global cache entries if any", identity, env)
 		cache.GlobalTrafficCache.Delete(identity, env)
 		return
-	} else if len(gtpsOrdered) > 1 {
-		log.Debugf("More than one GTP found for identity=%s in env=%s.", identity, env)
-		//sort by creation time and priority, gtp with highest priority and most recent at the beginning
-		sortGtpsByPriorityAndCreationTime(gtpsOrdered, identity, env)
 	}
 
-	mostRecentGtp := gtpsOrdered[0]
-
 	err := cache.GlobalTrafficCache.Put(mostRecentGtp)
 
 	if err != nil {
@@ -283,6 +321,22 @@ func updateGlobalGtpCache(cache *AdmiralCache, identity, env string, gtps map[st
 	}
 }
 
+//returns the GTP with the highest priority, the most recent one among them, from the GTPs of all the clusters. nil when there is none
+func getMostRecentGtp(identity, env string, gtps map[string][]*v1.GlobalTrafficPolicy) *v1.GlobalTrafficPolicy {
+	gtpsOrdered := make([]*v1.GlobalTrafficPolicy, 0)
+	for _, gtpsInCluster := range gtps {
+		gtpsOrdered = append(gtpsOrdered, gtpsInCluster...)
+	}
+	if len(gtpsOrdered) == 0 {
+		return nil
+	} else if len(gtpsOrdered) > 1 {
+		log.Debugf("More than one GTP found for identity=%s in env=%s.", identity, env)
+		//sort by creation time and priority, gtp with highest priority and most recent at the beginning
+		sortGtpsByPriorityAndCreationTime(gtpsOrdered, identity, env)
+	}
+	return gtpsOrdered[0]
+}
+
 func sortGtpsByPriorityAndCreationTime(gtpsToOrder []*v1.GlobalTrafficPolicy, identity string, env string) {
 	sort.Slice(gtpsToOrder, func(i, j int) bool {
 		iPriority := getGtpPriority(gtpsToOrder[i])
@@ -405,6 +459,9 @@ func modifySidecarForLocalClusterCommunication(sidecarNamespace string, sidecarE
 
 func addUpdateSidecar(obj *v1alpha3.Sidecar, exist *v1alpha3.Sidecar, namespace string, rc *RemoteController) {
 	var err error
+	if recordPendingChange(rc, common.Sidecar, &obj.ObjectMeta, namespace, "Update", "", &exist.Spec, &obj.Spec) {
+		return
+	}
 	exist.Labels = obj.Labels
 	exist.Annotations = obj.Annotations
 	exist.Spec = obj.Spec
@@ -425,12 +482,19 @@ func copySidecar(sidecar *v1alpha3.Sidecar) *v1alpha3.Sidecar {
 	return newSidecarObj
 }
 
-//This will create the default service entries and also additional ones specified in GTP
-func AddServiceEntriesWithDr(rr *RemoteRegistry, sourceClusters map[string]string, serviceEntries map[string]*networking.ServiceEntry) {
+//AddServiceEntriesWithDr writes the SEs and their DRs to the source clusters concurrently, the returned error aggregates the ClusterErrors of the clusters that failed
+func AddServiceEntriesWithDr(rr *RemoteRegistry, sourceClusters map[string]string, serviceEntries map[string]*networking.ServiceEntry) error {
+	return addServiceEntriesWithDr(rr, sourceClusters, serviceEntries, nil)
+}
+
+//with a render, the SE/DR sets are added to it instead of being written to the clusters
+func addServiceEntriesWithDr(rr *RemoteRegistry, sourceClusters map[string]string, serviceEntries map[string]*networking.ServiceEntry, render *IdentityRender) error {
 
 	cache := rr.AdmiralCache
 
-	syncNamespace := common.GetSyncNamespace()
+	//the SE/DR sets are built upfront, building them can allocate the addresses of new hosts
+	seDrSets := make(map[string][]*SeDrTuple)
+	identities := make(map[string]string)
 	for _, se := range serviceEntries {
 
 		var identityId string
@@ -442,58 +506,118 @@ func AddServiceEntriesWithDr(rr *RemoteRegistry, sourceClusters map[string]strin
 		var env = splitByEnv[0]
 
 		globalTrafficPolicy := cache.GlobalTrafficCache.GetFromIdentity(identityId, env)
+		if render != nil {
+			identityId, globalTrafficPolicy = render.Identity, render.GlobalTrafficPolicy
+		}
 
 		for _, sourceCluster := range sourceClusters {
 
 			rc := rr.GetRemoteController(sourceCluster)
 
-			if rc == nil || rc.NodeController == nil || rc.NodeController.Locality == nil {
+			var locality string
+			if rc != nil {
+				locality = getClusterLocality(rc)
+			}
+			if len(locality) == 0 {
 				log.Warnf(LogFormat, "Find", "remote-controller", sourceCluster, sourceCluster, "locality not available for the cluster")
+				render.skip(sourceCluster, "locality not available for the cluster")
 				continue
 			}
 
 			//check if there is a gtp and add additional hosts/destination rules
-			var seDrSet = createSeAndDrSetFromGtp(env, rc.NodeController.Locality.Region, se, globalTrafficPolicy, cache)
+			var seDrSet = createSeAndDrSetFromGtp(env, locality, se, globalTrafficPolicy, cache, render)
 
 			for _, seDr := range seDrSet {
-				oldServiceEntry, err := rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(syncNamespace).Get(seDr.SeName, v12.GetOptions{})
-				// if old service entry not find, just create a new service entry instead
-				if err != nil {
-					log.Infof(LogFormat, "Get (error)", "old ServiceEntry", seDr.SeName, sourceCluster, err)
-					oldServiceEntry = nil
-				}
-				oldDestinationRule, err := rc.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules(syncNamespace).Get(seDr.DrName, v12.GetOptions{})
+				seDrSets[sourceCluster] = append(seDrSets[sourceCluster], seDr)
+				identities[seDr.ServiceEntry.Hosts[0]] = identityId
+			}
+		}
+	}
 
-				if err != nil {
-					log.Infof(LogFormat, "Get (error)", "old DestinationRule", seDr.DrName, sourceCluster, err)
-					oldDestinationRule = nil
-				}
+	if render != nil {
+		for clusterID, seDrs := range seDrSets {
+			render.add(clusterID, seDrs, identities)
+		}
+		return nil
+	}
 
-				if len(seDr.ServiceEntry.Endpoints) == 0 {
-					deleteServiceEntry(oldServiceEntry, syncNamespace, rc)
-					cache.SeClusterCache.Delete(seDr.ServiceEntry.Hosts[0])
-					// after deleting the service entry, destination rule also need to be deleted if the service entry host no longer exists
-					deleteDestinationRule(oldDestinationRule, syncNamespace, rc)
-				} else {
-					newServiceEntry := createServiceEntrySkeletion(*seDr.ServiceEntry, seDr.SeName, syncNamespace)
-
-					if newServiceEntry != nil {
-						newServiceEntry.Labels = map[string]string{common.GetWorkloadIdentifier(): fmt.Sprintf("%v", identityId)}
-						addUpdateServiceEntry(newServiceEntry, oldServiceEntry, syncNamespace, rc)
-						cache.SeClusterCache.Put(newServiceEntry.Spec.Hosts[0], rc.ClusterID, rc.ClusterID)
-					}
+	clusterIDs := make([]string, 0, len(seDrSets))
+	for clusterID := range seDrSets {
+		clusterIDs = append(clusterIDs, clusterID)
+	}
+	return forEachCluster(rr.ctx, clusterIDs, func(ctx context.Context, clusterID string) error {
+		return addServiceEntriesWithDrToCluster(ctx, rr, rr.GetRemoteController(clusterID), seDrSets[clusterID], identities)
+	})
+}
+
+//writes the SE/DR pairs to the cluster, the pairs that failed don't stop the others. Stops once ctx is done
+func addServiceEntriesWithDrToCluster(ctx context.Context, rr *RemoteRegistry, rc *RemoteController, seDrs []*SeDrTuple, identities map[string]string) error {
+	cache := rr.AdmiralCache
+	syncNamespace := common.GetSyncNamespace()
+	errs := make([]error, 0)
+
+	for _, seDr := range seDrs {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+		oldServiceEntry, err := rc.ServiceEntryController.Get(syncNamespace, seDr.SeName)
+		// if old service entry not find, just create a new service entry instead
+		if err != nil {
+			log.Infof(LogFormat, "Get (error)", "old ServiceEntry", seDr.SeName, rc.ClusterID, err)
+			if !k8sErrors.IsNotFound(err) {
+				errs = append(errs, err)
+				continue
+			}
+			oldServiceEntry = nil
+		}
+		oldDestinationRule, err := rc.DestinationRuleController.Get(syncNamespace, seDr.DrName)
 
-					newDestinationRule := createDestinationRuleSkeletion(*seDr.DestinationRule, seDr.DrName, syncNamespace)
-					// if event was deletion when this function was called, then GlobalTrafficCache should already deleted the cache globalTrafficPolicy is an empty shell object
-					addUpdateDestinationRule(newDestinationRule, oldDestinationRule, syncNamespace, rc)
+		if err != nil {
+			log.Infof(LogFormat, "Get (error)", "old DestinationRule", seDr.DrName, rc.ClusterID, err)
+			if !k8sErrors.IsNotFound(err) {
+				errs = append(errs, err)
+				continue
+			}
+			oldDestinationRule = nil
+		}
+
+		if len(seDr.ServiceEntry.Endpoints) == 0 {
+			//the host is not desired anymore before the delete, so that the delete is not taken for a drift
+			cache.SeClusterCache.DeleteMap(seDr.ServiceEntry.Hosts[0], rc.ClusterID)
+			if err := deleteServiceEntry(oldServiceEntry, syncNamespace, rc); err != nil {
+				cache.SeClusterCache.Put(seDr.ServiceEntry.Hosts[0], rc.ClusterID, rc.ClusterID)
+				errs = append(errs, err)
+				continue
+			}
+			// after deleting the service entry, destination rule also need to be deleted if the service entry host no longer exists
+			if err := deleteDestinationRule(oldDestinationRule, syncNamespace, rc); err != nil {
+				errs = append(errs, err)
+			}
+		} else {
+			newServiceEntry := createServiceEntrySkeletion(*seDr.ServiceEntry, seDr.SeName, syncNamespace)
+
+			if newServiceEntry != nil {
+				newServiceEntry.Labels = map[string]string{common.GetWorkloadIdentifier(): fmt.Sprintf("%v", identities[seDr.ServiceEntry.Hosts[0]])}
+				if err := addUpdateServiceEntry(newServiceEntry, oldServiceEntry, syncNamespace, rc); err != nil {
+					errs = append(errs, err)
+					continue
 				}
+				cache.SeClusterCache.Put(newServiceEntry.Spec.Hosts[0], rc.ClusterID, rc.ClusterID)
+			}
+
+			newDestinationRule := createDestinationRuleSkeletion(*seDr.DestinationRule, seDr.DrName, syncNamespace)
+			// if event was deletion when this function was called, then GlobalTrafficCache should already deleted the cache globalTrafficPolicy is an empty shell object
+			if err := addUpdateDestinationRule(newDestinationRule, oldDestinationRule, syncNamespace, rc); err != nil {
+				errs = append(errs, err)
 			}
 		}
 	}
+	return utilerrors.NewAggregate(errs)
 }
 
-func createSeAndDrSetFromGtp(env, region string, se *networking.ServiceEntry, globalTrafficPolicy *v1.GlobalTrafficPolicy,
-	cache *AdmiralCache) map[string]*SeDrTuple {
+func createSeAndDrSetFromGtp(env, locality string, se *networking.ServiceEntry, globalTrafficPolicy *v1.GlobalTrafficPolicy,
+	cache *AdmiralCache, render *IdentityRender) map[string]*SeDrTuple {
 	var defaultDrName = getIstioResourceName(se.Hosts[0], "-default-dr")
 	var defaultSeName = getIstioResourceName(se.Hosts[0], "-se")
 	var seDrSet = make(map[string]*SeDrTuple)
@@ -512,12 +636,12 @@ func createSeAndDrSetFromGtp(env, region string, se *networking.ServiceEntry, gl
 				drName, seName = getIstioResourceName(host, "-dr"), getIstioResourceName(host, "-se")
 				modifiedSe = copyServiceEntry(se)
 				modifiedSe.Hosts[0] = host
-				modifiedSe.Addresses[0] = getUniqueAddress(cache, host)
+				modifiedSe.Addresses[0] = getServiceEntryAddress(cache, host, render)
 			}
 			var seDr = &SeDrTuple{
 				DrName:          drName,
 				SeName:          seName,
-				DestinationRule: getDestinationRule(modifiedSe, region, gtpTrafficPolicy),
+				DestinationRule: getDestinationRule(modifiedSe, locality, gtpTrafficPolicy),
 				ServiceEntry:    modifiedSe,
 			}
 			seDrSet[host] = seDr
@@ -528,7 +652,7 @@ func createSeAndDrSetFromGtp(env, region string, se *networking.ServiceEntry, gl
 		var seDr = &SeDrTuple{
 			DrName:          defaultDrName,
 			SeName:          defaultSeName,
-			DestinationRule: getDestinationRule(se, region, nil),
+			DestinationRule: getDestinationRule(se, locality, nil),
 			ServiceEntry:    se,
 		}
 		seDrSet[se.Hosts[0]] = seDr
@@ -536,10 +660,84 @@ func createSeAndDrSetFromGtp(env, region string, se *networking.ServiceEntry, gl
 	return seDrSet
 }
 
-func makeRemoteEndpointForServiceEntry(address string, locality string, portName string, portNumber int) *networking.ServiceEntry_Endpoint {
+//remote endpoints point to the gateway of the cluster, so every service entry port is mapped to the gateway port
+func makeRemoteEndpointForServiceEntry(address string, locality string, portNames []string, portNumber int) *networking.ServiceEntry_Endpoint {
+	var ports = make(map[string]uint32)
+	for _, portName := range portNames {
+		ports[portName] = uint32(portNumber)
+	}
 	return &networking.ServiceEntry_Endpoint{Address: address,
 		Locality: locality,
-		Ports:    map[string]uint32{portName: uint32(portNumber)}} //
+		Ports:    ports}
+}
+
+//returns the east west gateways of the cluster, matched with --cluster_gateway_selectors or app=<gateway_app>
+func getLoadBalancers(rc *RemoteController) []admiral.LoadBalancer {
+	gatewaySelector := common.GetClusterGatewaySelector(rc.ClusterID)
+	selector, err := labels.Parse(gatewaySelector)
+	if err != nil {
+		log.Errorf(LogErrFormat, "Parse", "gateway-selector", gatewaySelector, rc.ClusterID, err)
+		selector = labels.Nothing()
+	}
+	return rc.ServiceController.Cache.GetLoadBalancers(selector, common.NamespaceIstioSystem)
+}
+
+/*
+makeRemoteEndpointsForServiceEntry returns an endpoint per east west gateway address of the cluster, none when the gateways don't have an address.
+A gateway without a network or a locality of its own uses the network and the locality of the cluster.
+*/
+func makeRemoteEndpointsForServiceEntry(rc *RemoteController, portNames []string) []*networking.ServiceEntry_Endpoint {
+	clusterLocality := getClusterLocality(rc)
+	clusterNetwork := rc.ServiceController.GetClusterNetwork()
+
+	loadBalancers := getLoadBalancers(rc)
+	endpoints := make([]*networking.ServiceEntry_Endpoint, 0, len(loadBalancers))
+	for _, loadBalancer := range loadBalancers {
+		locality := loadBalancer.Locality
+		if len(locality) == 0 {
+			locality = clusterLocality
+		}
+		endpoint := makeRemoteEndpointForServiceEntry(loadBalancer.Address, locality, portNames, loadBalancer.Port)
+		endpoint.Network = loadBalancer.Network
+		if len(endpoint.Network) == 0 {
+			endpoint.Network = clusterNetwork
+		}
+		endpoint.Weight = loadBalancer.Weight
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
+
+func getClusterLocality(rc *RemoteController) string {
+	if rc.NodeController == nil {
+		return ""
+	}
+	if locality := rc.NodeController.GetLocality(); locality != nil {
+		return locality.String()
+	}
+	return ""
+}
+
+/*
+removeGatewayEndpoints returns a copy of the service entry without the endpoints pointing to the gateways of a cluster,
+except the first one, which is returned as well so that it can be replaced with the local endpoint of the cluster.
+The returned endpoint is nil when the service entry doesn't have any endpoint pointing to the gateways.
+*/
+func removeGatewayEndpoints(serviceEntry *networking.ServiceEntry, gatewayAddresses map[string]bool) (*networking.ServiceEntry, *networking.ServiceEntry_Endpoint) {
+	var se = copyServiceEntry(serviceEntry)
+	var gatewayEndpoint *networking.ServiceEntry_Endpoint
+	var endpoints = make([]*networking.ServiceEntry_Endpoint, 0, len(se.Endpoints))
+	for _, ep := range se.Endpoints {
+		if gatewayAddresses[ep.Address] || ep.Address == "" {
+			if gatewayEndpoint != nil {
+				continue
+			}
+			gatewayEndpoint = ep
+		}
+		endpoints = append(endpoints, ep)
+	}
+	se.Endpoints = endpoints
+	return se, gatewayEndpoint
 }
 
 func copyServiceEntry(se *networking.ServiceEntry) *networking.ServiceEntry {
@@ -568,6 +766,11 @@ func loadServiceEntryCacheData(c admiral.ConfigMapControllerInterface, admiralCa
 //Any error coupled with an empty string address means the method should be retried
 func GetLocalAddressForSe(seName string, seAddressCache *ServiceEntryAddressStore, configMapController admiral.ConfigMapControllerInterface) (string, bool, error) {
 	var address = seAddressCache.EntryAddresses[seName]
+	if len(address) == 0 && common.GetDryRun() {
+		//the addresses are allocated by the instance writing to the clusters, the SE gets a placeholder so its creation is still recorded
+		log.Infof(LogFormat, "Add", "ServiceEntryAddress", seName, "", "Dry run, no address allocated, using "+dryRunAddress)
+		return dryRunAddress, false, nil
+	}
 	if len(address) == 0 {
 		address, err := GenerateNewAddressAndAddToConfigMap(seName, configMapController)
 		return address, true, err
@@ -662,57 +865,30 @@ func putServiceEntryStateFromConfigmap(c admiral.ConfigMapControllerInterface, o
 	return c.PutConfigMap(originalConfigmap)
 }
 
-func createServiceEntryForRollout(event admiral.EventType, rc *RemoteController, admiralCache *AdmiralCache,
-	meshPorts map[string]uint32, destRollout *argo.Rollout, serviceEntries map[string]*networking.ServiceEntry) *networking.ServiceEntry {
-
-	workloadIdentityKey := common.GetWorkloadIdentifier()
-	globalFqdn := common.GetCnameForRollout(destRollout, workloadIdentityKey, common.GetHostnameSuffix())
-
-	//Handling retries for getting/putting service entries from/in cache
-
-	address := getUniqueAddress(admiralCache, globalFqdn)
-
-	if len(globalFqdn) == 0 || len(address) == 0 {
-		return nil
+//Returns the per pod hostnames, <pod name>.<env>.<identity>.global, mapped to the local pod fqdn resolved through the governing service.
+//Only populated when the statefulset has the admiral.io/pod-hostnames annotation set to true
+func getPodHostsForStatefulSet(statefulSet *k8sAppsV1.StatefulSet, cname string) map[string]string {
+	podHosts := make(map[string]string)
+	if statefulSet.Annotations[common.AdmiralPodHostnames] != "true" || len(cname) == 0 {
+		return podHosts
 	}
-
-	san := getSanForRollout(destRollout, workloadIdentityKey)
-
-	if destRollout.Spec.Strategy.BlueGreen != nil && destRollout.Spec.Strategy.BlueGreen.PreviewService != "" {
-		rolloutServices := getServiceForRollout(rc, destRollout)
-		if _, ok := rolloutServices[destRollout.Spec.Strategy.BlueGreen.PreviewService]; ok {
-			previewGlobalFqdn := common.BlueGreenRolloutPreviewPrefix + common.Sep + common.GetCnameForRollout(destRollout, workloadIdentityKey, common.GetHostnameSuffix())
-			previewAddress := getUniqueAddress(admiralCache, previewGlobalFqdn)
-			if len(previewGlobalFqdn) != 0 && len(previewAddress) != 0 {
-				generateServiceEntry(event, admiralCache, meshPorts, previewGlobalFqdn, rc, serviceEntries, previewAddress, san)
-			}
-		}
+	if len(statefulSet.Spec.ServiceName) == 0 {
+		log.Warnf(LogFormat, "Create", "statefulset", statefulSet.Name, "", "Skipped pod hostnames as serviceName is not set, namespace="+statefulSet.Namespace)
+		return podHosts
 	}
-
-	tmpSe := generateServiceEntry(event, admiralCache, meshPorts, globalFqdn, rc, serviceEntries, address, san)
-	return tmpSe
-}
-
-func getSanForDeployment(destDeployment *k8sAppsV1.Deployment, workloadIdentityKey string) (san []string) {
-	if common.GetEnableSAN() {
-		tmpSan := common.GetSAN(common.GetSANPrefix(), destDeployment, workloadIdentityKey)
-		if len(tmpSan) > 0 {
-			return []string{common.GetSAN(common.GetSANPrefix(), destDeployment, workloadIdentityKey)}
-		}
+	for _, podName := range common.GetStatefulSetPodNames(statefulSet) {
+		podHost := strings.ToLower(podName) + common.Sep + cname
+		podHosts[podHost] = podName + common.Sep + statefulSet.Spec.ServiceName + common.Sep + statefulSet.Namespace + common.DotLocalDomainSuffix
 	}
-	return nil
-
+	return podHosts
 }
 
-func getSanForRollout(destRollout *argo.Rollout, workloadIdentityKey string) (san []string) {
-	if common.GetEnableSAN() {
-		tmpSan := common.GetSANForRollout(common.GetSANPrefix(), destRollout, workloadIdentityKey)
-		if len(tmpSan) > 0 {
-			return []string{common.GetSANForRollout(common.GetSANPrefix(), destRollout, workloadIdentityKey)}
-		}
+//returns the address of the host, a render only looks up the addresses that are already allocated
+func getServiceEntryAddress(admiralCache *AdmiralCache, host string, render *IdentityRender) string {
+	if render != nil {
+		return admiralCache.ServiceEntryAddressStore.EntryAddresses[getIstioResourceName(host, "-se")]
 	}
-	return nil
-
+	return getUniqueAddress(admiralCache, host)
 }
 
 func getUniqueAddress(admiralCache *AdmiralCache, globalFqdn string) (address string) {
@@ -724,13 +900,13 @@ func getUniqueAddress(admiralCache *AdmiralCache, globalFqdn string) (address st
 	address = ""
 	needsCacheUpdate := false
 
-	for err == nil && counter < maxRetries {
+	for counter < maxRetries {
 		address, needsCacheUpdate, err = GetLocalAddressForSe(getIstioResourceName(globalFqdn, "-se"), admiralCache.ServiceEntryAddressStore, admiralCache.ConfigMapController)
 
-		if err != nil {
-			log.Errorf("Error getting local address for Service Entry. Err: %v", err)
+		if err == nil {
 			break
 		}
+		log.Errorf("Error getting local address for Service Entry. Err: %v", err)
 
 		//random expo backoff
 		timeToBackoff := rand.Intn(int(math.Pow(100.0, float64(counter)))) //get a random number between 0 and 100^counter. Will always be 0 the first time, will be 0-100 the second, and 0-1000 the third
@@ -751,21 +927,14 @@ func getUniqueAddress(admiralCache *AdmiralCache, globalFqdn string) (address st
 	return address
 }
 
-func generateServiceEntry(event admiral.EventType, admiralCache *AdmiralCache, meshPorts map[string]uint32, globalFqdn string, rc *RemoteController, serviceEntries map[string]*networking.ServiceEntry, address string, san []string) *networking.ServiceEntry {
-	admiralCache.CnameClusterCache.Put(globalFqdn, rc.ClusterID, rc.ClusterID)
+func generateServiceEntry(event admiral.EventType, admiralCache *AdmiralCache, meshPorts map[string]uint32, globalFqdn string, rc *RemoteController, serviceEntries map[string]*networking.ServiceEntry, address string, san []string, render *IdentityRender) *networking.ServiceEntry {
+	if render == nil {
+		admiralCache.CnameClusterCache.Put(globalFqdn, rc.ClusterID, rc.ClusterID)
+	}
 
 	tmpSe := serviceEntries[globalFqdn]
 
-	var finalProtocol = common.Http
-
-	var sePorts = []*networking.Port{{Number: uint32(common.DefaultServiceEntryPort),
-		Name: finalProtocol, Protocol: finalProtocol}}
-
-	for protocol := range meshPorts {
-		sePorts = []*networking.Port{{Number: uint32(common.DefaultServiceEntryPort),
-			Name: protocol, Protocol: protocol}}
-		finalProtocol = protocol
-	}
+	var sePorts = getServiceEntryPorts(meshPorts)
 
 	if tmpSe == nil {
 		tmpSe = &networking.ServiceEntry{
@@ -777,26 +946,37 @@ func generateServiceEntry(event admiral.EventType, admiralCache *AdmiralCache, m
 			SubjectAltNames: san,
 		}
 		tmpSe.Endpoints = []*networking.ServiceEntry_Endpoint{}
+	} else {
+		//the identity can expose different mesh ports in different clusters, the service entry ports are rebuilt from the mesh ports of all of them
+		tmpSe.Ports = getServiceEntryPorts(mergeMeshPorts(getMeshPortsForServiceEntryPorts(tmpSe.Ports), meshPorts))
 	}
 
-	endpointAddress, port := rc.ServiceController.Cache.GetLoadBalancer(common.GetAdmiralParams().LabelSet.GatewayApp, common.NamespaceIstioSystem)
-
-	var locality string
-	if rc.NodeController.Locality != nil {
-		locality = rc.NodeController.Locality.Region
+	var portNames = make([]string, 0, len(sePorts))
+	for _, sePort := range sePorts {
+		portNames = append(portNames, sePort.Name)
+	}
+	seEndpoints := makeRemoteEndpointsForServiceEntry(rc, portNames)
+	if len(seEndpoints) == 0 && event != admiral.Delete && render == nil {
+		common.EndpointsSkipped.With(rc.ClusterID).Inc()
+		log.Warnf(LogFormat, "Create", "ServiceEntry", globalFqdn, rc.ClusterID, "No east west gateway address found, skipped the endpoints of the cluster")
 	}
-	seEndpoint := makeRemoteEndpointForServiceEntry(endpointAddress,
-		locality, finalProtocol, port)
 
 	// if the action is deleting an endpoint from service entry, loop through the list and delete matching ones
 	if event == admiral.Add || event == admiral.Update {
-		tmpSe.Endpoints = append(tmpSe.Endpoints, seEndpoint)
+		tmpSe.Endpoints = append(tmpSe.Endpoints, seEndpoints...)
 	} else if event == admiral.Delete {
 		// create a tmp endpoint list to store all the endpoints that we intend to keep
 		remainEndpoints := []*networking.ServiceEntry_Endpoint{}
-		// if the endpoint is not equal to the endpoint we intend to delete, append it to remainEndpoint list
+		// if the endpoint is not equal to one of the endpoints we intend to delete, append it to remainEndpoint list
 		for _, existingEndpoint := range tmpSe.Endpoints {
-			if !reflect.DeepEqual(existingEndpoint, seEndpoint) {
+			deleted := false
+			for _, seEndpoint := range seEndpoints {
+				if reflect.DeepEqual(existingEndpoint, seEndpoint) {
+					deleted = true
+					break
+				}
+			}
+			if !deleted {
 				remainEndpoints = append(remainEndpoints, existingEndpoint)
 			}
 		}
@@ -19,6 +19,7 @@ import (
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/test"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/util"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v2"
 	istionetworkingv1alpha3 "istio.io/api/networking/v1alpha3"
@@ -28,7 +29,10 @@ import (
 	coreV1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
 	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 func init() {
@@ -106,6 +110,50 @@ func TestAddServiceEntriesWithDr(t *testing.T) {
 	AddServiceEntriesWithDr(rr, map[string]string{"cl1": "cl1"}, map[string]*istionetworkingv1alpha3.ServiceEntry{"se1": &emptyEndpointSe})
 }
 
+func TestAddServiceEntriesWithDrPartialFailure(t *testing.T) {
+	rr := NewRemoteRegistry(context.TODO(), common.AdmiralParams{})
+	rr.AdmiralCache.CnameIdentityCache.Store("dev.bar.global", "bar")
+
+	newRemoteController := func(clusterID string) (*RemoteController, *istiofake.Clientset) {
+		fakeIstioClient := istiofake.NewSimpleClientset()
+		rc := &RemoteController{
+			ClusterID:                 clusterID,
+			ServiceEntryController:    &istio.ServiceEntryController{IstioClient: fakeIstioClient},
+			DestinationRuleController: &istio.DestinationRuleController{IstioClient: fakeIstioClient},
+			NodeController:            &admiral.NodeController{Locality: &admiral.Locality{Region: "us-west-2"}},
+		}
+		rr.PutRemoteController(clusterID, rc)
+		return rc, fakeIstioClient
+	}
+	rc1, _ := newRemoteController("cluster1")
+	_, failingIstioClient := newRemoteController("cluster2")
+	failingIstioClient.PrependReactor("create", "serviceentries", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("connection refused")
+	})
+
+	se := istionetworkingv1alpha3.ServiceEntry{
+		Hosts: []string{"dev.bar.global"},
+		Endpoints: []*istionetworkingv1alpha3.ServiceEntry_Endpoint{
+			{Address: "127.0.0.1", Ports: map[string]uint32{"https": 80}, Locality: "us-west-2"},
+		},
+	}
+	err := AddServiceEntriesWithDr(rr, map[string]string{"cluster1": "cluster1", "cluster2": "cluster2"}, map[string]*istionetworkingv1alpha3.ServiceEntry{"dev.bar.global": &se})
+
+	agg, ok := err.(utilerrors.Aggregate)
+	if !ok || len(agg.Errors()) != 1 {
+		t.Fatalf("Wanted the error of cluster2, got %v", err)
+	}
+	if clusterErr, ok := agg.Errors()[0].(*ClusterError); !ok || clusterErr.ClusterID != "cluster2" {
+		t.Errorf("Wanted the error of cluster2, got %v", agg.Errors()[0])
+	}
+	if _, err := rc1.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries("ns").Get("dev.bar.global-se", v12.GetOptions{}); err != nil {
+		t.Errorf("Wanted the ServiceEntry written to cluster1, got %v", err)
+	}
+	if clusters := rr.AdmiralCache.SeClusterCache.Get("dev.bar.global").Copy(); !reflect.DeepEqual(clusters, map[string]string{"cluster1": "cluster1"}) {
+		t.Errorf("Wanted the ServiceEntry cached for cluster1 only, got %v", clusters)
+	}
+}
+
 func TestCreateSeAndDrSetFromGtp(t *testing.T) {
 
 	host := "dev.bar.global"
@@ -243,7 +291,7 @@ func TestCreateSeAndDrSetFromGtp(t *testing.T) {
 	//Run the test for every provided case
 	for _, c := range testCases {
 		t.Run(c.name, func(t *testing.T) {
-			result := createSeAndDrSetFromGtp(c.env, c.locality, c.se, c.gtp, &admiralCache)
+			result := createSeAndDrSetFromGtp(c.env, c.locality, c.se, c.gtp, &admiralCache, nil)
 			generatedHosts := make([]string, 0, len(result))
 			for generatedHost := range result {
 				generatedHosts = append(generatedHosts, generatedHost)
@@ -447,8 +495,9 @@ func TestMakeRemoteEndpointForServiceEntry(t *testing.T) {
 	address := "1.2.3.4"
 	locality := "us-west-2"
 	portName := "port"
+	secondPortName := "grpc-8091"
 
-	endpoint := makeRemoteEndpointForServiceEntry(address, locality, portName, common.DefaultMtlsPort)
+	endpoint := makeRemoteEndpointForServiceEntry(address, locality, []string{portName, secondPortName}, common.DefaultMtlsPort)
 
 	if endpoint.Address != address {
 		t.Errorf("Address mismatch. Got: %v, expected: %v", endpoint.Address, address)
@@ -456,7 +505,7 @@ func TestMakeRemoteEndpointForServiceEntry(t *testing.T) {
 	if endpoint.Locality != locality {
 		t.Errorf("Locality mismatch. Got: %v, expected: %v", endpoint.Locality, locality)
 	}
-	if endpoint.Ports[portName] != 15443 {
+	if endpoint.Ports[portName] != 15443 || endpoint.Ports[secondPortName] != 15443 {
 		t.Errorf("Incorrect port found")
 	}
 }
@@ -578,6 +627,10 @@ func TestCreateServiceEntry(t *testing.T) {
 		t.Fatalf("%v", e)
 	}
 
+	s.Cache.Put(newTestGatewayService("eastwestgateway.com"))
+	common.SetClusterGatewaySelectors(map[string]string{"cluster1": "istio=eastwestgateway"})
+	defer common.SetClusterGatewaySelectors(nil)
+
 	admiralCache := AdmiralCache{}
 
 	localAddress := common.LocalAddressPrefix + ".10.1"
@@ -595,6 +648,7 @@ func TestCreateServiceEntry(t *testing.T) {
 
 	fakeIstioClient := istiofake.NewSimpleClientset()
 	rc := &RemoteController{
+		ClusterID: "cluster1",
 		ServiceEntryController: &istio.ServiceEntryController{
 			IstioClient: fakeIstioClient,
 		},
@@ -609,6 +663,13 @@ func TestCreateServiceEntry(t *testing.T) {
 		ServiceController: s,
 	}
 
+	rcWithoutGateway := &RemoteController{
+		ClusterID:              "cluster2",
+		ServiceEntryController: rc.ServiceEntryController,
+		NodeController:         rc.NodeController,
+		ServiceController:      s,
+	}
+
 	cacheWithEntry := ServiceEntryAddressStore{
 		EntryAddresses: map[string]string{"e2e.my-first-service.mesh": localAddress},
 		Addresses:      []string{localAddress},
@@ -638,7 +699,7 @@ func TestCreateServiceEntry(t *testing.T) {
 		Resolution:      istionetworkingv1alpha3.ServiceEntry_DNS,
 		SubjectAltNames: []string{"spiffe://prefix/my-first-service"},
 		Endpoints: []*istionetworkingv1alpha3.ServiceEntry_Endpoint{
-			{Address: "dummy.admiral.global", Ports: map[string]uint32{"http": 0}, Locality: "us-west-2"},
+			{Address: "eastwestgateway.com", Ports: map[string]uint32{"http": common.DefaultMtlsPort}, Locality: "us-west-2"},
 		},
 	}
 
@@ -651,7 +712,7 @@ func TestCreateServiceEntry(t *testing.T) {
 		Resolution:      istionetworkingv1alpha3.ServiceEntry_DNS,
 		SubjectAltNames: []string{"spiffe://prefix/my-first-service"},
 		Endpoints: []*istionetworkingv1alpha3.ServiceEntry_Endpoint{
-			{Address: "dummy.admiral.global", Ports: map[string]uint32{"http": 0}, Locality: "us-west-2"},
+			{Address: "eastwestgateway.com", Ports: map[string]uint32{"http": common.DefaultMtlsPort}, Locality: "us-west-2"},
 		},
 	}
 
@@ -664,8 +725,8 @@ func TestCreateServiceEntry(t *testing.T) {
 		Resolution:      istionetworkingv1alpha3.ServiceEntry_DNS,
 		SubjectAltNames: []string{"spiffe://prefix/my-first-service"},
 		Endpoints: []*istionetworkingv1alpha3.ServiceEntry_Endpoint{
-			{Address: "dummy.admiral.global", Ports: map[string]uint32{"http": 0}, Locality: "us-west-2"},
-			{Address: "dummy.admiral.global", Ports: map[string]uint32{"http": 0}, Locality: "us-east-2"},
+			{Address: "eastwestgateway.com", Ports: map[string]uint32{"http": common.DefaultMtlsPort}, Locality: "us-west-2"},
+			{Address: "eastwestgateway.com", Ports: map[string]uint32{"http": common.DefaultMtlsPort}, Locality: "us-east-2"},
 		},
 	}
 
@@ -678,9 +739,9 @@ func TestCreateServiceEntry(t *testing.T) {
 		Resolution:      istionetworkingv1alpha3.ServiceEntry_DNS,
 		SubjectAltNames: []string{"spiffe://prefix/my-first-service"},
 		Endpoints: []*istionetworkingv1alpha3.ServiceEntry_Endpoint{
-			{Address: "dummy.admiral.global", Ports: map[string]uint32{"http": 0}, Locality: "us-west-2"},
-			{Address: "dummy.admiral.global", Ports: map[string]uint32{"http": 0}, Locality: "us-west-2"},
-			{Address: "dummy.admiral.global", Ports: map[string]uint32{"http": 0}, Locality: "us-east-2"},
+			{Address: "eastwestgateway.com", Ports: map[string]uint32{"http": common.DefaultMtlsPort}, Locality: "us-west-2"},
+			{Address: "eastwestgateway.com", Ports: map[string]uint32{"http": common.DefaultMtlsPort}, Locality: "us-west-2"},
+			{Address: "eastwestgateway.com", Ports: map[string]uint32{"http": common.DefaultMtlsPort}, Locality: "us-east-2"},
 		},
 	}
 	eastEndpointSe := istionetworkingv1alpha3.ServiceEntry{
@@ -692,7 +753,7 @@ func TestCreateServiceEntry(t *testing.T) {
 		Resolution:      istionetworkingv1alpha3.ServiceEntry_DNS,
 		SubjectAltNames: []string{"spiffe://prefix/my-first-service"},
 		Endpoints: []*istionetworkingv1alpha3.ServiceEntry_Endpoint{
-			{Address: "dummy.admiral.global", Ports: map[string]uint32{"http": 0}, Locality: "us-east-2"},
+			{Address: "eastwestgateway.com", Ports: map[string]uint32{"http": common.DefaultMtlsPort}, Locality: "us-east-2"},
 		},
 	}
 
@@ -716,7 +777,20 @@ func TestCreateServiceEntry(t *testing.T) {
 		Resolution:      istionetworkingv1alpha3.ServiceEntry_DNS,
 		SubjectAltNames: []string{"spiffe://prefix/my-first-service"},
 		Endpoints: []*istionetworkingv1alpha3.ServiceEntry_Endpoint{
-			{Address: "dummy.admiral.global", Ports: map[string]uint32{"grpc": 0}, Locality: "us-west-2"},
+			{Address: "eastwestgateway.com", Ports: map[string]uint32{"grpc": common.DefaultMtlsPort}, Locality: "us-west-2"},
+		},
+	}
+
+	multiPortSe := istionetworkingv1alpha3.ServiceEntry{
+		Hosts:     []string{"e2e.my-first-service.mesh"},
+		Addresses: []string{localAddress},
+		Ports: []*istionetworkingv1alpha3.Port{{Number: uint32(common.DefaultServiceEntryPort),
+			Name: "http", Protocol: "http"}, {Number: uint32(8091), Name: "grpc-8091", Protocol: "grpc"}},
+		Location:        istionetworkingv1alpha3.ServiceEntry_MESH_INTERNAL,
+		Resolution:      istionetworkingv1alpha3.ServiceEntry_DNS,
+		SubjectAltNames: []string{"spiffe://prefix/my-first-service"},
+		Endpoints: []*istionetworkingv1alpha3.ServiceEntry_Endpoint{
+			{Address: "eastwestgateway.com", Ports: map[string]uint32{"http": common.DefaultMtlsPort, "grpc-8091": common.DefaultMtlsPort}, Locality: "us-west-2"},
 		},
 	}
 
@@ -750,6 +824,16 @@ func TestCreateServiceEntry(t *testing.T) {
 			serviceEntries: map[string]*istionetworkingv1alpha3.ServiceEntry{},
 			expectedResult: &se,
 		},
+		{
+			name:           "Should return a created service entry with a port per mesh port",
+			action:         admiral.Add,
+			rc:             rc,
+			admiralCache:   admiralCache,
+			meshPorts:      map[string]uint32{"http": uint32(8090), "grpc-8091": uint32(8091)},
+			deployment:     deployment,
+			serviceEntries: map[string]*istionetworkingv1alpha3.ServiceEntry{},
+			expectedResult: &multiPortSe,
+		},
 		{
 			name:         "Delete the service entry with one endpoint",
 			action:       admiral.Delete,
@@ -786,13 +870,23 @@ func TestCreateServiceEntry(t *testing.T) {
 			},
 			expectedResult: &eastEndpointSe,
 		},
+		{
+			name:           "Should return a created service entry without endpoints when the cluster has no gateway",
+			action:         admiral.Add,
+			rc:             rcWithoutGateway,
+			admiralCache:   admiralCache,
+			meshPorts:      map[string]uint32{"http": uint32(80)},
+			deployment:     deployment,
+			serviceEntries: map[string]*istionetworkingv1alpha3.ServiceEntry{},
+			expectedResult: &emptyEndpointSe,
+		},
 	}
 
 	//Run the test for every provided case
 	for _, c := range deploymentSeCreationTestCases {
 		t.Run(c.name, func(t *testing.T) {
 			var createdSE *istionetworkingv1alpha3.ServiceEntry
-			createdSE = createServiceEntry(c.action, c.rc, &c.admiralCache, c.meshPorts, &c.deployment, c.serviceEntries)
+			createdSE = createServiceEntryForWorkload(c.action, c.rc, &c.admiralCache, c.meshPorts, newDeploymentWorkload(&c.deployment), nil, c.serviceEntries, nil)
 			if !reflect.DeepEqual(createdSE, c.expectedResult) {
 				t.Errorf("Test %s failed, expected: %v got %v", c.name, c.expectedResult, createdSE)
 			}
@@ -827,12 +921,20 @@ func TestCreateServiceEntry(t *testing.T) {
 			rollout:        rollout,
 			expectedResult: &se,
 		},
+		{
+			name:           "Should return a created service entry with a port per mesh port",
+			rc:             rc,
+			admiralCache:   admiralCache,
+			meshPorts:      map[string]uint32{"http": uint32(8090), "grpc-8091": uint32(8091)},
+			rollout:        rollout,
+			expectedResult: &multiPortSe,
+		},
 	}
 
 	//Run the test for every provided case
 	for _, c := range rolloutSeCreationTestCases {
 		t.Run(c.name, func(t *testing.T) {
-			createdSE := createServiceEntryForRollout(admiral.Add, c.rc, &c.admiralCache, c.meshPorts, &c.rollout, map[string]*istionetworkingv1alpha3.ServiceEntry{})
+			createdSE := createServiceEntryForWorkload(admiral.Add, c.rc, &c.admiralCache, c.meshPorts, newRolloutWorkload(&c.rollout), nil, map[string]*istionetworkingv1alpha3.ServiceEntry{}, nil)
 			if !reflect.DeepEqual(createdSE, c.expectedResult) {
 				t.Errorf("Test %s failed, expected: %v got %v", c.name, c.expectedResult, createdSE)
 			}
@@ -884,6 +986,9 @@ func TestCreateServiceEntryForNewServiceOrPodRolloutsUsecase(t *testing.T) {
 		DestinationRuleController: &istio.DestinationRuleController{
 			IstioClient: fakeIstioClient,
 		},
+		SidecarController: &istio.SidecarController{
+			IstioClient: fakeIstioClient,
+		},
 		NodeController: &admiral.NodeController{
 			Locality: &admiral.Locality{
 				Region: "us-west-2",
@@ -908,8 +1013,10 @@ func TestCreateServiceEntryForNewServiceOrPodRolloutsUsecase(t *testing.T) {
 		GlobalTrafficCache:         &globalTrafficCache{},
 		DependencyNamespaceCache:   common.NewSidecarEgressMap(),
 		SeClusterCache:             common.NewMapOfMaps(),
+		HostIdentityEnvCache:       &sync.Map{},
 	}
 	rr.AdmiralCache = admiralCache
+	admiralCache.IdentityDependencyCache.Put("bar", "dep", "dep")
 
 	rollout := argo.Rollout{}
 
@@ -963,7 +1070,7 @@ func TestCreateServiceEntryForNewServiceOrPodRolloutsUsecase(t *testing.T) {
 	activeService.Spec.Ports = ports
 
 	s.Cache.Put(activeService)
-	se := modifyServiceEntryForNewServiceOrPod(admiral.Add, "test", "bar", rr)
+	se, _ := modifyServiceEntryForNewServiceOrPod(admiral.Add, "test", "bar", rr)
 	if nil == se {
 		t.Fatalf("no service entries found")
 	}
@@ -974,6 +1081,20 @@ func TestCreateServiceEntryForNewServiceOrPodRolloutsUsecase(t *testing.T) {
 	if nil == serviceEntryResp {
 		t.Fatalf("Service entry returned should not be empty")
 	}
+
+	//the dependent's sidecar reaches the global cname of the rollout
+	sidecar := &v1alpha3.Sidecar{
+		ObjectMeta: v12.ObjectMeta{Name: common.GetWorkloadSidecarName(), Namespace: "dep-ns"},
+		Spec: istionetworkingv1alpha3.Sidecar{
+			Egress: []*istionetworkingv1alpha3.IstioEgressListener{{Hosts: []string{"./*"}}},
+		},
+	}
+	fakeIstioClient.NetworkingV1alpha3().Sidecars("dep-ns").Create(sidecar)
+	modifySidecarForLocalClusterCommunication("dep-ns", admiralCache.DependencyNamespaceCache.Get("dep"), rc)
+	updatedSidecar, _ := fakeIstioClient.NetworkingV1alpha3().Sidecars("dep-ns").Get(common.GetWorkloadSidecarName(), v12.GetOptions{})
+	if !util.Contains(updatedSidecar.Spec.Egress[0].Hosts, NAMESPACE+"/test.test.mesh") {
+		t.Errorf("Expected the egress hosts of the dependent to contain %s/test.test.mesh, got %v", NAMESPACE, updatedSidecar.Spec.Egress[0].Hosts)
+	}
 }
 
 func TestCreateServiceEntryForBlueGreenRolloutsUsecase(t *testing.T) {
@@ -1044,6 +1165,7 @@ func TestCreateServiceEntryForBlueGreenRolloutsUsecase(t *testing.T) {
 		GlobalTrafficCache:         &globalTrafficCache{},
 		DependencyNamespaceCache:   common.NewSidecarEgressMap(),
 		SeClusterCache:             common.NewMapOfMaps(),
+		HostIdentityEnvCache:       &sync.Map{},
 	}
 	rr.AdmiralCache = admiralCache
 
@@ -1112,7 +1234,7 @@ func TestCreateServiceEntryForBlueGreenRolloutsUsecase(t *testing.T) {
 
 	s.Cache.Put(previewService)
 
-	se := modifyServiceEntryForNewServiceOrPod(admiral.Add, "test", "bar", rr)
+	se, _ := modifyServiceEntryForNewServiceOrPod(admiral.Add, "test", "bar", rr)
 
 	if nil == se {
 		t.Fatalf("no service entries found")
@@ -1134,7 +1256,7 @@ func TestCreateServiceEntryForBlueGreenRolloutsUsecase(t *testing.T) {
 		BlueGreen: &argo.BlueGreenStrategy{ActiveService: ACTIVE_SERVICENAME},
 	}
 
-	se = modifyServiceEntryForNewServiceOrPod(admiral.Add, "test", "bar", rr)
+	se, _ = modifyServiceEntryForNewServiceOrPod(admiral.Add, "test", "bar", rr)
 
 	if len(se) != 1 {
 		t.Fatalf("Expected 1 service entries to be created but found %d", len(se))
@@ -1509,3 +1631,225 @@ func TestIsBlueGreenStrategy(t *testing.T) {
 		})
 	}
 }
+
+func TestGetPodHostsForStatefulSet(t *testing.T) {
+	var replicas int32 = 2
+	statefulSet := v14.StatefulSet{
+		ObjectMeta: v12.ObjectMeta{Name: "mongo", Namespace: "mongo-ns", Annotations: map[string]string{common.AdmiralPodHostnames: "true"}},
+		Spec:       v14.StatefulSetSpec{ServiceName: "mongo-headless", Replicas: &replicas},
+	}
+	withoutAnnotation := statefulSet
+	withoutAnnotation.ObjectMeta = v12.ObjectMeta{Name: "mongo", Namespace: "mongo-ns"}
+	withoutServiceName := statefulSet
+	withoutServiceName.Spec = v14.StatefulSetSpec{Replicas: &replicas}
+
+	testCases := []struct {
+		name        string
+		statefulSet v14.StatefulSet
+		cname       string
+		expected    map[string]string
+	}{
+		{
+			name:        "should return a hostname per pod mapped to the local pod fqdn",
+			statefulSet: statefulSet,
+			cname:       "e2e.mongo.mesh",
+			expected: map[string]string{
+				"mongo-0.e2e.mongo.mesh": "mongo-0.mongo-headless.mongo-ns.svc.cluster.local",
+				"mongo-1.e2e.mongo.mesh": "mongo-1.mongo-headless.mongo-ns.svc.cluster.local",
+			},
+		},
+		{
+			name:        "should return no hostnames without the annotation",
+			statefulSet: withoutAnnotation,
+			cname:       "e2e.mongo.mesh",
+			expected:    map[string]string{},
+		},
+		{
+			name:        "should return no hostnames without a governing service",
+			statefulSet: withoutServiceName,
+			cname:       "e2e.mongo.mesh",
+			expected:    map[string]string{},
+		},
+		{
+			name:        "should return no hostnames without a cname",
+			statefulSet: statefulSet,
+			cname:       "",
+			expected:    map[string]string{},
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			podHosts := getPodHostsForStatefulSet(&c.statefulSet, c.cname)
+			if !reflect.DeepEqual(podHosts, c.expected) {
+				t.Errorf("Wanted pod hosts: %v, got: %v", c.expected, podHosts)
+			}
+		})
+	}
+}
+
+func TestCreateServiceEntryForStatefulSet(t *testing.T) {
+	config := rest.Config{
+		Host: "localhost",
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	s, e := admiral.NewServiceController("test", stop, &test.MockServiceHandler{}, &config, time.Second*time.Duration(300))
+	if e != nil {
+		t.Fatalf("%v", e)
+	}
+
+	localAddress := common.LocalAddressPrefix + ".10.1"
+	podAddress := common.LocalAddressPrefix + ".10.2"
+	addressStore := ServiceEntryAddressStore{
+		EntryAddresses: map[string]string{"e2e.mongo.mesh-se": localAddress, "mongo-0.e2e.mongo.mesh-se": podAddress},
+		Addresses:      []string{localAddress, podAddress},
+	}
+	admiralCache := AdmiralCache{
+		CnameClusterCache:        common.NewMapOfMaps(),
+		ServiceEntryAddressStore: &addressStore,
+		ConfigMapController: &test.FakeConfigMapController{
+			ConfigmapToReturn: buildFakeConfigMapFromAddressStore(&addressStore, "123"),
+		},
+	}
+	s.Cache.Put(newTestGatewayService("eastwestgateway.com"))
+	common.SetClusterGatewaySelectors(map[string]string{"cluster1": "istio=eastwestgateway"})
+	defer common.SetClusterGatewaySelectors(nil)
+
+	rc := &RemoteController{
+		ClusterID: "cluster1",
+		NodeController: &admiral.NodeController{
+			Locality: &admiral.Locality{Region: "us-west-2"},
+		},
+		ServiceController: s,
+	}
+
+	statefulSet := v14.StatefulSet{
+		ObjectMeta: v12.ObjectMeta{Name: "mongo", Namespace: "mongo-ns", Annotations: map[string]string{common.AdmiralPodHostnames: "true"}},
+		Spec: v14.StatefulSetSpec{
+			ServiceName: "mongo",
+			Template: coreV1.PodTemplateSpec{
+				ObjectMeta: v12.ObjectMeta{Labels: map[string]string{"env": "e2e", "identity": "mongo"}},
+			},
+		},
+	}
+	workload := newStatefulSetWorkload(&statefulSet)
+	localEndpoints := workload.GetLocalEndpoints(rc.ClusterID, "e2e.mongo.mesh", nil)
+	serviceEntries := make(map[string]*istionetworkingv1alpha3.ServiceEntry)
+
+	createServiceEntryForWorkload(admiral.Add, rc, &admiralCache, map[string]uint32{"mongo": 27017}, workload, localEndpoints, serviceEntries, nil)
+
+	expectedAddresses := map[string]string{"e2e.mongo.mesh": localAddress, "mongo-0.e2e.mongo.mesh": podAddress}
+	if len(serviceEntries) != len(expectedAddresses) {
+		t.Fatalf("Wanted service entries for %v, got %v", expectedAddresses, serviceEntries)
+	}
+	for host, address := range expectedAddresses {
+		se := serviceEntries[host]
+		if se == nil {
+			t.Fatalf("Missing service entry for host %v", host)
+		}
+		if se.Addresses[0] != address {
+			t.Errorf("Wanted address %v for host %v, got %v", address, host, se.Addresses[0])
+		}
+		if len(se.Ports) != 1 || se.Ports[0].Name != "mongo" || se.Ports[0].Protocol != "mongo" {
+			t.Errorf("Wanted a single mongo port for host %v, got %v", host, se.Ports)
+		}
+		if !reflect.DeepEqual(se.SubjectAltNames, []string{"spiffe://prefix/mongo"}) {
+			t.Errorf("Wanted SAN spiffe://prefix/mongo for host %v, got %v", host, se.SubjectAltNames)
+		}
+		if len(se.Endpoints) != 1 || se.Endpoints[0].Locality != "us-west-2" {
+			t.Errorf("Wanted a single us-west-2 endpoint for host %v, got %v", host, se.Endpoints)
+		}
+	}
+}
+
+//returns an east west gateway matching the istio=eastwestgateway selector
+func newTestGatewayService(address string) *coreV1.Service {
+	gateway := &coreV1.Service{}
+	gateway.Name = "istio-eastwestgateway"
+	gateway.Namespace = common.NamespaceIstioSystem
+	gateway.Labels = map[string]string{"istio": "eastwestgateway"}
+	gateway.Status.LoadBalancer.Ingress = []coreV1.LoadBalancerIngress{{Hostname: address}}
+	return gateway
+}
+
+func TestMakeRemoteEndpointsForServiceEntry(t *testing.T) {
+	config := rest.Config{
+		Host: "localhost",
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	s, e := admiral.NewServiceController("test", stop, &test.MockServiceHandler{}, &config, time.Second*time.Duration(300))
+	if e != nil {
+		t.Fatalf("%v", e)
+	}
+
+	westGateway := &coreV1.Service{}
+	westGateway.Name = "eastwestgateway-west"
+	westGateway.Namespace = common.NamespaceIstioSystem
+	westGateway.Labels = map[string]string{"istio": "eastwestgateway", common.NetworkLabel: "network1"}
+	westGateway.Annotations = map[string]string{common.GatewayLocalityAnnotation: "us-west-2/us-west-2a", common.GatewayWeightAnnotation: "80"}
+	westGateway.Status.LoadBalancer.Ingress = []coreV1.LoadBalancerIngress{{Hostname: "west.com"}}
+
+	eastGateway := &coreV1.Service{}
+	eastGateway.Name = "eastwestgateway-east"
+	eastGateway.Namespace = common.NamespaceIstioSystem
+	eastGateway.Labels = map[string]string{"istio": "eastwestgateway"}
+	eastGateway.Status.LoadBalancer.Ingress = []coreV1.LoadBalancerIngress{{Hostname: "east.com"}}
+
+	s.Cache.Put(westGateway)
+	s.Cache.Put(eastGateway)
+
+	rc := &RemoteController{
+		ClusterID:         "cluster1",
+		ServiceController: s,
+		NodeController: &admiral.NodeController{
+			Locality: &admiral.Locality{Region: "us-east-2"},
+		},
+	}
+
+	if endpoints := makeRemoteEndpointsForServiceEntry(rc, []string{"http"}); len(endpoints) != 0 {
+		t.Errorf("Wanted no endpoint as no gateway matches the default gateway selector, got %v", endpoints)
+	}
+
+	common.SetClusterGatewaySelectors(map[string]string{"cluster1": "istio=eastwestgateway"})
+	defer common.SetClusterGatewaySelectors(nil)
+
+	expectedEndpoints := []*istionetworkingv1alpha3.ServiceEntry_Endpoint{
+		{Address: "east.com", Ports: map[string]uint32{"http": common.DefaultMtlsPort}, Locality: "us-east-2"},
+		{Address: "west.com", Ports: map[string]uint32{"http": common.DefaultMtlsPort}, Locality: "us-west-2/us-west-2a", Network: "network1", Weight: 80},
+	}
+	if endpoints := makeRemoteEndpointsForServiceEntry(rc, []string{"http"}); !cmp.Equal(endpoints, expectedEndpoints) {
+		t.Errorf("Wanted an endpoint per gateway of the cluster selector, got %v", cmp.Diff(expectedEndpoints, endpoints))
+	}
+}
+
+func TestRemoveGatewayEndpoints(t *testing.T) {
+	se := &istionetworkingv1alpha3.ServiceEntry{
+		Hosts: []string{"e2e.my-first-service.mesh"},
+		Endpoints: []*istionetworkingv1alpha3.ServiceEntry_Endpoint{
+			{Address: "west-a.com", Locality: "us-west-2/us-west-2a", Network: "network1"},
+			{Address: "east.com", Locality: "us-east-2", Network: "network2"},
+			{Address: "west-b.com", Locality: "us-west-2/us-west-2b", Network: "network1"},
+		},
+	}
+
+	updatedSe, ep := removeGatewayEndpoints(se, map[string]bool{"west-a.com": true, "west-b.com": true})
+	expectedEndpoints := []*istionetworkingv1alpha3.ServiceEntry_Endpoint{
+		{Address: "west-a.com", Locality: "us-west-2/us-west-2a", Network: "network1"},
+		{Address: "east.com", Locality: "us-east-2", Network: "network2"},
+	}
+	if !cmp.Equal(updatedSe.Endpoints, expectedEndpoints) {
+		t.Errorf("Wanted a single endpoint for the gateways of the cluster, got %v", cmp.Diff(expectedEndpoints, updatedSe.Endpoints))
+	}
+	if ep != updatedSe.Endpoints[0] {
+		t.Errorf("Wanted the endpoint to replace to be the first endpoint of the gateways, got %v", ep)
+	}
+	if len(se.Endpoints) != 3 {
+		t.Errorf("Wanted the service entry to be left as is, got %v", se.Endpoints)
+	}
+
+	if _, ep := removeGatewayEndpoints(se, map[string]bool{"north.com": true}); ep != nil {
+		t.Errorf("Wanted no endpoint to replace, got %v", ep)
+	}
+}
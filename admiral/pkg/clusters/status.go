@@ -0,0 +1,308 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"istio.io/api/networking/v1alpha3"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	statusUpdateOp = "StatusUpdate"
+	//number of times a status update that failed is retried before waiting for the next reconcile
+	statusUpdateMaxRetries = 5
+
+	StatusSynced  = "Synced"
+	StatusPending = "Pending"
+	StatusError   = "Error"
+	//a GTP of the identity/env that isn't the one picked by updateGlobalGtpCache
+	StatusInactive = "Inactive"
+
+	gtpStatusKind        = "GlobalTrafficPolicy"
+	dependencyStatusKind = "Dependency"
+)
+
+type statusKey struct {
+	kind      string
+	cluster   string
+	namespace string
+	name      string
+}
+
+// outcome of the last reconcile of an identity/env
+type identityEnvResult struct {
+	//clusters the SEs and DRs were written to
+	clusters map[string]bool
+	hosts    []string
+	err      error
+}
+
+/*
+statusWriter writes the outcome of the reconciles to the status of the GTPs and dependency records.
+The desired statuses are kept by object, only the latest one is written when the updates of an object pile up.
+The writes are rate limited by --status_update_qps, a status that didn't change is not written again.
+*/
+type statusWriter struct {
+	ctx     context.Context
+	rr      *RemoteRegistry
+	queue   workqueue.RateLimitingInterface
+	limiter flowcontrol.RateLimiter
+	mutex   sync.Mutex
+	//key=identity, value=result by env
+	results            map[string]map[string]identityEnvResult
+	gtpStatuses        map[statusKey]v1.GlobalTrafficPolicyStatus
+	dependencyStatuses map[statusKey]v1.DependencyStatus
+}
+
+func newStatusWriter(ctx context.Context, rr *RemoteRegistry) *statusWriter {
+	return &statusWriter{
+		ctx:                ctx,
+		rr:                 rr,
+		queue:              workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "status-update"),
+		limiter:            flowcontrol.NewTokenBucketRateLimiter(common.GetStatusUpdateQPS(), 1),
+		results:            make(map[string]map[string]identityEnvResult),
+		gtpStatuses:        make(map[statusKey]v1.GlobalTrafficPolicyStatus),
+		dependencyStatuses: make(map[statusKey]v1.DependencyStatus),
+	}
+}
+
+// runs the worker until the context is done
+func (s *statusWriter) run() {
+	defer s.limiter.Stop()
+	go wait.Until(s.runWorker, time.Second, s.ctx.Done())
+	<-s.ctx.Done()
+	s.queue.ShutDown()
+}
+
+func (s *statusWriter) runWorker() {
+	for s.processNextItem() {
+		// continue looping
+	}
+}
+
+func (s *statusWriter) processNextItem() bool {
+	item, quit := s.queue.Get()
+	if quit {
+		return false
+	}
+	defer s.queue.Done(item)
+
+	key := item.(statusKey)
+	if err := s.limiter.Wait(s.ctx); err != nil {
+		log.Infof(LogFormat, statusUpdateOp, key.kind, key.name, key.cluster, fmt.Sprintf("Stopped: %v", err))
+		return false
+	}
+	err := s.write(key)
+	if err == nil {
+		s.queue.Forget(item)
+	} else if s.queue.NumRequeues(item) < statusUpdateMaxRetries {
+		log.Errorf(LogErrFormat, statusUpdateOp, key.kind, key.name, key.cluster, fmt.Sprintf("%v (will retry)", err))
+		s.queue.AddRateLimited(item)
+	} else {
+		log.Errorf(LogErrFormat, statusUpdateOp, key.kind, key.name, key.cluster, fmt.Sprintf("%v (giving up)", err))
+		s.queue.Forget(item)
+	}
+	return true
+}
+
+// writes the desired status of the object, a conflict is returned as an error so the write is retried on the latest version
+func (s *statusWriter) write(key statusKey) error {
+	switch key.kind {
+	case gtpStatusKind:
+		s.mutex.Lock()
+		status, ok := s.gtpStatuses[key]
+		s.mutex.Unlock()
+		rc := s.rr.GetRemoteController(key.cluster)
+		if !ok || rc == nil || rc.GlobalTraffic == nil {
+			return nil
+		}
+		client := rc.GlobalTraffic.CrdClient.AdmiralV1().GlobalTrafficPolicies(key.namespace)
+		gtp, err := client.Get(key.name, v12.GetOptions{})
+		if k8sErrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if reflect.DeepEqual(gtp.Status, status) {
+			return nil
+		}
+		gtp.Status = status
+		_, err = client.UpdateStatus(gtp)
+		return err
+	case dependencyStatusKind:
+		s.mutex.Lock()
+		status, ok := s.dependencyStatuses[key]
+		s.mutex.Unlock()
+		if !ok || s.rr.dependencyController == nil {
+			return nil
+		}
+		client := s.rr.dependencyController.DepCrdClient.AdmiralV1().Dependencies(key.namespace)
+		dep, err := client.Get(key.name, v12.GetOptions{})
+		if k8sErrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if reflect.DeepEqual(dep.Status, status) {
+			return nil
+		}
+		dep.Status = status
+		_, err = client.UpdateStatus(dep)
+		return err
+	}
+	return nil
+}
+
+/*
+reconciled records the outcome of the reconcile of an identity/env, and queues the status updates of its GTPs
+and of the dependency records with the identity as a destination.
+gtps are the GTPs of the identity/env by cluster, activeGtp the one applied to it. syncedClusters are the clusters written without errors.
+*/
+func (s *statusWriter) reconciled(identity string, env string, gtps map[string][]*v1.GlobalTrafficPolicy, activeGtp *v1.GlobalTrafficPolicy,
+	syncedClusters map[string]bool, hosts []string, err error) {
+	if s == nil {
+		return
+	}
+	var lastError string
+	if err != nil {
+		lastError = err.Error()
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.results[identity] == nil {
+		s.results[identity] = make(map[string]identityEnvResult)
+	}
+	s.results[identity][env] = identityEnvResult{clusters: syncedClusters, hosts: hosts, err: err}
+
+	for cluster, clusterGtps := range gtps {
+		for _, gtp := range clusterGtps {
+			status := v1.GlobalTrafficPolicyStatus{State: StatusInactive, ObservedGeneration: gtp.Generation}
+			//the same GTP is often applied to every cluster running the identity, all its copies are active
+			if activeGtp != nil && gtp.Name == activeGtp.Name && gtp.Namespace == activeGtp.Namespace {
+				status.Active = true
+				status.State = StatusSynced
+				status.ClusterSynced = int32(len(syncedClusters))
+				status.Hostnames = hosts
+				status.LastError = lastError
+				if err != nil {
+					status.State = StatusError
+				}
+			}
+			key := statusKey{kind: gtpStatusKind, cluster: cluster, namespace: gtp.Namespace, name: gtp.Name}
+			s.gtpStatuses[key] = status
+			s.queue.Add(key)
+		}
+	}
+
+	if s.rr.dependencyController == nil {
+		return
+	}
+	s.rr.dependencyController.Cache.Range(func(dep *v1.Dependency) {
+		for _, destination := range dep.Spec.Destinations {
+			if destination == identity {
+				key := statusKey{kind: dependencyStatusKind, namespace: dep.Namespace, name: dep.Name}
+				s.dependencyStatuses[key] = s.getDependencyStatus(dep)
+				s.queue.Add(key)
+				return
+			}
+		}
+	})
+}
+
+/*
+The clusters of a dependency record are the clusters running its source, a cluster is synced once the SEs and DRs of all its destinations
+were written to it. A destination that wasn't reconciled yet leaves the record pending.
+*/
+func (s *statusWriter) getDependencyStatus(dep *v1.Dependency) v1.DependencyStatus {
+	status := v1.DependencyStatus{State: StatusSynced, ObservedGeneration: dep.Generation}
+	synced := make(map[string]bool)
+	for cluster := range s.rr.AdmiralCache.IdentityClusterCache.Get(dep.Spec.Source).Copy() {
+		synced[cluster] = true
+	}
+	hosts := make(map[string]bool)
+	errs := make([]string, 0)
+	for _, destination := range dep.Spec.Destinations {
+		results := s.results[destination]
+		if len(results) == 0 {
+			status.State = StatusPending
+			continue
+		}
+		for env, result := range results {
+			for _, host := range result.hosts {
+				hosts[host] = true
+			}
+			if result.err != nil {
+				errs = append(errs, fmt.Sprintf("identity=%s env=%s e=%v", destination, env, result.err))
+			}
+			for cluster := range synced {
+				if !result.clusters[cluster] {
+					synced[cluster] = false
+				}
+			}
+		}
+	}
+	for _, ok := range synced {
+		if ok {
+			status.ClusterSynced++
+		}
+	}
+	for host := range hosts {
+		status.Hostnames = append(status.Hostnames, host)
+	}
+	sort.Strings(status.Hostnames)
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		status.State = StatusError
+		status.LastError = strings.Join(errs, "; ")
+	}
+	return status
+}
+
+// returns the clusters written without errors, err is the aggregate of the ClusterErrors of the writes
+func getSyncedClusters(clusters map[string]bool, err error) map[string]bool {
+	synced := make(map[string]bool)
+	for cluster := range clusters {
+		synced[cluster] = true
+	}
+	if agg, ok := err.(utilerrors.Aggregate); ok {
+		for _, e := range agg.Errors() {
+			if clusterErr, ok := e.(*ClusterError); ok {
+				delete(synced, clusterErr.ClusterID)
+			}
+		}
+	}
+	return synced
+}
+
+// returns the hosts generated for the SEs, along with the hosts the GTP adds with its dns prefixes (see createSeAndDrSetFromGtp)
+func getGeneratedHosts(env string, serviceEntries map[string]*v1alpha3.ServiceEntry, gtp *v1.GlobalTrafficPolicy) []string {
+	hosts := make([]string, 0)
+	for host := range serviceEntries {
+		hosts = append(hosts, host)
+		if gtp == nil {
+			continue
+		}
+		for _, policy := range gtp.Spec.Policy {
+			if policy.DnsPrefix != env && policy.DnsPrefix != common.Default && policy.Dns != host {
+				hosts = append(hosts, common.GetCnameVal([]string{policy.DnsPrefix, host}))
+			}
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
@@ -0,0 +1,131 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/model"
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1"
+	admiralfake "github.com/istio-ecosystem/admiral/admiral/pkg/client/clientset/versioned/fake"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/test"
+	"istio.io/api/networking/v1alpha3"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+func TestStatusWriterReconciled(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	activeGtp := &v1.GlobalTrafficPolicy{
+		ObjectMeta: metaV1.ObjectMeta{Name: "bar-gtp", Namespace: "bar-ns", Generation: 2},
+		Spec: model.GlobalTrafficPolicy{
+			Policy: []*model.TrafficPolicy{{DnsPrefix: "west"}, {DnsPrefix: "test"}},
+		},
+	}
+	oldGtp := &v1.GlobalTrafficPolicy{
+		ObjectMeta: metaV1.ObjectMeta{Name: "bar-gtp-old", Namespace: "bar-ns", Generation: 1},
+	}
+	dep := &v1.Dependency{
+		ObjectMeta: metaV1.ObjectMeta{Name: "foo-dep", Namespace: "dep-ns", Generation: 3},
+		Spec:       model.Dependency{Source: "foo", Destinations: []string{"bar", "baz"}},
+	}
+
+	rr := NewRemoteRegistry(nil, common.AdmiralParams{})
+	rr.AdmiralCache.IdentityClusterCache.Put("foo", "cluster1", "cluster1")
+	rr.AdmiralCache.IdentityClusterCache.Put("foo", "cluster2", "cluster2")
+
+	config := rest.Config{Host: "localhost"}
+	gtpc, err := admiral.NewGlobalTrafficController("cluster1", stop, &test.MockGlobalTrafficHandler{}, &config, time.Second*time.Duration(300))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	gtpc.CrdClient = admiralfake.NewSimpleClientset()
+	gtpc.CrdClient.AdmiralV1().GlobalTrafficPolicies("bar-ns").Create(activeGtp.DeepCopy())
+	gtpc.CrdClient.AdmiralV1().GlobalTrafficPolicies("bar-ns").Create(oldGtp.DeepCopy())
+	rr.PutRemoteController("cluster1", &RemoteController{ClusterID: "cluster1", GlobalTraffic: gtpc})
+
+	depc, err := admiral.NewDependencyController(stop, &test.MockDependencyHandler{}, "testdata/fake.config", "dep-ns", time.Second*time.Duration(300))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	depc.DepCrdClient = admiralfake.NewSimpleClientset()
+	depc.DepCrdClient.AdmiralV1().Dependencies("dep-ns").Create(dep.DeepCopy())
+	depc.Cache.Put(dep)
+	rr.dependencyController = depc
+
+	s := newStatusWriter(context.Background(), rr)
+	s.limiter = flowcontrol.NewFakeAlwaysRateLimiter()
+	processAll := func() {
+		for s.queue.Len() > 0 {
+			s.processNextItem()
+		}
+	}
+
+	serviceEntries := map[string]*v1alpha3.ServiceEntry{"test.bar.mesh": {}}
+	hosts := getGeneratedHosts("test", serviceEntries, activeGtp)
+	if !reflect.DeepEqual(hosts, []string{"test.bar.mesh", "west.test.bar.mesh"}) {
+		t.Errorf("Expected the hosts of the SEs and the GTP dns prefixes, got %v", hosts)
+	}
+
+	writeErr := utilerrors.NewAggregate([]error{&ClusterError{ClusterID: "cluster2", Err: fmt.Errorf("timeout")}})
+	synced := getSyncedClusters(map[string]bool{"cluster1": true, "cluster2": true}, writeErr)
+	if !reflect.DeepEqual(synced, map[string]bool{"cluster1": true}) {
+		t.Errorf("Expected the cluster with a write error to be left out, got %v", synced)
+	}
+
+	gtps := map[string][]*v1.GlobalTrafficPolicy{"cluster1": {activeGtp, oldGtp}}
+	s.reconciled("bar", "test", gtps, activeGtp, synced, hosts, writeErr)
+	processAll()
+
+	gtpClient := gtpc.CrdClient.AdmiralV1().GlobalTrafficPolicies("bar-ns")
+	active, err := gtpClient.Get("bar-gtp", metaV1.GetOptions{})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	expectedActive := v1.GlobalTrafficPolicyStatus{ClusterSynced: 1, State: StatusError, Active: true, Hostnames: hosts, LastError: writeErr.Error(), ObservedGeneration: 2}
+	if !reflect.DeepEqual(active.Status, expectedActive) {
+		t.Errorf("Expected status %v for the active GTP, got %v", expectedActive, active.Status)
+	}
+	inactive, _ := gtpClient.Get("bar-gtp-old", metaV1.GetOptions{})
+	expectedInactive := v1.GlobalTrafficPolicyStatus{State: StatusInactive, ObservedGeneration: 1}
+	if !reflect.DeepEqual(inactive.Status, expectedInactive) {
+		t.Errorf("Expected status %v for the inactive GTP, got %v", expectedInactive, inactive.Status)
+	}
+
+	depClient := depc.DepCrdClient.AdmiralV1().Dependencies("dep-ns")
+	updated, _ := depClient.Get("foo-dep", metaV1.GetOptions{})
+	if updated.Status.State != StatusError || updated.Status.ClusterSynced != 1 || updated.Status.ObservedGeneration != 3 ||
+		!strings.Contains(updated.Status.LastError, "identity=bar env=test") {
+		t.Errorf("Expected the dependency record in error with cluster1 synced, got %v", updated.Status)
+	}
+
+	//bar is written everywhere, baz is still missing
+	s.reconciled("bar", "test", nil, nil, map[string]bool{"cluster1": true, "cluster2": true}, []string{"test.bar.mesh"}, nil)
+	processAll()
+	updated, _ = depClient.Get("foo-dep", metaV1.GetOptions{})
+	expectedDep := v1.DependencyStatus{ClusterSynced: 2, State: StatusPending, Hostnames: []string{"test.bar.mesh"}, ObservedGeneration: 3}
+	if !reflect.DeepEqual(updated.Status, expectedDep) {
+		t.Errorf("Expected status %v for the dependency record, got %v", expectedDep, updated.Status)
+	}
+
+	s.reconciled("baz", "test", nil, nil, map[string]bool{"cluster1": true, "cluster2": true}, []string{"test.baz.mesh"}, nil)
+	processAll()
+	updated, _ = depClient.Get("foo-dep", metaV1.GetOptions{})
+	expectedDep = v1.DependencyStatus{ClusterSynced: 2, State: StatusSynced, Hostnames: []string{"test.bar.mesh", "test.baz.mesh"}, ObservedGeneration: 3}
+	if !reflect.DeepEqual(updated.Status, expectedDep) {
+		t.Errorf("Expected status %v for the dependency record, got %v", expectedDep, updated.Status)
+	}
+
+	//a nil writer, when the status updates are disabled, ignores the reconciles
+	var disabled *statusWriter
+	disabled.reconciled("bar", "test", gtps, activeGtp, synced, hosts, nil)
+}
@@ -0,0 +1,247 @@
+package clusters
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/util"
+	log "github.com/sirupsen/logrus"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const orphanSweepOp = "OrphanSweep"
+
+type orphanKey struct {
+	objectType common.ResourceType
+	name       string
+}
+
+/*
+orphanSweeper looks up the ServiceEntries, DestinationRules and VirtualServices created by Admiral in the sync namespace of a cluster,
+and deletes the ones the AdmiralCache doesn't want anymore once they have been orphaned for longer than --orphan_grace_period.
+Objects are left behind when identities are renamed, envs change or clusters are removed, because no event ever points to them again.
+*/
+type orphanSweeper struct {
+	rr        *RemoteRegistry
+	clusterID string
+	//key=orphaned object value=time the object was first found orphaned
+	orphanedSince map[orphanKey]time.Time
+	gracePeriod   time.Duration
+	dryRun        bool
+	now           func() time.Time
+}
+
+func newOrphanSweeper(rr *RemoteRegistry, clusterID string) *orphanSweeper {
+	return &orphanSweeper{
+		rr:            rr,
+		clusterID:     clusterID,
+		orphanedSince: make(map[orphanKey]time.Time),
+		gracePeriod:   common.GetOrphanGracePeriod(),
+		dryRun:        common.GetOrphanSweepDryRun() || common.GetDryRun(),
+		now:           time.Now,
+	}
+}
+
+func (s *orphanSweeper) run(stop <-chan struct{}) {
+	wait.Until(s.sweep, common.GetOrphanSweepInterval(), stop)
+}
+
+func (s *orphanSweeper) sweep() {
+	rc := s.rr.GetRemoteController(s.clusterID)
+	if rc == nil || rc.ServiceEntryController == nil || rc.DestinationRuleController == nil || rc.VirtualServiceController == nil {
+		return
+	}
+	//the cache is not complete until every workload went through a sync, anything would look orphaned before that
+	if IsCacheWarmupTime(s.rr) || time.Since(rc.StartTime) < common.GetAdmiralParams().CacheRefreshDuration {
+		log.Infof(LogFormat, orphanSweepOp, "", "", s.clusterID, "Skipped during cache warm up state")
+		return
+	}
+	if IsAdmiralReadOnly() {
+		log.Infof(LogFormat, orphanSweepOp, "", "", s.clusterID, "Skipped as Admiral is in Read-only mode")
+		return
+	}
+	//the events received while Admiral was read-only are only in the cache once the full reconcile that follows a promotion is done
+	if !s.rr.isPromotionReconciled() {
+		log.Infof(LogFormat, orphanSweepOp, "", "", s.clusterID, "Skipped until the full reconcile after Admiral moved to Read/Write mode completes")
+		return
+	}
+	defer util.LogElapsedTime(orphanSweepOp, "", "", s.clusterID)()
+
+	orphans, err := s.findOrphans(rc)
+	if err != nil {
+		log.Errorf(LogErrFormat, orphanSweepOp, "", "", s.clusterID, err)
+		return
+	}
+
+	now := s.now()
+	orphanedSince := make(map[orphanKey]time.Time)
+	found := map[common.ResourceType]int{common.ServiceEntry: 0, common.DestinationRule: 0, common.VirtualService: 0}
+	for _, orphan := range orphans {
+		found[orphan.objectType]++
+		since, ok := s.orphanedSince[orphan]
+		if !ok {
+			since = now
+		}
+		if now.Sub(since) < s.gracePeriod {
+			orphanedSince[orphan] = since
+			log.Infof(LogFormat, orphanSweepOp, orphan.objectType, orphan.name, s.clusterID, fmt.Sprintf("Orphaned since %v, waiting for the grace period", since.Format(time.RFC3339)))
+			continue
+		}
+		if s.dryRun {
+			orphanedSince[orphan] = since
+			log.Infof(LogFormat, orphanSweepOp, orphan.objectType, orphan.name, s.clusterID, "Dry run, skipped deleting the orphaned object")
+			continue
+		}
+		if err := deleteOrphan(rc, orphan); err != nil {
+			orphanedSince[orphan] = since
+			log.Errorf(LogErrFormat, orphanSweepOp, orphan.objectType, orphan.name, s.clusterID, err)
+			continue
+		}
+		common.OrphansRemoved.With(s.clusterID, string(orphan.objectType)).Inc()
+		log.Infof(LogFormat, orphanSweepOp, orphan.objectType, orphan.name, s.clusterID, "Deleted the orphaned object")
+	}
+	s.orphanedSince = orphanedSince
+
+	for objectType, count := range found {
+		common.OrphansFound.With(s.clusterID, string(objectType)).Set(float64(count))
+	}
+}
+
+/*
+findOrphans returns the objects created by Admiral in the sync namespace of the cluster that are not desired anymore,
+the objects are read from the informer caches of the clusters:
+- ServiceEntries whose host is not mapped to the cluster in SeClusterCache
+- DestinationRules whose host is not mapped to the cluster in SeClusterCache, and that are not a copy of a DestinationRule in another cluster
+- VirtualServices that are not a copy of a VirtualService in another cluster
+*/
+func (s *orphanSweeper) findOrphans(rc *RemoteController) ([]orphanKey, error) {
+	syncNamespace := common.GetSyncNamespace()
+	cache := s.rr.AdmiralCache
+
+	isDesiredHost := func(host string) bool {
+		_, ok := cache.SeClusterCache.Get(host).Copy()[s.clusterID]
+		return ok
+	}
+
+	serviceEntries, err := rc.ServiceEntryController.List(syncNamespace)
+	if err != nil {
+		return nil, err
+	}
+	destinationRules, err := rc.DestinationRuleController.List(syncNamespace)
+	if err != nil {
+		return nil, err
+	}
+	virtualServices, err := rc.VirtualServiceController.List(syncNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	orphans := make([]orphanKey, 0)
+	for _, serviceEntry := range serviceEntries {
+		if !isCreatedByAdmiral(serviceEntry.Annotations) {
+			continue
+		}
+		if len(serviceEntry.Spec.Hosts) == 0 || !isDesiredHost(serviceEntry.Spec.Hosts[0]) {
+			orphans = append(orphans, orphanKey{objectType: common.ServiceEntry, name: serviceEntry.Name})
+		}
+	}
+
+	var copiedDestinationRules, copiedVirtualServices map[string]bool
+	for _, destinationRule := range destinationRules {
+		if !isCreatedByAdmiral(destinationRule.Annotations) || isDesiredHost(destinationRule.Spec.Host) {
+			continue
+		}
+		if copiedDestinationRules == nil {
+			if copiedDestinationRules, err = s.getCopiedDestinationRules(); err != nil {
+				return nil, err
+			}
+		}
+		if !copiedDestinationRules[destinationRule.Name] {
+			orphans = append(orphans, orphanKey{objectType: common.DestinationRule, name: destinationRule.Name})
+		}
+	}
+	for _, virtualService := range virtualServices {
+		if !isCreatedByAdmiral(virtualService.Annotations) {
+			continue
+		}
+		if copiedVirtualServices == nil {
+			if copiedVirtualServices, err = s.getCopiedVirtualServices(); err != nil {
+				return nil, err
+			}
+		}
+		if !copiedVirtualServices[virtualService.Name] {
+			orphans = append(orphans, orphanKey{objectType: common.VirtualService, name: virtualService.Name})
+		}
+	}
+	return orphans, nil
+}
+
+// returns the names of the DestinationRules of the other clusters that Admiral copies to the sync namespace, see handleDestinationRuleEvent
+func (s *orphanSweeper) getCopiedDestinationRules() (map[string]bool, error) {
+	names := make(map[string]bool)
+	for _, clusterID := range s.rr.GetClusterIds() {
+		rc := s.rr.GetRemoteController(clusterID)
+		if clusterID == s.clusterID || rc == nil || rc.DestinationRuleController == nil {
+			continue
+		}
+		destinationRules, err := rc.DestinationRuleController.List("")
+		if err != nil {
+			return nil, err
+		}
+		for _, destinationRule := range destinationRules {
+			if !IgnoreIstioResource(destinationRule.Spec.ExportTo, destinationRule.Annotations, destinationRule.Namespace) {
+				names[destinationRule.Name] = true
+			}
+		}
+	}
+	return names, nil
+}
+
+// returns the names of the VirtualServices of the other clusters that Admiral copies to the sync namespace, see handleVirtualServiceEvent
+func (s *orphanSweeper) getCopiedVirtualServices() (map[string]bool, error) {
+	names := make(map[string]bool)
+	for _, clusterID := range s.rr.GetClusterIds() {
+		rc := s.rr.GetRemoteController(clusterID)
+		if clusterID == s.clusterID || rc == nil || rc.VirtualServiceController == nil {
+			continue
+		}
+		virtualServices, err := rc.VirtualServiceController.List("")
+		if err != nil {
+			return nil, err
+		}
+		for _, virtualService := range virtualServices {
+			if !IgnoreIstioResource(virtualService.Spec.ExportTo, virtualService.Annotations, virtualService.Namespace) {
+				names[virtualService.Name] = true
+			}
+		}
+	}
+	return names, nil
+}
+
+func isCreatedByAdmiral(annotations map[string]string) bool {
+	return annotations[common.CreatedByAnnotation] == common.CreatedByAdmiral
+}
+
+func deleteOrphan(rc *RemoteController, orphan orphanKey) error {
+	syncNamespace := common.GetSyncNamespace()
+	forget := markAdmiralDelete(rc, orphan.objectType, syncNamespace, orphan.name)
+	err := deleteOrphanObject(rc, orphan, syncNamespace)
+	if err != nil {
+		forget()
+	}
+	return err
+}
+
+func deleteOrphanObject(rc *RemoteController, orphan orphanKey, syncNamespace string) error {
+	switch orphan.objectType {
+	case common.ServiceEntry:
+		return rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(syncNamespace).Delete(orphan.name, &v12.DeleteOptions{})
+	case common.DestinationRule:
+		return rc.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules(syncNamespace).Delete(orphan.name, &v12.DeleteOptions{})
+	case common.VirtualService:
+		return rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(syncNamespace).Delete(orphan.name, &v12.DeleteOptions{})
+	}
+	return fmt.Errorf("unsupported object type %v", orphan.objectType)
+}
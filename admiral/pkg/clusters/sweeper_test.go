@@ -0,0 +1,201 @@
+package clusters
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	istionetworkingv1alpha3 "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newSweeperTestRemoteController(clusterID string) *RemoteController {
+	fakeIstioClient := istiofake.NewSimpleClientset()
+	return &RemoteController{
+		ClusterID:                 clusterID,
+		StartTime:                 time.Now().Add(-time.Hour),
+		ServiceEntryController:    &istio.ServiceEntryController{IstioClient: fakeIstioClient},
+		DestinationRuleController: &istio.DestinationRuleController{IstioClient: fakeIstioClient},
+		VirtualServiceController:  &istio.VirtualServiceController{IstioClient: fakeIstioClient},
+	}
+}
+
+func getSweeperTestObjectMeta(name string, namespace string, createdByAdmiral bool) v12.ObjectMeta {
+	objectMeta := v12.ObjectMeta{Name: name, Namespace: namespace}
+	if createdByAdmiral {
+		objectMeta.Annotations = map[string]string{common.CreatedByAnnotation: common.CreatedByAdmiral}
+	}
+	return objectMeta
+}
+
+func getSweeperTestObjects(t *testing.T, rc *RemoteController) []string {
+	istioClient := rc.ServiceEntryController.IstioClient.NetworkingV1alpha3()
+	serviceEntries, err := istioClient.ServiceEntries("ns").List(v12.ListOptions{})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	destinationRules, err := istioClient.DestinationRules("ns").List(v12.ListOptions{})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	virtualServices, err := istioClient.VirtualServices("ns").List(v12.ListOptions{})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	names := make([]string, 0)
+	for _, serviceEntry := range serviceEntries.Items {
+		names = append(names, serviceEntry.Name)
+	}
+	for _, destinationRule := range destinationRules.Items {
+		names = append(names, destinationRule.Name)
+	}
+	for _, virtualService := range virtualServices.Items {
+		names = append(names, virtualService.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestOrphanSweeperSweep(t *testing.T) {
+	rr := NewRemoteRegistry(context.TODO(), common.AdmiralParams{})
+	rr.StartTime = time.Now().Add(-time.Hour)
+	rc1 := newSweeperTestRemoteController("cluster1")
+	rc2 := newSweeperTestRemoteController("cluster2")
+	rr.PutRemoteController(rc1.ClusterID, rc1)
+	rr.PutRemoteController(rc2.ClusterID, rc2)
+
+	rr.AdmiralCache.SeClusterCache.Put("e2e.bar.mesh", "cluster1", "cluster1")
+	//cluster2 had a dependent of foo, it's only desired in cluster3 now
+	rr.AdmiralCache.SeClusterCache.Put("e2e.foo.mesh", "cluster3", "cluster3")
+
+	istioClient := rc1.ServiceEntryController.IstioClient.NetworkingV1alpha3()
+	for _, host := range []string{"e2e.bar.mesh", "e2e.foo.mesh"} {
+		istioClient.ServiceEntries("ns").Create(&v1alpha3.ServiceEntry{
+			ObjectMeta: getSweeperTestObjectMeta(getIstioResourceName(host, "-se"), "ns", true),
+			Spec:       istionetworkingv1alpha3.ServiceEntry{Hosts: []string{host}},
+		})
+		istioClient.DestinationRules("ns").Create(&v1alpha3.DestinationRule{
+			ObjectMeta: getSweeperTestObjectMeta(getIstioResourceName(host, "-default-dr"), "ns", true),
+			Spec:       istionetworkingv1alpha3.DestinationRule{Host: host},
+		})
+	}
+	//not created by admiral, never touched
+	istioClient.ServiceEntries("ns").Create(&v1alpha3.ServiceEntry{
+		ObjectMeta: getSweeperTestObjectMeta("manual-se", "ns", false),
+		Spec:       istionetworkingv1alpha3.ServiceEntry{Hosts: []string{"manual.mesh"}},
+	})
+	//copies of the objects of cluster2
+	istioClient.DestinationRules("ns").Create(&v1alpha3.DestinationRule{
+		ObjectMeta: getSweeperTestObjectMeta("copied-dr", "ns", true),
+		Spec:       istionetworkingv1alpha3.DestinationRule{Host: "copied.foo.svc.cluster.local"},
+	})
+	istioClient.VirtualServices("ns").Create(&v1alpha3.VirtualService{ObjectMeta: getSweeperTestObjectMeta("copied-vs", "ns", true)})
+	istioClient.VirtualServices("ns").Create(&v1alpha3.VirtualService{ObjectMeta: getSweeperTestObjectMeta("deleted-vs", "ns", true)})
+
+	rc2.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules("foo").Create(&v1alpha3.DestinationRule{
+		ObjectMeta: getSweeperTestObjectMeta("copied-dr", "foo", false),
+		Spec:       istionetworkingv1alpha3.DestinationRule{Host: "copied.foo.svc.cluster.local"},
+	})
+	rc2.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices("foo").Create(&v1alpha3.VirtualService{ObjectMeta: getSweeperTestObjectMeta("copied-vs", "foo", false)})
+
+	allObjects := []string{"copied-dr", "copied-vs", "deleted-vs", "e2e.bar.mesh-default-dr", "e2e.bar.mesh-se", "e2e.foo.mesh-default-dr", "e2e.foo.mesh-se", "manual-se"}
+	desiredObjects := []string{"copied-dr", "copied-vs", "e2e.bar.mesh-default-dr", "e2e.bar.mesh-se", "manual-se"}
+
+	now := time.Now()
+	sweeper := newOrphanSweeper(rr, "cluster1")
+	sweeper.gracePeriod = time.Hour
+	sweeper.now = func() time.Time {
+		return now
+	}
+
+	sweeper.sweep()
+	if objects := getSweeperTestObjects(t, rc1); !reflect.DeepEqual(objects, allObjects) {
+		t.Errorf("Wanted no object deleted before the grace period, got %v", objects)
+	}
+	if len(sweeper.orphanedSince) != 3 {
+		t.Errorf("Wanted 3 orphaned objects, got %v", sweeper.orphanedSince)
+	}
+
+	sweeper.dryRun = true
+	now = now.Add(time.Hour)
+	sweeper.sweep()
+	if objects := getSweeperTestObjects(t, rc1); !reflect.DeepEqual(objects, allObjects) {
+		t.Errorf("Wanted no object deleted in dry run, got %v", objects)
+	}
+
+	sweeper.dryRun = false
+	sweeper.sweep()
+	if objects := getSweeperTestObjects(t, rc1); !reflect.DeepEqual(objects, desiredObjects) {
+		t.Errorf("Wanted %v after the grace period, got %v", desiredObjects, objects)
+	}
+	if len(sweeper.orphanedSince) != 0 {
+		t.Errorf("Wanted no orphaned objects left, got %v", sweeper.orphanedSince)
+	}
+}
+
+func TestOrphanSweeperSweepResetsGracePeriod(t *testing.T) {
+	rr := NewRemoteRegistry(context.TODO(), common.AdmiralParams{})
+	rr.StartTime = time.Now().Add(-time.Hour)
+	rc := newSweeperTestRemoteController("cluster1")
+	rr.PutRemoteController(rc.ClusterID, rc)
+
+	rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries("ns").Create(&v1alpha3.ServiceEntry{
+		ObjectMeta: getSweeperTestObjectMeta("e2e.bar.mesh-se", "ns", true),
+		Spec:       istionetworkingv1alpha3.ServiceEntry{Hosts: []string{"e2e.bar.mesh"}},
+	})
+
+	now := time.Now()
+	sweeper := newOrphanSweeper(rr, "cluster1")
+	sweeper.gracePeriod = time.Hour
+	sweeper.now = func() time.Time {
+		return now
+	}
+
+	sweeper.sweep()
+	//the host came back before the end of the grace period
+	rr.AdmiralCache.SeClusterCache.Put("e2e.bar.mesh", "cluster1", "cluster1")
+	now = now.Add(30 * time.Minute)
+	sweeper.sweep()
+	rr.AdmiralCache.SeClusterCache.DeleteMap("e2e.bar.mesh", "cluster1")
+	now = now.Add(30 * time.Minute)
+	sweeper.sweep()
+
+	if objects := getSweeperTestObjects(t, rc); !reflect.DeepEqual(objects, []string{"e2e.bar.mesh-se"}) {
+		t.Errorf("Wanted the grace period to start over, got %v", objects)
+	}
+}
+
+func TestOrphanSweeperWaitsForPromotionReconcile(t *testing.T) {
+	rr := NewRemoteRegistry(context.TODO(), common.AdmiralParams{})
+	rr.StartTime = time.Now().Add(-time.Hour)
+	rc := newSweeperTestRemoteController("cluster1")
+	rr.PutRemoteController(rc.ClusterID, rc)
+
+	rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries("ns").Create(&v1alpha3.ServiceEntry{
+		ObjectMeta: getSweeperTestObjectMeta("e2e.bar.mesh-se", "ns", true),
+		Spec:       istionetworkingv1alpha3.ServiceEntry{Hosts: []string{"e2e.bar.mesh"}},
+	})
+
+	sweeper := newOrphanSweeper(rr, "cluster1")
+	sweeper.gracePeriod = 0
+	sweeper.dryRun = false
+
+	//Admiral moved to read-write, the cache misses what happened while it was read-only until the full reconcile is done
+	rr.promotions++
+	sweeper.sweep()
+	if objects := getSweeperTestObjects(t, rc); !reflect.DeepEqual(objects, []string{"e2e.bar.mesh-se"}) {
+		t.Errorf("Wanted no object deleted before the full reconcile, got %v", objects)
+	}
+
+	rr.fullReconcile()
+	sweeper.sweep()
+	if objects := getSweeperTestObjects(t, rc); len(objects) != 0 {
+		t.Errorf("Wanted the orphaned object deleted after the full reconcile, got %v", objects)
+	}
+}
@@ -32,6 +32,10 @@ type RemoteController struct {
 	VirtualServiceController  *istio.VirtualServiceController
 	SidecarController         *istio.SidecarController
 	RolloutController         *admiral.RolloutController
+	StatefulSetController     *admiral.StatefulSetController
+	eventRecorder             *eventRecorder
+	pendingChanges            *pendingChanges //the pending changes of the registry, set by PutRemoteController
+	admiralDeletes            sync.Map        //key=object Admiral is deleting, its delete event isn't a drift, see markAdmiralDelete
 	stop                      chan struct{}
 	//listener for normal types
 }
@@ -49,8 +53,11 @@ type AdmiralCache struct {
 	GlobalTrafficCache              *globalTrafficCache                  //The cache needs to live in the handler because it needs access to deployments
 	DependencyNamespaceCache        *common.SidecarEgressMap
 	SeClusterCache                  *common.MapOfMaps
+	GatewayAddressCache             *sync.Map //key=cluster value=addresses of the east west gateways of the cluster
+	HostIdentityEnvCache            *sync.Map //key=host of a generated ServiceEntry value=identityEnv the host was generated for
 
 	argoRolloutsEnabled bool
+	statefulSetsEnabled bool
 }
 
 type RemoteRegistry struct {
@@ -61,6 +68,21 @@ type RemoteRegistry struct {
 	ctx               context.Context
 	AdmiralCache      *AdmiralCache
 	StartTime         time.Time
+	reconcileMutex    sync.Mutex
+	reconcileQueue    *identityReconcileQueue
+	driftMutex        sync.Mutex
+	driftCorrections  []DriftCorrection
+	//nil when the status updates are disabled or Admiral runs with --dry_run
+	statusWriter         *statusWriter
+	dependencyController *admiral.DependencyController
+	//records the events of the dependency records, in the cluster running Admiral
+	dependencyEventRecorder *eventRecorder
+	//changes recorded with --dry_run
+	pendingChanges *pendingChanges
+	//number of moves to read-write, and the last of them followed by a completed full reconcile, the orphan sweep waits for them to match
+	promotionMutex       sync.Mutex
+	promotions           int
+	reconciledPromotions int
 }
 
 func NewRemoteRegistry(ctx context.Context, params common.AdmiralParams) *RemoteRegistry {
@@ -80,13 +102,17 @@ func NewRemoteRegistry(ctx context.Context, params common.AdmiralParams) *Remote
 		ServiceEntryAddressStore:        &ServiceEntryAddressStore{EntryAddresses: map[string]string{}, Addresses: []string{}},
 		GlobalTrafficCache:              gtpCache,
 		SeClusterCache:                  common.NewMapOfMaps(),
+		GatewayAddressCache:             &sync.Map{},
+		HostIdentityEnvCache:            &sync.Map{},
 		argoRolloutsEnabled:             params.ArgoRolloutsEnabled,
+		statefulSetsEnabled:             params.StatefulSetsEnabled,
 	}
 	return &RemoteRegistry{
 		ctx:               ctx,
 		StartTime:         time.Now(),
 		remoteControllers: make(map[string]*RemoteController),
 		AdmiralCache:      admiralCache,
+		pendingChanges:    newPendingChanges(),
 	}
 }
 
@@ -99,6 +125,7 @@ func (r *RemoteRegistry) GetRemoteController(clusterId string) *RemoteController
 func (r *RemoteRegistry) PutRemoteController(clusterId string, rc *RemoteController) {
 	r.Mutex.Lock()
 	defer r.Mutex.Unlock()
+	rc.pendingChanges = r.pendingChanges
 	r.remoteControllers[clusterId] = rc
 }
 
@@ -158,6 +185,11 @@ type RolloutHandler struct {
 	ClusterID      string
 }
 
+type StatefulSetHandler struct {
+	RemoteRegistry *RemoteRegistry
+	ClusterID      string
+}
+
 type globalTrafficCache struct {
 	//map of global traffic policies key=environment.identity, value: GlobalTrafficPolicy object
 	identityCache map[string]*v1.GlobalTrafficPolicy
@@ -210,6 +242,16 @@ type ServiceHandler struct {
 	ClusterID      string
 }
 
+/*
+The locality of the cluster is written in the SE endpoints and the DRs of the identities related to the cluster,
+so they are generated again when it changes. The first locality of a cluster is handled the same way,
+as the workloads processed before it was known were skipped for the cluster.
+*/
+func (nh *NodeHandler) LocalityChanged(previous string, current string) {
+	log.Infof(LogFormat, "Update", "locality", current, nh.ClusterID, "Reconciling the cluster after the locality changed from="+previous)
+	nh.RemoteRegistry.triggerClusterReconcile(nh.ClusterID)
+}
+
 func (sh *ServiceHandler) Added(obj *k8sV1.Service) {
 	log.Infof(LogFormat, "Added", "service", obj.Name, sh.ClusterID, "received")
 	err := HandleEventForService(obj, sh.RemoteRegistry, sh.ClusterID)
@@ -235,6 +277,11 @@ func (sh *ServiceHandler) Deleted(obj *k8sV1.Service) {
 }
 
 func HandleEventForService(svc *k8sV1.Service, remoteRegistry *RemoteRegistry, clusterName string) error {
+	if svc.Namespace == common.NamespaceIstioSystem {
+		if rc := remoteRegistry.GetRemoteController(clusterName); rc != nil && rc.ServiceController != nil {
+			updateGatewayReadiness(remoteRegistry, rc)
+		}
+	}
 	if svc.Spec.Selector == nil {
 		return fmt.Errorf("selector missing on service=%s in namespace=%s cluster=%s", svc.Name, svc.Namespace, clusterName)
 	}
@@ -242,23 +289,9 @@ func HandleEventForService(svc *k8sV1.Service, remoteRegistry *RemoteRegistry, c
 	if rc == nil {
 		return fmt.Errorf("could not find the remote controller for cluster=%s", clusterName)
 	}
-	deploymentController := rc.DeploymentController
-	rolloutController := rc.RolloutController
-	if deploymentController != nil {
-		matchingDeployements := deploymentController.GetDeploymentBySelectorInNamespace(svc.Spec.Selector, svc.Namespace)
-		if len(matchingDeployements) > 0 {
-			for _, deployment := range matchingDeployements {
-				HandleEventForDeployment(admiral.Update, &deployment, remoteRegistry, clusterName)
-			}
-		}
-	}
-	if common.GetAdmiralParams().ArgoRolloutsEnabled && rolloutController != nil {
-		matchingRollouts := rolloutController.GetRolloutBySelectorInNamespace(svc.Spec.Selector, svc.Namespace)
-
-		if len(matchingRollouts) > 0 {
-			for _, rollout := range matchingRollouts {
-				HandleEventForRollout(admiral.Update, &rollout, remoteRegistry, clusterName)
-			}
+	for _, source := range GetWorkloadSources() {
+		for _, workload := range source.GetBySelectorInNamespace(rc, svc.Spec.Selector, svc.Namespace) {
+			HandleEventForWorkload(admiral.Update, workload, remoteRegistry, clusterName)
 		}
 	}
 	return nil
@@ -272,15 +305,15 @@ func (dh *DependencyHandler) Added(obj *v1.Dependency) {
 
 }
 
-func (dh *DependencyHandler) Updated(obj *v1.Dependency) {
+func (dh *DependencyHandler) Updated(obj *v1.Dependency, oldObj *v1.Dependency) {
 
 	log.Infof(LogFormat, "Update", "dependency-record", obj.Name, "", "Received=true namespace="+obj.Namespace)
 
-	// need clean up before handle it as added, I need to handle update that delete the dependency, find diff first
-	// this is more complex cos want to make sure no other service depend on the same service (which we just removed the dependancy).
-	// need to make sure nothing depend on that before cleaning up the SE for that service
 	HandleDependencyRecord(obj, dh.RemoteRegistry)
 
+	// destinations removed from the record, and the ones of its previous source if the source changed, are cleaned up
+	dh.removeStaleDependencies(oldObj, obj)
+
 }
 
 func HandleDependencyRecord(obj *v1.Dependency, remoteRegitry *RemoteRegistry) {
@@ -288,15 +321,19 @@ func HandleDependencyRecord(obj *v1.Dependency, remoteRegitry *RemoteRegistry) {
 
 	if len(sourceIdentity) == 0 {
 		log.Infof(LogFormat, "Event", "dependency-record", obj.Name, "", "No identity found namespace="+obj.Namespace)
+		remoteRegitry.dependencyEventRecorder.event(dependencyTypeMeta, &obj.ObjectMeta, k8sV1.EventTypeWarning, sourceNotFoundReason,
+			"No source identity found, the record is ignored")
 	}
 
 	updateIdentityDependencyCache(sourceIdentity, remoteRegitry.AdmiralCache.IdentityDependencyCache, obj)
 }
 
 func (dh *DependencyHandler) Deleted(obj *v1.Dependency) {
-	// special case of update, delete the dependency crd file for one service, need to loop through all ones we plan to update
-	// and make sure nobody else is relying on the same SE in same cluster
-	log.Infof(LogFormat, "Deleted", "dependency", obj.Name, "", "Skipping, not implemented")
+
+	log.Infof(LogFormat, "Delete", "dependency-record", obj.Name, "", "Received=true namespace="+obj.Namespace)
+
+	// the destinations of the record are cleaned up unless another record of the same source still depends on them
+	dh.removeStaleDependencies(obj, nil)
 }
 
 func (gtp *GlobalTrafficHandler) Added(obj *v1.GlobalTrafficPolicy) {
@@ -347,33 +384,25 @@ func (rh *RolloutHandler) Deleted(obj *argo.Rollout) {
 func HandleEventForRollout(event admiral.EventType, obj *argo.Rollout, remoteRegistry *RemoteRegistry, clusterName string) {
 
 	log.Infof(LogFormat, event, "rollout", obj.Name, clusterName, "Received")
-	globalIdentifier := common.GetRolloutGlobalIdentifier(obj)
+	HandleEventForWorkload(event, newRolloutWorkload(obj), remoteRegistry, clusterName)
+}
 
-	if len(globalIdentifier) == 0 {
-		log.Infof(LogFormat, "Event", "rollout", obj.Name, clusterName, "Skipped as '"+common.GetWorkloadIdentifier()+" was not found', namespace="+obj.Namespace)
-		return
-	}
+func (sh *StatefulSetHandler) Added(obj *k8sAppsV1.StatefulSet) {
+	HandleEventForStatefulSet(admiral.Add, obj, sh.RemoteRegistry, sh.ClusterID)
+}
 
-	env := common.GetEnvForRollout(obj)
+func (sh *StatefulSetHandler) Deleted(obj *k8sAppsV1.StatefulSet) {
+	HandleEventForStatefulSet(admiral.Delete, obj, sh.RemoteRegistry, sh.ClusterID)
+}
 
-	// Use the same function as added deployment function to update and put new service entry in place to replace old one
-	modifyServiceEntryForNewServiceOrPod(event, env, globalIdentifier, remoteRegistry)
+// helper function to handle add and delete for StatefulSetHandler
+func HandleEventForStatefulSet(event admiral.EventType, obj *k8sAppsV1.StatefulSet, remoteRegistry *RemoteRegistry, clusterName string) {
+	HandleEventForWorkload(event, newStatefulSetWorkload(obj), remoteRegistry, clusterName)
 }
 
 // helper function to handle add and delete for DeploymentHandler
 func HandleEventForDeployment(event admiral.EventType, obj *k8sAppsV1.Deployment, remoteRegistry *RemoteRegistry, clusterName string) {
-
-	globalIdentifier := common.GetDeploymentGlobalIdentifier(obj)
-
-	if len(globalIdentifier) == 0 {
-		log.Infof(LogFormat, "Event", "deployment", obj.Name, clusterName, "Skipped as '"+common.GetWorkloadIdentifier()+" was not found', namespace="+obj.Namespace)
-		return
-	}
-
-	env := common.GetEnv(obj)
-
-	// Use the same function as added deployment function to update and put new service entry in place to replace old one
-	modifyServiceEntryForNewServiceOrPod(event, env, globalIdentifier, remoteRegistry)
+	HandleEventForWorkload(event, newDeploymentWorkload(obj), remoteRegistry, clusterName)
 }
 
 // HandleEventForGlobalTrafficPolicy processes all the events related to GTPs
@@ -382,6 +411,8 @@ func HandleEventForGlobalTrafficPolicy(gtp *v1.GlobalTrafficPolicy, remoteRegist
 	globalIdentifier := common.GetGtpIdentity(gtp)
 
 	if len(globalIdentifier) == 0 {
+		remoteRegistry.getEventRecorder(clusterName).event(gtpTypeMeta, &gtp.ObjectMeta, k8sV1.EventTypeWarning, identityNotFoundReason,
+			"No '"+common.GetWorkloadIdentifier()+"' label found, the GTP is ignored")
 		return fmt.Errorf(LogFormat, "Event", "globaltrafficpolicy", gtp.Name, clusterName, "Skipped as '"+common.GetWorkloadIdentifier()+" was not found', namespace="+gtp.Namespace)
 	}
 
@@ -391,6 +422,6 @@ func HandleEventForGlobalTrafficPolicy(gtp *v1.GlobalTrafficPolicy, remoteRegist
 	// the endpoints from being deleted.
 	// TODO: Need to come up with a way to prevent deleting default endpoints so that this hack can be removed.
 	// Use the same function as added deployment function to update and put new service entry in place to replace old one
-	modifyServiceEntryForNewServiceOrPod(admiral.Update, env, globalIdentifier, remoteRegistry)
+	remoteRegistry.reconcileIdentityEnv(admiral.Update, globalIdentifier, env)
 	return nil
 }
@@ -3,28 +3,34 @@ package clusters
 import (
 	"errors"
 	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
+	networking "istio.io/api/networking/v1alpha3"
 	k8sAppsV1 "k8s.io/api/apps/v1"
 	k8sV1 "k8s.io/api/core/v1"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 )
 
-func GetMeshPorts(clusterName string, destService *k8sV1.Service,
-	destDeployment *k8sAppsV1.Deployment) map[string]uint32 {
-	var meshPorts = destDeployment.Spec.Template.Annotations[common.SidecarEnabledPorts]
+// GetMeshPortsForWorkload returns the ports of destService listed in the mesh ports annotation of the workload pod template
+func GetMeshPortsForWorkload(clusterName string, destService *k8sV1.Service, workload Workload) map[string]uint32 {
+	var meshPorts = workload.GetPodTemplate().Annotations[common.SidecarEnabledPorts]
 	ports := getMeshPortsHelper(meshPorts, destService, clusterName)
 	return ports
 }
 
+func GetMeshPorts(clusterName string, destService *k8sV1.Service,
+	destDeployment *k8sAppsV1.Deployment) map[string]uint32 {
+	return GetMeshPortsForWorkload(clusterName, destService, newDeploymentWorkload(destDeployment))
+}
+
 func GetMeshPortsForRollout(clusterName string, destService *k8sV1.Service,
 	destRollout *argo.Rollout) map[string]uint32 {
-	var meshPorts = destRollout.Spec.Template.Annotations[common.SidecarEnabledPorts]
-	ports := getMeshPortsHelper(meshPorts, destService, clusterName)
-	return ports
+	return GetMeshPortsForWorkload(clusterName, destService, newRolloutWorkload(destRollout))
 }
 
 func getMeshPortsHelper(meshPorts string, destService *k8sV1.Service, clusterName string) map[string]uint32 {
@@ -36,7 +42,7 @@ func getMeshPortsHelper(meshPorts string, destService *k8sV1.Service, clusterNam
 	if len(meshPorts) == 0 {
 		log.Infof(LogFormat, "GetMeshPorts", "service", destService.Name, clusterName, "No mesh ports present, defaulting to first port")
 		if destService.Spec.Ports != nil && len(destService.Spec.Ports) > 0 {
-			var protocol = GetPortProtocol(destService.Spec.Ports[0].Name)
+			var protocol = GetServicePortProtocol(destService, destService.Spec.Ports[0])
 			ports[protocol] = uint32(destService.Spec.Ports[0].Port)
 		}
 		return ports
@@ -44,20 +50,18 @@ func getMeshPortsHelper(meshPorts string, destService *k8sV1.Service, clusterNam
 
 	meshPortsSplit := strings.Split(meshPorts, ",")
 
-	if len(meshPortsSplit) > 1 {
-		log.Warnf(LogErrFormat, "Get", "MeshPorts", "", clusterName,
-			"Multiple inbound mesh ports detected, admiral generates service entry with first matched port and protocol")
-	}
-
-	//fetch the first valid port if there is more than one mesh port
-	var meshPortMap = make(map[uint32]uint32)
-	for _, meshPort := range meshPortsSplit {
-		port, err := strconv.ParseUint(meshPort, 10, 32)
+	//index of every valid mesh port in the annotation, used to keep the annotation order
+	var meshPortMap = make(map[uint32]int)
+	for i, meshPort := range meshPortsSplit {
+		port, err := strconv.ParseUint(strings.TrimSpace(meshPort), 10, 32)
 		if err == nil {
-			meshPortMap[uint32(port)] = uint32(port)
-			break
+			if _, ok := meshPortMap[uint32(port)]; !ok {
+				meshPortMap[uint32(port)] = i
+			}
 		}
 	}
+	var matchedPorts = make([]k8sV1.ServicePort, 0)
+	var matchedPortIndexes = make(map[int32]int)
 	for _, servicePort := range destService.Spec.Ports {
 		//handling relevant protocols from here:
 		// https://istio.io/latest/docs/ops/configuration/traffic-management/protocol-selection/#manual-protocol-selection
@@ -76,17 +80,129 @@ func getMeshPortsHelper(meshPorts string, destService *k8sV1.Service, clusterNam
 		if servicePort.TargetPort.IntVal != 0 {
 			targetPort = uint32(servicePort.TargetPort.IntVal)
 		}
-		if _, ok := meshPortMap[targetPort]; ok {
-			var protocol = GetPortProtocol(servicePort.Name)
-			log.Debugf(LogFormat, "GetMeshPorts", servicePort.Port, destService.Name, clusterName, "Adding mesh port for protocol: " + protocol)
-			ports[protocol] = uint32(servicePort.Port)
+		if index, ok := meshPortMap[targetPort]; ok {
+			matchedPorts = append(matchedPorts, servicePort)
+			matchedPortIndexes[servicePort.Port] = index
+		}
+	}
+	if len(matchedPorts) == 0 {
+		return ports
+	}
+	sort.SliceStable(matchedPorts, func(i, j int) bool {
+		return matchedPortIndexes[matchedPorts[i].Port] < matchedPortIndexes[matchedPorts[j].Port]
+	})
+
+	//the primary port is exposed on the default service entry port, so a service port using that number is always the primary one
+	primary := 0
+	for i, servicePort := range matchedPorts {
+		if uint32(servicePort.Port) == common.DefaultServiceEntryPort {
+			primary = i
 			break
 		}
 	}
+	for i, servicePort := range matchedPorts {
+		var protocol = GetServicePortProtocol(destService, servicePort)
+		var name = protocol
+		if i != primary {
+			name = getSecondaryMeshPortName(protocol, uint32(servicePort.Port))
+		}
+		log.Debugf(LogFormat, "GetMeshPorts", servicePort.Port, destService.Name, clusterName, "Adding mesh port "+name+" for protocol: "+protocol)
+		ports[name] = uint32(servicePort.Port)
+	}
 	return ports
 }
 
+/*
+Every mesh port is exposed as its own port of the generated ServiceEntry. The primary mesh port is named after its protocol, and exposed on
+DefaultServiceEntryPort when it's an http protocol, as it was when only one mesh port was supported. Every other mesh port is named
+<protocol>-<service port>. The ports that aren't the primary http port are exposed on their service port number.
+*/
+func getSecondaryMeshPortName(protocol string, servicePort uint32) string {
+	return protocol + common.Dash + strconv.FormatUint(uint64(servicePort), 10)
+}
+
+func getServiceEntryPortNumber(name string, servicePort uint32) uint32 {
+	if protocol := GetPortProtocol(name); name == protocol && isHttpProtocol(protocol) {
+		return common.DefaultServiceEntryPort
+	}
+	return servicePort
+}
+
+//returns the mesh ports getServiceEntryPorts generated the service entry ports from
+func getMeshPortsForServiceEntryPorts(sePorts []*networking.Port) map[string]uint32 {
+	meshPorts := make(map[string]uint32, len(sePorts))
+	for _, sePort := range sePorts {
+		meshPorts[sePort.Name] = sePort.Number
+	}
+	return meshPorts
+}
+
+//adds the mesh ports of another cluster to meshPorts, the ports whose service entry port number is already used are left out
+func mergeMeshPorts(meshPorts map[string]uint32, clusterMeshPorts map[string]uint32) map[string]uint32 {
+	merged := make(map[string]uint32, len(meshPorts)+len(clusterMeshPorts))
+	numbers := make(map[uint32]bool)
+	for name, servicePort := range meshPorts {
+		merged[name] = servicePort
+		numbers[getServiceEntryPortNumber(name, servicePort)] = true
+	}
+	names := make([]string, 0, len(clusterMeshPorts))
+	for name := range clusterMeshPorts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		number := getServiceEntryPortNumber(name, clusterMeshPorts[name])
+		if _, ok := merged[name]; ok || numbers[number] {
+			continue
+		}
+		merged[name] = clusterMeshPorts[name]
+		numbers[number] = true
+	}
+	return merged
+}
+
+//returns the service entry ports for the mesh ports, sorted by port number
+func getServiceEntryPorts(meshPorts map[string]uint32) []*networking.Port {
+	if len(meshPorts) == 0 {
+		return []*networking.Port{{Number: uint32(common.DefaultServiceEntryPort),
+			Name: common.Http, Protocol: common.Http}}
+	}
+	var sePorts = make([]*networking.Port, 0, len(meshPorts))
+	for name, servicePort := range meshPorts {
+		var protocol = GetPortProtocol(name)
+		sePorts = append(sePorts, &networking.Port{Number: getServiceEntryPortNumber(name, servicePort),
+			Name: name, Protocol: protocol})
+	}
+	sortServiceEntryPorts(sePorts)
+	return sePorts
+}
+
+func sortServiceEntryPorts(sePorts []*networking.Port) {
+	sort.Slice(sePorts, func(i, j int) bool {
+		if sePorts[i].Number == sePorts[j].Number {
+			return sePorts[i].Name < sePorts[j].Name
+		}
+		return sePorts[i].Number < sePorts[j].Number
+	})
+}
+
 func GetPortProtocol(name string) string {
+	return GetProtocol("", name)
+}
+
+// GetServicePortProtocol returns the protocol of a port of the k8s service, from its appProtocol or its name
+func GetServicePortProtocol(service *k8sV1.Service, servicePort k8sV1.ServicePort) string {
+	return GetProtocol(admiral.GetAppProtocol(service, servicePort.Port), servicePort.Name)
+}
+
+/*
+Follows the istio manual protocol selection rules https://istio.io/latest/docs/ops/configuration/traffic-management/protocol-selection/#manual-protocol-selection
+A known appProtocol takes precedence over the port name, the port name is expected to be <protocol>[-<suffix>]. Defaults to http.
+*/
+func GetProtocol(appProtocol string, name string) string {
+	if protocol, ok := getKnownProtocol(appProtocol); ok {
+		return protocol
+	}
 	var protocol = common.Http
 	if strings.Index(name, common.GrpcWeb) == 0 {
 		protocol = common.GrpcWeb
@@ -94,10 +210,37 @@ func GetPortProtocol(name string) string {
 		protocol = common.Grpc
 	} else if strings.Index(name, common.Http2) == 0 {
 		protocol = common.Http2
+	} else {
+		var lowerName = strings.ToLower(name)
+		for _, p := range []string{common.Https, common.Tcp, common.Tls, common.Mongo, common.Mysql, common.Redis} {
+			if lowerName == p || strings.HasPrefix(lowerName, p+common.Dash) {
+				protocol = p
+				break
+			}
+		}
 	}
 	return protocol
 }
 
+func getKnownProtocol(protocol string) (string, bool) {
+	var lowerProtocol = strings.ToLower(protocol)
+	switch lowerProtocol {
+	case common.Http, common.Http2, common.Https, common.Grpc, common.GrpcWeb,
+		common.Tcp, common.Tls, common.Mongo, common.Mysql, common.Redis:
+		return lowerProtocol, true
+	}
+	return "", false
+}
+
+//http, http2, grpc and grpc-web are handled by the http connection manager, everything else is proxied as opaque tcp
+func isHttpProtocol(protocol string) bool {
+	switch strings.ToLower(protocol) {
+	case common.Http, common.Http2, common.Grpc, common.GrpcWeb:
+		return true
+	}
+	return false
+}
+
 func GetServiceEntryStateFromConfigmap(configmap *k8sV1.ConfigMap) *ServiceEntryAddressStore {
 
 	bytes := []byte(configmap.Data["serviceEntryAddressStore"])
@@ -4,6 +4,7 @@ import (
 	"errors"
 	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	networking "istio.io/api/networking/v1alpha3"
 	k8sAppsV1 "k8s.io/api/apps/v1"
 	coreV1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -52,6 +53,8 @@ func TestGetMeshPorts(t *testing.T) {
 
 	portsFromDefaultSvcPort := map[string]uint32{"http": defaultServicePort}
 
+	multiplePorts := map[string]uint32{"http": uint32(annotatedPort), "grpc-8091": uint32(annotatedSecondPort)}
+
 	emptyPorts := map[string]uint32{}
 
 	testCases := []struct {
@@ -112,6 +115,16 @@ func TestGetMeshPorts(t *testing.T) {
 			deployment: deployment,
 			expected:   http2Ports,
 		},
+		{
+			name:       "should return a grpc port based on the appProtocol of the service port",
+			service:    k8sV1.Service{
+				ObjectMeta: v1.ObjectMeta{Name: "server", Labels: map[string]string{"asset": "Intuit.platform.mesh.server"},
+					Annotations: map[string]string{common.AppProtocolsAnnotation: strconv.Itoa(annotatedPort) + "=GRPC"}},
+				Spec:       k8sV1.ServiceSpec{Ports: []k8sV1.ServicePort{{Name: "service", Port: int32(annotatedPort)}}},
+			},
+			deployment: deployment,
+			expected:   grpcPorts,
+		},
 		{
 			name: "should return a default port",
 			service: k8sV1.Service{
@@ -137,14 +150,24 @@ func TestGetMeshPorts(t *testing.T) {
 			expected: emptyPorts,
 		},
 		{
-			name:       "should return a http port if the port name doesn't start with a protocol name",
+			name:       "should return every annotated mesh port",
 			service:    k8sV1.Service{
 				ObjectMeta: v1.ObjectMeta{Name: "server", Labels: map[string]string{"asset": "Intuit.platform.mesh.server"}},
 				Spec:       k8sV1.ServiceSpec{Ports: []k8sV1.ServicePort{{Name: "http", Port: int32(annotatedPort)},
 					{Name: "grpc", Port: int32(annotatedSecondPort)}}},
 			},
 			deployment: deploymentWithMultipleMeshPorts,
-			expected:   ports,
+			expected:   multiplePorts,
+		},
+		{
+			name:    "should keep the service port using the default service entry port as primary",
+			service: k8sV1.Service{
+				ObjectMeta: v1.ObjectMeta{Name: "server", Labels: map[string]string{"asset": "Intuit.platform.mesh.server"}},
+				Spec: k8sV1.ServiceSpec{Ports: []k8sV1.ServicePort{{Name: "grpc", Port: int32(annotatedPort)},
+					{Name: "http", Port: int32(80), TargetPort: intstr.FromInt(annotatedSecondPort)}}},
+			},
+			deployment: deploymentWithMultipleMeshPorts,
+			expected:   map[string]uint32{"http": uint32(80), "grpc-8090": uint32(annotatedPort)},
 		},
 	}
 
@@ -291,3 +314,100 @@ func TestGetMeshPortsForRollout(t *testing.T) {
 		})
 	}
 }
+
+func TestGetServiceEntryPorts(t *testing.T) {
+	testCases := []struct {
+		name      string
+		meshPorts map[string]uint32
+		expected  []*networking.Port
+	}{
+		{
+			name:      "should default to a http port",
+			meshPorts: map[string]uint32{},
+			expected:  []*networking.Port{{Number: uint32(common.DefaultServiceEntryPort), Name: "http", Protocol: "http"}},
+		},
+		{
+			name:      "should expose the primary port on the default port",
+			meshPorts: map[string]uint32{"grpc": 8090},
+			expected:  []*networking.Port{{Number: uint32(common.DefaultServiceEntryPort), Name: "grpc", Protocol: "grpc"}},
+		},
+		{
+			name:      "should expose every other mesh port on its service port sorted by number",
+			meshPorts: map[string]uint32{"http-9090": 9090, "grpc-web-8091": 8091, "http": 8090},
+			expected: []*networking.Port{
+				{Number: uint32(common.DefaultServiceEntryPort), Name: "http", Protocol: "http"},
+				{Number: 8091, Name: "grpc-web-8091", Protocol: "grpc-web"},
+				{Number: 9090, Name: "http-9090", Protocol: "http"},
+			},
+		},
+		{
+			name:      "should expose a tcp primary port on its service port",
+			meshPorts: map[string]uint32{"tcp": 5432, "mongo-27017": 27017, "tls-9443": 9443},
+			expected: []*networking.Port{
+				{Number: 5432, Name: "tcp", Protocol: "tcp"},
+				{Number: 9443, Name: "tls-9443", Protocol: "tls"},
+				{Number: 27017, Name: "mongo-27017", Protocol: "mongo"},
+			},
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			sePorts := getServiceEntryPorts(c.meshPorts)
+			if !reflect.DeepEqual(sePorts, c.expected) {
+				t.Errorf("Wanted ports: %v, got: %v", c.expected, sePorts)
+			}
+			//the mesh ports found back from the service entry ports give the same ports
+			if rebuilt := getServiceEntryPorts(getMeshPortsForServiceEntryPorts(sePorts)); !reflect.DeepEqual(rebuilt, c.expected) {
+				t.Errorf("Wanted rebuilt ports: %v, got: %v", c.expected, rebuilt)
+			}
+		})
+	}
+}
+
+func TestMergeMeshPorts(t *testing.T) {
+	meshPorts := map[string]uint32{"http": 8080, "grpc-8091": 8091}
+	//grpc would be exposed on the default port too, tcp on the port of grpc-8091
+	clusterMeshPorts := map[string]uint32{"grpc": 8090, "tcp": 8091, "mongo-27017": 27017, "http": 9090}
+
+	merged := mergeMeshPorts(meshPorts, clusterMeshPorts)
+
+	expected := map[string]uint32{"http": 8080, "grpc-8091": 8091, "mongo-27017": 27017}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("Wanted mesh ports: %v, got: %v", expected, merged)
+	}
+}
+
+func TestGetProtocol(t *testing.T) {
+	testCases := []struct {
+		name        string
+		appProtocol string
+		portName    string
+		expected    string
+	}{
+		{name: "should default to http", portName: "web", expected: "http"},
+		{name: "should default to http for an empty name", portName: "", expected: "http"},
+		{name: "should return grpc-web", portName: "grpc-web", expected: "grpc-web"},
+		{name: "should return grpc", portName: "grpc-foo", expected: "grpc"},
+		{name: "should return http2", portName: "http2", expected: "http2"},
+		{name: "should return https", portName: "https-api", expected: "https"},
+		{name: "should return tcp", portName: "tcp", expected: "tcp"},
+		{name: "should return tcp with a suffix", portName: "tcp-db", expected: "tcp"},
+		{name: "should match the protocol prefix case insensitively", portName: "TLS-secure", expected: "tls"},
+		{name: "should return mongo", portName: "mongo", expected: "mongo"},
+		{name: "should return mysql", portName: "mysql-primary", expected: "mysql"},
+		{name: "should return redis", portName: "redis-cache", expected: "redis"},
+		{name: "should not match a protocol without a dash separator", portName: "tcpx", expected: "http"},
+		{name: "should prefer appProtocol over the port name", appProtocol: "TCP", portName: "http", expected: "tcp"},
+		{name: "should fall back to the port name for an unknown appProtocol", appProtocol: "kubernetes.io/h2c", portName: "mongo", expected: "mongo"},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			protocol := GetProtocol(c.appProtocol, c.portName)
+			if protocol != c.expected {
+				t.Errorf("Wanted protocol: %v, got: %v", c.expected, protocol)
+			}
+		})
+	}
+}
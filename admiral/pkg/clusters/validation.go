@@ -0,0 +1,232 @@
+package clusters
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/model"
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+)
+
+/*
+ValidateGlobalTrafficPolicy checks a GTP before it's admitted, and returns the reasons to reject it along with the warnings to show to its author.
+The target regions are checked against the localities of the clusters known to Admiral, and the GTP is rejected when another GTP
+of the same identity/env has the same priority, as getMostRecentGtp would then pick one based on their creation time.
+*/
+func (r *RemoteRegistry) ValidateGlobalTrafficPolicy(gtp *v1.GlobalTrafficPolicy) ([]string, []string) {
+	errs := make([]string, 0)
+	warnings := make([]string, 0)
+
+	identityKey := common.GetGlobalTrafficDeploymentLabel()
+	identity := common.GetGtpIdentity(gtp)
+	if len(gtp.Spec.Selector) == 0 {
+		errs = append(errs, "spec.selector is empty")
+	}
+	if len(identity) == 0 {
+		errs = append(errs, fmt.Sprintf("no '%s' label or selector found", identityKey))
+	} else if label, selector := gtp.Labels[identityKey], gtp.Spec.Selector[identityKey]; len(label) > 0 && len(selector) > 0 && label != selector {
+		errs = append(errs, fmt.Sprintf("the '%s' label %s doesn't match the selector %s", identityKey, label, selector))
+	}
+	if len(gtp.Spec.Policy) == 0 {
+		errs = append(errs, "spec.policy is empty")
+	}
+
+	env := common.GetGtpEnv(gtp)
+	regions := r.getKnownRegions()
+	hosts := make(map[string]int)
+	for i, policy := range gtp.Spec.Policy {
+		field := fmt.Sprintf("spec.policy[%d]", i)
+		if policy == nil {
+			errs = append(errs, field+" is empty")
+			continue
+		}
+		if len(policy.Dns) > 0 {
+			warnings = append(warnings, field+".dns is deprecated, use dnsPrefix")
+		}
+
+		//the policies of the default host, see createSeAndDrSetFromGtp
+		host := policy.DnsPrefix
+		if policy.DnsPrefix == env || policy.DnsPrefix == common.Default {
+			host = common.Default
+		} else if len(policy.DnsPrefix) == 0 {
+			host = policy.Dns
+		}
+		if len(host) == 0 {
+			errs = append(errs, field+".dnsPrefix is required")
+		} else if previous, ok := hosts[host]; ok {
+			errs = append(errs, fmt.Sprintf("%s.dnsPrefix %s generates the same host as spec.policy[%d]", field, policy.DnsPrefix, previous))
+		} else {
+			hosts[host] = i
+		}
+
+		if _, ok := model.TrafficPolicy_LbType_name[int32(policy.LbType)]; !ok {
+			errs = append(errs, fmt.Sprintf("%s.lbType %d is unknown", field, policy.LbType))
+		}
+		targetErrs, targetWarnings := validateTargets(field, policy, regions)
+		errs = append(errs, targetErrs...)
+		warnings = append(warnings, targetWarnings...)
+	}
+
+	if len(identity) > 0 {
+		if conflict, cluster := r.getConflictingGtp(gtp, identity, env); conflict != nil {
+			errs = append(errs, fmt.Sprintf("identity=%s env=%s already has the GTP %s/%s in cluster %s with the same priority, give them different '%s' labels",
+				identity, env, conflict.Namespace, conflict.Name, cluster, common.GetAdmiralParams().LabelSet.PriorityKey))
+		}
+	}
+	return errs, warnings
+}
+
+/*
+ValidateDependency checks a dependency record before it's admitted, and returns the reasons to reject it along with the warnings to show to its author.
+The destinations Admiral hasn't seen a workload of are reported as warnings, as a record can come before the workloads it names, unless
+--webhook_reject_unknown_destinations is set. They're only checked once the workloads of every cluster have been listed.
+*/
+func (r *RemoteRegistry) ValidateDependency(dependency *v1.Dependency) ([]string, []string) {
+	errs := make([]string, 0)
+	warnings := make([]string, 0)
+
+	if len(dependency.Spec.Source) == 0 {
+		errs = append(errs, "spec.source is required")
+	}
+	if len(dependency.Spec.IdentityLabel) == 0 {
+		errs = append(errs, "spec.identityLabel is required")
+	}
+
+	//every destination would look unknown before the workloads are listed
+	var knownIdentities map[string]bool
+	if r.haveWorkloadsSynced() {
+		knownIdentities = r.getKnownIdentities()
+	}
+	destinations := make(map[string]int)
+	for i, destination := range dependency.Spec.Destinations {
+		field := fmt.Sprintf("spec.destinations[%d]", i)
+		if len(destination) == 0 {
+			errs = append(errs, field+" is empty")
+			continue
+		}
+		if destination == dependency.Spec.Source {
+			errs = append(errs, fmt.Sprintf("%s %s is the source", field, destination))
+			continue
+		}
+		if previous, ok := destinations[destination]; ok {
+			errs = append(errs, fmt.Sprintf("%s %s is the same as spec.destinations[%d]", field, destination, previous))
+			continue
+		}
+		destinations[destination] = i
+		if knownIdentities != nil && !knownIdentities[destination] && r.AdmiralCache.IdentityClusterCache.Get(destination) == nil {
+			message := fmt.Sprintf("%s %s isn't the identity of any workload known to Admiral", field, destination)
+			if common.GetRejectUnknownDestinations() {
+				errs = append(errs, message)
+			} else {
+				warnings = append(warnings, message)
+			}
+		}
+	}
+	return errs, warnings
+}
+
+//returns true once the workload informers of every cluster have listed their workloads, false while no cluster is known
+func (r *RemoteRegistry) haveWorkloadsSynced() bool {
+	synced := len(r.GetClusterIds()) > 0
+	r.RangeRemoteControllers(func(k string, rc *RemoteController) {
+		for _, source := range GetWorkloadSources() {
+			if !source.HasSynced(rc) {
+				synced = false
+			}
+		}
+	})
+	return synced
+}
+
+//returns the identities of the workloads of every cluster, the IdentityClusterCache misses the ones received while Admiral is read-only
+func (r *RemoteRegistry) getKnownIdentities() map[string]bool {
+	identities := make(map[string]bool)
+	for _, ie := range r.getIdentityEnvs() {
+		identities[ie.identity] = true
+	}
+	return identities
+}
+
+func validateTargets(field string, policy *model.TrafficPolicy, regions map[string]bool) ([]string, []string) {
+	errs := make([]string, 0)
+	warnings := make([]string, 0)
+	failover := policy.LbType == model.TrafficPolicy_FAILOVER || policy.LbType == model.TrafficPolicy_FAILOVER_PRIORITY
+	if failover && len(policy.Target) == 0 {
+		errs = append(errs, fmt.Sprintf("%s.target is required by %s", field, policy.LbType))
+	}
+	var total int32
+	targetRegions := make(map[string]int)
+	for j, target := range policy.Target {
+		targetField := fmt.Sprintf("%s.target[%d]", field, j)
+		if target == nil {
+			errs = append(errs, targetField+" is empty")
+			continue
+		}
+		if len(target.Region) == 0 {
+			errs = append(errs, targetField+".region is required")
+		} else if len(regions) > 0 && !regions[target.Region] {
+			errs = append(errs, fmt.Sprintf("%s.region %s isn't the region of any cluster, known regions are %s", targetField, target.Region, strings.Join(getSortedKeys(regions), ",")))
+		}
+		if policy.LbType == model.TrafficPolicy_FAILOVER_PRIORITY {
+			//the targets only give the order of the regions
+			if previous, ok := targetRegions[target.Region]; ok && len(target.Region) > 0 {
+				errs = append(errs, fmt.Sprintf("%s.region %s is the same as %s.target[%d]", targetField, target.Region, field, previous))
+			} else {
+				targetRegions[target.Region] = j
+			}
+			if target.Weight != 0 || len(target.Zone) > 0 {
+				warnings = append(warnings, fmt.Sprintf("%s weight and zone are ignored by %s", targetField, policy.LbType))
+			}
+			continue
+		}
+		if target.Weight < 0 || target.Weight > 100 {
+			errs = append(errs, fmt.Sprintf("%s.weight %d must be between 0 and 100", targetField, target.Weight))
+		}
+		total += target.Weight
+	}
+	if policy.LbType == model.TrafficPolicy_FAILOVER && len(policy.Target) > 0 && total != 100 {
+		errs = append(errs, fmt.Sprintf("%s.target weights sum to %d instead of 100", field, total))
+	}
+	return errs, warnings
+}
+
+//returns the regions of the clusters whose locality is known
+func (r *RemoteRegistry) getKnownRegions() map[string]bool {
+	regions := make(map[string]bool)
+	r.RangeRemoteControllers(func(k string, rc *RemoteController) {
+		if locality := getClusterLocality(rc); len(locality) > 0 {
+			regions[strings.SplitN(locality, common.Slash, 2)[0]] = true
+		}
+	})
+	return regions
+}
+
+//returns another GTP of the identity/env with the same priority, and its cluster. The copies of the GTP in the other clusters don't conflict with it
+func (r *RemoteRegistry) getConflictingGtp(gtp *v1.GlobalTrafficPolicy, identity string, env string) (*v1.GlobalTrafficPolicy, string) {
+	priority := getGtpPriority(gtp)
+	clusterIDs := r.GetClusterIds()
+	sort.Strings(clusterIDs)
+	for _, clusterID := range clusterIDs {
+		rc := r.GetRemoteController(clusterID)
+		if rc == nil || rc.GlobalTraffic == nil {
+			continue
+		}
+		for _, existing := range rc.GlobalTraffic.Cache.Get(common.ConstructGtpKey(env, identity), gtp.Namespace) {
+			if existing.Name != gtp.Name && getGtpPriority(existing) == priority {
+				return existing, clusterID
+			}
+		}
+	}
+	return nil, ""
+}
+
+func getSortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
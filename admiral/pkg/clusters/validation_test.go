@@ -0,0 +1,234 @@
+package clusters
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/model"
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/test"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestValidateGlobalTrafficPolicy(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	config := rest.Config{Host: "localhost"}
+	gtpc, err := admiral.NewGlobalTrafficController("cluster1", stop, &test.MockGlobalTrafficHandler{}, &config, time.Second*time.Duration(300))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	gtpc.Cache.Put(&v1.GlobalTrafficPolicy{
+		ObjectMeta: metaV1.ObjectMeta{Name: "existing-gtp", Namespace: "bar-ns", Labels: map[string]string{"identity": "bar", "env": "e2e"}},
+	})
+
+	rr := NewRemoteRegistry(nil, common.AdmiralParams{})
+	rr.PutRemoteController("cluster1", &RemoteController{
+		ClusterID:      "cluster1",
+		GlobalTraffic:  gtpc,
+		NodeController: &admiral.NodeController{Locality: &admiral.Locality{Region: "us-west-2", Zone: "us-west-2a"}},
+	})
+	rr.PutRemoteController("cluster2", &RemoteController{
+		ClusterID:      "cluster2",
+		NodeController: &admiral.NodeController{Locality: &admiral.Locality{Region: "us-east-2"}},
+	})
+
+	newGtp := func(labels map[string]string, policies ...*model.TrafficPolicy) *v1.GlobalTrafficPolicy {
+		return &v1.GlobalTrafficPolicy{
+			ObjectMeta: metaV1.ObjectMeta{Name: "new-gtp", Namespace: "bar-ns", Labels: labels},
+			Spec:       model.GlobalTrafficPolicy{Selector: map[string]string{"identity": "bar", "env": "e2e"}, Policy: policies},
+		}
+	}
+	barLabels := map[string]string{"identity": "bar", "env": "e2e", "priority": "1"}
+	failover := func(dnsPrefix string, targets ...*model.TrafficGroup) *model.TrafficPolicy {
+		return &model.TrafficPolicy{DnsPrefix: dnsPrefix, LbType: model.TrafficPolicy_FAILOVER, Target: targets}
+	}
+
+	testCases := []struct {
+		name             string
+		gtp              *v1.GlobalTrafficPolicy
+		expectedErrs     []string
+		expectedWarnings int
+	}{
+		{
+			name: "Given a valid FAILOVER policy, " +
+				"Then it's accepted",
+			gtp: newGtp(barLabels, failover("default", &model.TrafficGroup{Region: "us-west-2", Weight: 90}, &model.TrafficGroup{Region: "us-east-2", Weight: 10}),
+				&model.TrafficPolicy{DnsPrefix: "west", LbType: model.TrafficPolicy_TOPOLOGY}),
+		},
+		{
+			name: "Given weights that don't sum to 100 and an unknown region, " +
+				"Then it's rejected",
+			gtp:          newGtp(barLabels, failover("default", &model.TrafficGroup{Region: "us-west-2", Weight: 90}, &model.TrafficGroup{Region: "eu-west-1", Weight: 20})),
+			expectedErrs: []string{"spec.policy[0].target[1].region eu-west-1 isn't the region of any cluster, known regions are us-east-2,us-west-2", "spec.policy[0].target weights sum to 110 instead of 100"},
+		},
+		{
+			name: "Given two policies for the default host, " +
+				"Then it's rejected",
+			gtp:          newGtp(barLabels, &model.TrafficPolicy{DnsPrefix: "default"}, &model.TrafficPolicy{DnsPrefix: "e2e"}),
+			expectedErrs: []string{"spec.policy[1].dnsPrefix e2e generates the same host as spec.policy[0]"},
+		},
+		{
+			name: "Given the deprecated dns field, " +
+				"Then it's accepted with a warning",
+			gtp:              newGtp(barLabels, &model.TrafficPolicy{Dns: "e2e.bar.global"}),
+			expectedWarnings: 1,
+		},
+		{
+			name: "Given an empty selector and policy, " +
+				"Then it's rejected",
+			gtp:          &v1.GlobalTrafficPolicy{ObjectMeta: metaV1.ObjectMeta{Name: "new-gtp", Namespace: "bar-ns"}},
+			expectedErrs: []string{"spec.selector is empty", "no 'identity' label or selector found", "spec.policy is empty"},
+		},
+		{
+			name: "Given a label identity different from the selector one, " +
+				"Then it's rejected",
+			gtp:          newGtp(map[string]string{"identity": "foo", "priority": "1"}, &model.TrafficPolicy{DnsPrefix: "default"}),
+			expectedErrs: []string{"the 'identity' label foo doesn't match the selector bar"},
+		},
+		{
+			name: "Given a FAILOVER policy without targets and an unknown lbType, " +
+				"Then it's rejected",
+			gtp:          newGtp(barLabels, failover("default"), &model.TrafficPolicy{DnsPrefix: "west", LbType: 5}),
+			expectedErrs: []string{"spec.policy[0].target is required by FAILOVER", "spec.policy[1].lbType 5 is unknown"},
+		},
+		{
+			name: "Given a FAILOVER_PRIORITY policy listing a region twice and a weight, " +
+				"Then it's rejected with a warning",
+			gtp: newGtp(barLabels, &model.TrafficPolicy{DnsPrefix: "default", LbType: model.TrafficPolicy_FAILOVER_PRIORITY,
+				Target: []*model.TrafficGroup{{Region: "us-west-2", Weight: 100}, {Region: "us-east-2"}, {Region: "us-west-2"}}}),
+			expectedErrs:     []string{"spec.policy[0].target[2].region us-west-2 is the same as spec.policy[0].target[0]"},
+			expectedWarnings: 1,
+		},
+		{
+			name: "Given another GTP of the identity/env with the same priority, " +
+				"Then it's rejected",
+			gtp:          newGtp(map[string]string{"identity": "bar", "env": "e2e"}, &model.TrafficPolicy{DnsPrefix: "default"}),
+			expectedErrs: []string{"identity=bar env=e2e already has the GTP bar-ns/existing-gtp in cluster cluster1 with the same priority, give them different 'priority' labels"},
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			errs, warnings := rr.ValidateGlobalTrafficPolicy(c.gtp)
+			if strings.Join(errs, "\n") != strings.Join(c.expectedErrs, "\n") {
+				t.Errorf("Expected errors %v, got %v", c.expectedErrs, errs)
+			}
+			if len(warnings) != c.expectedWarnings {
+				t.Errorf("Expected %d warnings, got %v", c.expectedWarnings, warnings)
+			}
+		})
+	}
+}
+
+func TestValidateDependency(t *testing.T) {
+	defer common.SetRejectUnknownDestinations(false)
+	rr := NewRemoteRegistry(nil, common.AdmiralParams{})
+	//no workload controller, so the cluster has nothing left to list
+	syncedCluster := &RemoteController{ClusterID: "cluster1"}
+	config := rest.Config{Host: "localhost"}
+	stop := make(chan struct{})
+	defer close(stop)
+	unsyncedDeployments, err := admiral.NewDeploymentController("cluster2", stop, &test.MockDeploymentHandler{}, &config, time.Second*time.Duration(300))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	unsyncedCluster := &RemoteController{ClusterID: "cluster2", DeploymentController: unsyncedDeployments}
+
+	newDependency := func(source string, identityLabel string, destinations ...string) *v1.Dependency {
+		return &v1.Dependency{
+			ObjectMeta: metaV1.ObjectMeta{Name: "foo-dep", Namespace: "admiral"},
+			Spec:       model.Dependency{Source: source, IdentityLabel: identityLabel, Destinations: destinations},
+		}
+	}
+
+	testCases := []struct {
+		name             string
+		dependency       *v1.Dependency
+		clusters         []*RemoteController
+		knownIdentities  []string
+		reject           bool
+		expectedErrs     []string
+		expectedWarnings []string
+	}{
+		{
+			name: "Given a valid dependency record, " +
+				"Then it's accepted",
+			dependency:      newDependency("foo", "identity", "bar", "baz"),
+			clusters:        []*RemoteController{syncedCluster},
+			knownIdentities: []string{"bar", "baz"},
+		},
+		{
+			name: "Given a record without source and identityLabel, " +
+				"Then it's rejected",
+			dependency:   newDependency("", "", "bar"),
+			expectedErrs: []string{"spec.source is required", "spec.identityLabel is required"},
+		},
+		{
+			name: "Given a record depending on its source and on the same destination twice, " +
+				"Then it's rejected",
+			dependency:   newDependency("foo", "identity", "bar", "foo", "bar", ""),
+			expectedErrs: []string{"spec.destinations[1] foo is the source", "spec.destinations[2] bar is the same as spec.destinations[0]", "spec.destinations[3] is empty"},
+		},
+		{
+			name: "Given a destination Admiral doesn't know, " +
+				"Then it's accepted with a warning",
+			dependency:       newDependency("foo", "identity", "bar", "baz"),
+			clusters:         []*RemoteController{syncedCluster},
+			knownIdentities:  []string{"bar"},
+			expectedWarnings: []string{"spec.destinations[1] baz isn't the identity of any workload known to Admiral"},
+		},
+		{
+			name: "Given a destination Admiral doesn't know, " +
+				"And unknown destinations are rejected, " +
+				"Then it's rejected",
+			dependency:      newDependency("foo", "identity", "bar", "baz"),
+			clusters:        []*RemoteController{syncedCluster},
+			knownIdentities: []string{"bar"},
+			reject:          true,
+			expectedErrs:    []string{"spec.destinations[1] baz isn't the identity of any workload known to Admiral"},
+		},
+		{
+			name: "Given Admiral doesn't know any cluster yet, " +
+				"Then the destinations aren't checked",
+			dependency: newDependency("foo", "identity", "bar", "baz"),
+			reject:     true,
+		},
+		{
+			name: "Given the workloads of a cluster haven't been listed yet, " +
+				"Then the destinations aren't checked",
+			dependency:      newDependency("foo", "identity", "bar", "baz"),
+			clusters:        []*RemoteController{syncedCluster, unsyncedCluster},
+			knownIdentities: []string{"bar"},
+			reject:          true,
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			common.SetRejectUnknownDestinations(c.reject)
+			for _, clusterID := range rr.GetClusterIds() {
+				rr.DeleteRemoteController(clusterID)
+			}
+			for _, rc := range c.clusters {
+				rr.PutRemoteController(rc.ClusterID, rc)
+			}
+			rr.AdmiralCache.IdentityClusterCache = common.NewMapOfMaps()
+			for _, identity := range c.knownIdentities {
+				rr.AdmiralCache.IdentityClusterCache.Put(identity, "cluster1", "cluster1")
+			}
+			errs, warnings := rr.ValidateDependency(c.dependency)
+			if strings.Join(errs, "\n") != strings.Join(c.expectedErrs, "\n") {
+				t.Errorf("Expected errors %v, got %v", c.expectedErrs, errs)
+			}
+			if strings.Join(warnings, "\n") != strings.Join(c.expectedWarnings, "\n") {
+				t.Errorf("Expected warnings %v, got %v", c.expectedWarnings, warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,450 @@
+package clusters
+
+import (
+	"sort"
+	"sync"
+
+	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	networking "istio.io/api/networking/v1alpha3"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	k8sV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+)
+
+const (
+	deploymentKind  = "deployment"
+	rolloutKind     = "rollout"
+	statefulSetKind = "statefulset"
+)
+
+/*
+Workload is a k8s object running the pods of an identity in a cluster, like a deployment or an argo rollout.
+Service entries are generated from the workload without knowing its kind.
+*/
+type Workload interface {
+	GetKind() string
+	// GetObjectMeta returns the metadata of the workload object itself, not the one of its pod template
+	GetObjectMeta() *metaV1.ObjectMeta
+	GetIdentity() string
+	GetEnv() string
+	GetPodTemplate() *k8sV1.PodTemplateSpec
+	GetSelector() *metaV1.LabelSelector
+	// GetServices returns the k8s services of the workload that expose a mesh port, keyed by service name.
+	// Workloads splitting traffic between several services, like canary rollouts, return one entry per service with its weight
+	GetServices(rc *RemoteController) map[string]*WeightedService
+	// GetLocalEndpoints returns the hosts, other than the default one, that need a specific endpoint in the cluster running the workload,
+	// like the preview host of a blue green rollout. The default host cname can be included to override its endpoint
+	GetLocalEndpoints(clusterID string, cname string, services map[string]*WeightedService) map[string]*LocalEndpoint
+}
+
+// LocalEndpoint is the endpoint of a service entry host in the cluster running the workload
+type LocalEndpoint struct {
+	Address string
+	Ports   map[string]uint32
+}
+
+// WorkloadSource finds the workloads of one kind in a remote cluster
+type WorkloadSource interface {
+	Kind() string
+	// Get returns the workload of the identity and env in the cluster, nil if there is none or if the source isn't enabled for the cluster
+	Get(rc *RemoteController, identity string, env string) Workload
+	// Range calls fn for every identity and env of the workloads in the cluster
+	Range(rc *RemoteController, fn func(identity string, env string))
+	// GetBySelectorInNamespace returns the workloads of the namespace matched by a service selector
+	GetBySelectorInNamespace(rc *RemoteController, selector map[string]string, namespace string) []Workload
+	// HasSynced returns true once the workloads of the cluster have been listed, or if the source isn't enabled for the cluster
+	HasSynced(rc *RemoteController) bool
+}
+
+var (
+	workloadSourcesMutex sync.Mutex
+	workloadSources      []WorkloadSource
+)
+
+func init() {
+	RegisterWorkloadSource(&deploymentSource{})
+	RegisterWorkloadSource(&rolloutSource{})
+	RegisterWorkloadSource(&statefulSetSource{})
+}
+
+/*
+RegisterWorkloadSource adds a kind of workload Admiral generates service entries for.
+When an identity has workloads of several kinds in the same cluster, the workload of the source registered first is used.
+If RegisterWorkloadSource is called twice with the same kind or if source is nil, it panics.
+*/
+func RegisterWorkloadSource(source WorkloadSource) {
+	workloadSourcesMutex.Lock()
+	defer workloadSourcesMutex.Unlock()
+	if source == nil {
+		panic("RegisterWorkloadSource source is nil")
+	}
+	for _, registered := range workloadSources {
+		if registered.Kind() == source.Kind() {
+			panic("RegisterWorkloadSource called twice for workload kind " + source.Kind())
+		}
+	}
+	workloadSources = append(workloadSources, source)
+}
+
+// GetWorkloadSources returns the registered workload sources in registration order
+func GetWorkloadSources() []WorkloadSource {
+	workloadSourcesMutex.Lock()
+	defer workloadSourcesMutex.Unlock()
+	sources := make([]WorkloadSource, len(workloadSources))
+	copy(sources, workloadSources)
+	return sources
+}
+
+// getWorkload returns the workload of the identity and env from the first source that has one in the cluster
+func getWorkload(rc *RemoteController, identity string, env string) Workload {
+	for _, source := range GetWorkloadSources() {
+		if workload := source.Get(rc, identity, env); workload != nil {
+			return workload
+		}
+	}
+	return nil
+}
+
+// getWorkloadsForIdentity returns the workloads of the identity in the cluster, from every source and for every env
+func getWorkloadsForIdentity(rc *RemoteController, identity string) []Workload {
+	workloads := make([]Workload, 0)
+	for _, source := range GetWorkloadSources() {
+		envs := make([]string, 0)
+		source.Range(rc, func(workloadIdentity string, env string) {
+			if workloadIdentity == identity {
+				envs = append(envs, env)
+			}
+		})
+		for _, env := range envs {
+			if workload := source.Get(rc, identity, env); workload != nil {
+				workloads = append(workloads, workload)
+			}
+		}
+	}
+	return workloads
+}
+
+func getCnameForWorkload(workload Workload) string {
+	return common.GetCnameFromPodTemplate(workload.GetKind(), workload.GetObjectMeta(), workload.GetPodTemplate(), common.GetWorkloadIdentifier(), common.GetHostnameSuffix())
+}
+
+func getSanForWorkload(workload Workload) (san []string) {
+	if common.GetEnableSAN() {
+		tmpSan := common.GetSANFromPodTemplate(workload.GetKind(), common.GetSANPrefix(), workload.GetObjectMeta(), workload.GetPodTemplate(), common.GetWorkloadIdentifier())
+		if len(tmpSan) > 0 {
+			return []string{tmpSan}
+		}
+	}
+	return nil
+}
+
+// creates the service entry of the workload cname, and one for every other host of localEndpoints
+func createServiceEntryForWorkload(event admiral.EventType, rc *RemoteController, admiralCache *AdmiralCache,
+	meshPorts map[string]uint32, workload Workload, localEndpoints map[string]*LocalEndpoint, serviceEntries map[string]*networking.ServiceEntry, render *IdentityRender) *networking.ServiceEntry {
+
+	globalFqdn := getCnameForWorkload(workload)
+
+	//Handling retries for getting/putting service entries from/in cache
+
+	address := getServiceEntryAddress(admiralCache, globalFqdn, render)
+
+	//a render shows the service entries of the hosts without an address yet, with an empty address
+	if len(globalFqdn) == 0 || (len(address) == 0 && render == nil) {
+		return nil
+	}
+
+	san := getSanForWorkload(workload)
+
+	hosts := make([]string, 0, len(localEndpoints))
+	for host := range localEndpoints {
+		if host != globalFqdn {
+			hosts = append(hosts, host)
+		}
+	}
+	//keeps the order addresses are allocated in stable
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		hostAddress := getServiceEntryAddress(admiralCache, host, render)
+		if len(hostAddress) != 0 || render != nil {
+			generateServiceEntry(event, admiralCache, meshPorts, host, rc, serviceEntries, hostAddress, san, render)
+		}
+	}
+
+	tmpSe := generateServiceEntry(event, admiralCache, meshPorts, globalFqdn, rc, serviceEntries, address, san, render)
+	return tmpSe
+}
+
+// HandleEventForWorkload updates the service entries of the workload identity and env, custom workload sources call it from their controllers
+func HandleEventForWorkload(event admiral.EventType, workload Workload, remoteRegistry *RemoteRegistry, clusterName string) {
+
+	globalIdentifier := workload.GetIdentity()
+
+	if len(globalIdentifier) == 0 {
+		log.Infof(LogFormat, "Event", workload.GetKind(), workload.GetObjectMeta().Name, clusterName, "Skipped as '"+common.GetWorkloadIdentifier()+" was not found', namespace="+workload.GetObjectMeta().Namespace)
+		if event != admiral.Delete {
+			remoteRegistry.getEventRecorder(clusterName).workloadEvent(workload, k8sV1.EventTypeWarning, identityNotFoundReason,
+				"No '"+common.GetWorkloadIdentifier()+"' label found on the pod template, the workload is not added to the mesh")
+		}
+		return
+	}
+
+	remoteRegistry.reconcileIdentityEnv(event, globalIdentifier, workload.GetEnv())
+}
+
+// implements the Workload metadata methods from the object metadata and the pod template, for the kinds that have one
+type podTemplateWorkload struct {
+	kind     string
+	meta     *metaV1.ObjectMeta
+	template *k8sV1.PodTemplateSpec
+	selector *metaV1.LabelSelector
+}
+
+func (w *podTemplateWorkload) GetKind() string {
+	return w.kind
+}
+
+func (w *podTemplateWorkload) GetObjectMeta() *metaV1.ObjectMeta {
+	return w.meta
+}
+
+func (w *podTemplateWorkload) GetIdentity() string {
+	return common.GetGlobalIdentifierFromPodTemplate(w.template)
+}
+
+func (w *podTemplateWorkload) GetEnv() string {
+	return common.GetEnvFromPodTemplate(w.kind, w.meta, w.template)
+}
+
+func (w *podTemplateWorkload) GetPodTemplate() *k8sV1.PodTemplateSpec {
+	return w.template
+}
+
+func (w *podTemplateWorkload) GetSelector() *metaV1.LabelSelector {
+	return w.selector
+}
+
+func (w *podTemplateWorkload) GetLocalEndpoints(clusterID string, cname string, services map[string]*WeightedService) map[string]*LocalEndpoint {
+	return nil
+}
+
+// returns the service as the only service of a workload
+func singleWeightedService(service *k8sV1.Service) map[string]*WeightedService {
+	if service == nil {
+		return nil
+	}
+	return map[string]*WeightedService{service.Name: {Weight: 1, Service: service}}
+}
+
+type deploymentWorkload struct {
+	podTemplateWorkload
+	deployment *k8sAppsV1.Deployment
+}
+
+func newDeploymentWorkload(deployment *k8sAppsV1.Deployment) *deploymentWorkload {
+	return &deploymentWorkload{
+		podTemplateWorkload: podTemplateWorkload{kind: deploymentKind, meta: &deployment.ObjectMeta, template: &deployment.Spec.Template, selector: deployment.Spec.Selector},
+		deployment:          deployment,
+	}
+}
+
+func (w *deploymentWorkload) GetServices(rc *RemoteController) map[string]*WeightedService {
+	return singleWeightedService(getServiceForDeployment(rc, w.deployment))
+}
+
+type deploymentSource struct{}
+
+func (s *deploymentSource) Kind() string {
+	return deploymentKind
+}
+
+func (s *deploymentSource) Get(rc *RemoteController, identity string, env string) Workload {
+	if rc.DeploymentController == nil {
+		return nil
+	}
+	deployment := rc.DeploymentController.Cache.Get(identity, env)
+	if deployment == nil {
+		return nil
+	}
+	return newDeploymentWorkload(deployment)
+}
+
+func (s *deploymentSource) Range(rc *RemoteController, fn func(identity string, env string)) {
+	if rc.DeploymentController == nil {
+		return
+	}
+	rc.DeploymentController.Cache.Range(func(identity string, env string, deployment *k8sAppsV1.Deployment) {
+		fn(identity, env)
+	})
+}
+
+func (s *deploymentSource) HasSynced(rc *RemoteController) bool {
+	return rc.DeploymentController == nil || rc.DeploymentController.HasSynced()
+}
+
+func (s *deploymentSource) GetBySelectorInNamespace(rc *RemoteController, selector map[string]string, namespace string) []Workload {
+	if rc.DeploymentController == nil {
+		return nil
+	}
+	deployments := rc.DeploymentController.GetDeploymentBySelectorInNamespace(selector, namespace)
+	workloads := make([]Workload, 0, len(deployments))
+	for i := range deployments {
+		workloads = append(workloads, newDeploymentWorkload(&deployments[i]))
+	}
+	return workloads
+}
+
+type rolloutWorkload struct {
+	podTemplateWorkload
+	rollout *argo.Rollout
+}
+
+func newRolloutWorkload(rollout *argo.Rollout) *rolloutWorkload {
+	return &rolloutWorkload{
+		podTemplateWorkload: podTemplateWorkload{kind: rolloutKind, meta: &rollout.ObjectMeta, template: &rollout.Spec.Template, selector: rollout.Spec.Selector},
+		rollout:             rollout,
+	}
+}
+
+func (w *rolloutWorkload) GetServices(rc *RemoteController) map[string]*WeightedService {
+	return getServiceForRollout(rc, w.rollout)
+}
+
+// blue green rollouts point the cname to the active service and the preview host to the preview service
+func (w *rolloutWorkload) GetLocalEndpoints(clusterID string, cname string, services map[string]*WeightedService) map[string]*LocalEndpoint {
+	if !isBlueGreenStrategy(w.rollout) {
+		return nil
+	}
+	hosts := []string{cname}
+	if previewService := w.rollout.Spec.Strategy.BlueGreen.PreviewService; previewService != "" {
+		if _, ok := services[previewService]; ok {
+			hosts = append(hosts, common.BlueGreenRolloutPreviewPrefix+common.Sep+cname)
+		}
+	}
+	localEndpoints := make(map[string]*LocalEndpoint)
+	for _, host := range hosts {
+		ep := &networking.ServiceEntry_Endpoint{}
+		updateEndpointsForBlueGreen(w.rollout, services, map[string]string{}, ep, clusterID, host)
+		if len(ep.Address) > 0 {
+			localEndpoints[host] = &LocalEndpoint{Address: ep.Address, Ports: ep.Ports}
+		}
+	}
+	return localEndpoints
+}
+
+type rolloutSource struct{}
+
+func (s *rolloutSource) Kind() string {
+	return rolloutKind
+}
+
+func (s *rolloutSource) Get(rc *RemoteController, identity string, env string) Workload {
+	if rc.RolloutController == nil {
+		return nil
+	}
+	rollout := rc.RolloutController.Cache.Get(identity, env)
+	if rollout == nil {
+		return nil
+	}
+	return newRolloutWorkload(rollout)
+}
+
+func (s *rolloutSource) Range(rc *RemoteController, fn func(identity string, env string)) {
+	if rc.RolloutController == nil {
+		return
+	}
+	rc.RolloutController.Cache.Range(func(identity string, env string, rollout *argo.Rollout) {
+		fn(identity, env)
+	})
+}
+
+func (s *rolloutSource) HasSynced(rc *RemoteController) bool {
+	return rc.RolloutController == nil || rc.RolloutController.HasSynced()
+}
+
+func (s *rolloutSource) GetBySelectorInNamespace(rc *RemoteController, selector map[string]string, namespace string) []Workload {
+	if !common.GetAdmiralParams().ArgoRolloutsEnabled || rc.RolloutController == nil {
+		return nil
+	}
+	rollouts := rc.RolloutController.GetRolloutBySelectorInNamespace(selector, namespace)
+	workloads := make([]Workload, 0, len(rollouts))
+	for i := range rollouts {
+		workloads = append(workloads, newRolloutWorkload(&rollouts[i]))
+	}
+	return workloads
+}
+
+type statefulSetWorkload struct {
+	podTemplateWorkload
+	statefulSet *k8sAppsV1.StatefulSet
+}
+
+func newStatefulSetWorkload(statefulSet *k8sAppsV1.StatefulSet) *statefulSetWorkload {
+	return &statefulSetWorkload{
+		podTemplateWorkload: podTemplateWorkload{kind: statefulSetKind, meta: &statefulSet.ObjectMeta, template: &statefulSet.Spec.Template, selector: statefulSet.Spec.Selector},
+		statefulSet:         statefulSet,
+	}
+}
+
+func (w *statefulSetWorkload) GetServices(rc *RemoteController) map[string]*WeightedService {
+	return singleWeightedService(getServiceForStatefulSet(rc, w.statefulSet))
+}
+
+// the per pod hosts point to the pod dns names of the governing service
+func (w *statefulSetWorkload) GetLocalEndpoints(clusterID string, cname string, services map[string]*WeightedService) map[string]*LocalEndpoint {
+	var meshPorts map[string]uint32
+	for _, service := range services {
+		meshPorts = GetMeshPortsForWorkload(clusterID, service.Service, w)
+		break
+	}
+	localEndpoints := make(map[string]*LocalEndpoint)
+	for podHost, podFqdn := range getPodHostsForStatefulSet(w.statefulSet, cname) {
+		localEndpoints[podHost] = &LocalEndpoint{Address: podFqdn, Ports: meshPorts}
+	}
+	return localEndpoints
+}
+
+type statefulSetSource struct{}
+
+func (s *statefulSetSource) Kind() string {
+	return statefulSetKind
+}
+
+func (s *statefulSetSource) Get(rc *RemoteController, identity string, env string) Workload {
+	if rc.StatefulSetController == nil {
+		return nil
+	}
+	statefulSet := rc.StatefulSetController.Cache.Get(identity, env)
+	if statefulSet == nil {
+		return nil
+	}
+	return newStatefulSetWorkload(statefulSet)
+}
+
+func (s *statefulSetSource) Range(rc *RemoteController, fn func(identity string, env string)) {
+	if rc.StatefulSetController == nil {
+		return
+	}
+	rc.StatefulSetController.Cache.Range(func(identity string, env string, statefulSet *k8sAppsV1.StatefulSet) {
+		fn(identity, env)
+	})
+}
+
+func (s *statefulSetSource) HasSynced(rc *RemoteController) bool {
+	return rc.StatefulSetController == nil || rc.StatefulSetController.HasSynced()
+}
+
+func (s *statefulSetSource) GetBySelectorInNamespace(rc *RemoteController, selector map[string]string, namespace string) []Workload {
+	if !common.GetStatefulSetsEnabled() || rc.StatefulSetController == nil {
+		return nil
+	}
+	statefulSets := rc.StatefulSetController.GetStatefulSetBySelectorInNamespace(selector, namespace)
+	workloads := make([]Workload, 0, len(statefulSets))
+	for i := range statefulSets {
+		workloads = append(workloads, newStatefulSetWorkload(&statefulSets[i]))
+	}
+	return workloads
+}
@@ -0,0 +1,194 @@
+package clusters
+
+import (
+	"reflect"
+	"testing"
+
+	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeWorkloadSource struct {
+	kind     string
+	workload Workload
+}
+
+func (s *fakeWorkloadSource) Kind() string {
+	return s.kind
+}
+
+func (s *fakeWorkloadSource) Get(rc *RemoteController, identity string, env string) Workload {
+	return s.workload
+}
+
+func (s *fakeWorkloadSource) Range(rc *RemoteController, fn func(identity string, env string)) {
+	fn(s.workload.GetIdentity(), s.workload.GetEnv())
+}
+
+func (s *fakeWorkloadSource) HasSynced(rc *RemoteController) bool {
+	return true
+}
+
+func (s *fakeWorkloadSource) GetBySelectorInNamespace(rc *RemoteController, selector map[string]string, namespace string) []Workload {
+	return []Workload{s.workload}
+}
+
+func TestGetWorkload(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	template := coreV1.PodTemplateSpec{ObjectMeta: metaV1.ObjectMeta{Labels: map[string]string{"identity": "bar", "env": "e2e"}}}
+	deployment := &k8sAppsV1.Deployment{ObjectMeta: metaV1.ObjectMeta{Name: "bar", Namespace: "ns"}, Spec: k8sAppsV1.DeploymentSpec{Template: template}}
+	rollout := &argo.Rollout{ObjectMeta: metaV1.ObjectMeta{Name: "bar", Namespace: "ns"}, Spec: argo.RolloutSpec{Template: template}}
+
+	rcWithBoth := newReconcileTestRemoteController(t, "cluster1", stop)
+	rcWithBoth.DeploymentController.Cache.UpdateDeploymentToClusterCache("bar", deployment)
+	rcWithBoth.RolloutController.Cache.UpdateRolloutToClusterCache("bar", rollout)
+
+	rcWithRollout := newReconcileTestRemoteController(t, "cluster2", stop)
+	rcWithRollout.RolloutController.Cache.UpdateRolloutToClusterCache("bar", rollout)
+
+	testCases := []struct {
+		name         string
+		rc           *RemoteController
+		identity     string
+		expectedKind string
+	}{
+		{
+			name:         "should prefer the deployment when the identity has a deployment and a rollout",
+			rc:           rcWithBoth,
+			identity:     "bar",
+			expectedKind: deploymentKind,
+		},
+		{
+			name:         "should return the rollout when the identity only has a rollout",
+			rc:           rcWithRollout,
+			identity:     "bar",
+			expectedKind: rolloutKind,
+		},
+		{
+			name:     "should return nil for an unknown identity",
+			rc:       rcWithBoth,
+			identity: "foo",
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			workload := getWorkload(c.rc, c.identity, "e2e")
+			if len(c.expectedKind) == 0 {
+				if workload != nil {
+					t.Errorf("Wanted no workload, got %v", workload.GetKind())
+				}
+				return
+			}
+			if workload == nil || workload.GetKind() != c.expectedKind {
+				t.Fatalf("Wanted a %v workload, got %v", c.expectedKind, workload)
+			}
+			if workload.GetIdentity() != "bar" || workload.GetEnv() != "e2e" || workload.GetObjectMeta().Namespace != "ns" {
+				t.Errorf("Wanted identity bar, env e2e and namespace ns, got %v, %v and %v", workload.GetIdentity(), workload.GetEnv(), workload.GetObjectMeta().Namespace)
+			}
+		})
+	}
+}
+
+func TestRegisterWorkloadSource(t *testing.T) {
+	registered := GetWorkloadSources()
+	defer func() {
+		workloadSourcesMutex.Lock()
+		workloadSources = registered
+		workloadSourcesMutex.Unlock()
+	}()
+
+	for _, source := range []WorkloadSource{nil, &fakeWorkloadSource{kind: deploymentKind}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Wanted a panic registering %v", source)
+				}
+			}()
+			RegisterWorkloadSource(source)
+		}()
+	}
+
+	job := newDeploymentWorkload(&k8sAppsV1.Deployment{
+		ObjectMeta: metaV1.ObjectMeta{Name: "job"},
+		Spec:       k8sAppsV1.DeploymentSpec{Template: coreV1.PodTemplateSpec{ObjectMeta: metaV1.ObjectMeta{Labels: map[string]string{"identity": "job"}}}},
+	})
+	RegisterWorkloadSource(&fakeWorkloadSource{kind: "job", workload: job})
+
+	//the built in sources have nothing for a cluster without controllers, so the custom source is used
+	if workload := getWorkload(&RemoteController{}, "job", "default"); workload != job {
+		t.Errorf("Wanted the workload of the custom source, got %v", workload)
+	}
+	identityEnvs := make([]identityEnv, 0)
+	GetWorkloadSources()[len(registered)].Range(&RemoteController{}, func(identity string, env string) {
+		identityEnvs = append(identityEnvs, identityEnv{identity: identity, env: env})
+	})
+	if !reflect.DeepEqual(identityEnvs, []identityEnv{{identity: "job", env: "default"}}) {
+		t.Errorf("Wanted the identity and env of the custom source, got %v", identityEnvs)
+	}
+}
+
+func TestRolloutWorkloadGetLocalEndpoints(t *testing.T) {
+	activeService := &coreV1.Service{
+		ObjectMeta: metaV1.ObjectMeta{Name: "active", Namespace: "ns"},
+		Spec:       coreV1.ServiceSpec{Ports: []coreV1.ServicePort{{Name: "http", Port: 8080}}},
+	}
+	previewService := &coreV1.Service{
+		ObjectMeta: metaV1.ObjectMeta{Name: "preview", Namespace: "ns"},
+		Spec:       coreV1.ServiceSpec{Ports: []coreV1.ServicePort{{Name: "http", Port: 8081}}},
+	}
+	blueGreenRollout := &argo.Rollout{Spec: argo.RolloutSpec{Strategy: argo.RolloutStrategy{
+		BlueGreen: &argo.BlueGreenStrategy{ActiveService: "active", PreviewService: "preview"},
+	}}}
+	canaryRollout := &argo.Rollout{Spec: argo.RolloutSpec{Strategy: argo.RolloutStrategy{
+		Canary: &argo.CanaryStrategy{StableService: "active", CanaryService: "preview"},
+	}}}
+
+	testCases := []struct {
+		name     string
+		rollout  *argo.Rollout
+		services map[string]*WeightedService
+		expected map[string]*LocalEndpoint
+	}{
+		{
+			name:     "should point the cname to the active service and the preview host to the preview service",
+			rollout:  blueGreenRollout,
+			services: map[string]*WeightedService{"active": {Service: activeService}, "preview": {Service: previewService}},
+			expected: map[string]*LocalEndpoint{
+				"e2e.bar.mesh":         {Address: "active.ns.svc.cluster.local", Ports: map[string]uint32{"http": 8080}},
+				"preview.e2e.bar.mesh": {Address: "preview.ns.svc.cluster.local", Ports: map[string]uint32{"http": 8081}},
+			},
+		},
+		{
+			name:     "should skip the preview host when the preview service is missing",
+			rollout:  blueGreenRollout,
+			services: map[string]*WeightedService{"active": {Service: activeService}},
+			expected: map[string]*LocalEndpoint{
+				"e2e.bar.mesh": {Address: "active.ns.svc.cluster.local", Ports: map[string]uint32{"http": 8080}},
+			},
+		},
+		{
+			name:     "should return nothing for canary rollouts",
+			rollout:  canaryRollout,
+			services: map[string]*WeightedService{"active": {Service: activeService, Weight: 90}, "preview": {Service: previewService, Weight: 10}},
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			localEndpoints := newRolloutWorkload(c.rollout).GetLocalEndpoints("cluster1", "e2e.bar.mesh", c.services)
+			if len(localEndpoints) != len(c.expected) {
+				t.Fatalf("Wanted %v, got %v", c.expected, localEndpoints)
+			}
+			for host, expected := range c.expected {
+				if !reflect.DeepEqual(localEndpoints[host], expected) {
+					t.Errorf("Wanted %v for host %v, got %v", expected, host, localEndpoints[host])
+				}
+			}
+		})
+	}
+}
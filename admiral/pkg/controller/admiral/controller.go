@@ -9,6 +9,11 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 
+	k8sV1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sV1Informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 )
@@ -176,3 +181,25 @@ func (s *MonitoredDelegator) Deleted(obj interface{}) {
 	common.EventsProcessed.With(s.clusterID, s.objectType, common.DeleteEventLabelValue).Inc()
 	s.d.Deleted(obj)
 }
+
+//starts an informer of the namespaces of the cluster, the workload controllers read the admiral.io/ignore annotation of the namespaces from it
+func newNamespaceInformer(client kubernetes.Interface, stopCh <-chan struct{}, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	informer := k8sV1Informers.NewNamespaceInformer(client, resyncPeriod, cache.Indexers{})
+	go informer.Run(stopCh)
+	return informer
+}
+
+//returns the namespace from the informer cache, the API server is queried until the cache is synced. The namespace must not be modified
+func getNamespace(informer cache.SharedIndexInformer, client kubernetes.Interface, name string) (*k8sV1.Namespace, error) {
+	if informer == nil || !informer.HasSynced() {
+		return client.CoreV1().Namespaces().Get(name, meta_v1.GetOptions{})
+	}
+	obj, exists, err := informer.GetStore().GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, k8sErrors.NewNotFound(k8sV1.Resource("namespaces"), name)
+	}
+	return obj.(*k8sV1.Namespace), nil
+}
@@ -3,6 +3,12 @@ package admiral
 import (
 	"github.com/stretchr/testify/assert"
 	"testing"
+
+	k8sV1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
 )
 
 func TestMonitoredDelegator_Added(t *testing.T) {
@@ -52,3 +58,26 @@ func (t *TestDelegator) Updated(obj interface{}, oldObj interface{}) {
 func (t *TestDelegator) Deleted(obj interface{}) {
 	t.DeleteInvoked = true
 }
+
+func TestGetNamespace(t *testing.T) {
+	client := fake.NewSimpleClientset(&k8sV1.Namespace{ObjectMeta: metaV1.ObjectMeta{Name: "ns1"}})
+
+	//without an informer the API server is queried
+	ns, err := getNamespace(nil, client, "ns1")
+	assert.Nil(t, err)
+	assert.Equal(t, "ns1", ns.Name)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	informer := newNamespaceInformer(client, stop, 0)
+	if !cache.WaitForCacheSync(stop, informer.HasSynced) {
+		t.Fatalf("Timed out waiting for the informer to sync")
+	}
+	informer.GetStore().Add(&k8sV1.Namespace{ObjectMeta: metaV1.ObjectMeta{Name: "ns2"}})
+
+	ns, err = getNamespace(informer, client, "ns2")
+	assert.Nil(t, err)
+	assert.Equal(t, "ns2", ns.Name)
+	_, err = getNamespace(informer, client, "ns3")
+	assert.True(t, k8sErrors.IsNotFound(err))
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
+	"reflect"
 	"sync"
 	"time"
 
@@ -15,7 +16,8 @@ import (
 // Handler interface contains the methods that are required
 type DepHandler interface {
 	Added(obj *v1.Dependency)
-	Updated(obj *v1.Dependency)
+	// Updated gets the previous record too, nil if it's not known
+	Updated(obj *v1.Dependency, oldObj *v1.Dependency)
 	Deleted(obj *v1.Dependency)
 }
 
@@ -56,6 +58,15 @@ func (d *depCache) Delete(dep *v1.Dependency) {
 	delete(d.cache, d.getKey(dep))
 }
 
+// Range calls fn for every dependency record in the cache, fn should not call back into the cache
+func (d *depCache) Range(fn func(dep *v1.Dependency)) {
+	defer d.mutex.Unlock()
+	d.mutex.Lock()
+	for _, dep := range d.cache {
+		fn(dep)
+	}
+}
+
 func NewDependencyController(stopCh <-chan struct{}, handler DepHandler, configPath string, namespace string, resyncPeriod time.Duration) (*DependencyController, error) {
 
 	depController := DependencyController{}
@@ -101,7 +112,17 @@ func (d *DependencyController) Added(ojb interface{}) {
 func (d *DependencyController) Updated(obj interface{}, oldObj interface{}) {
 	dep := obj.(*v1.Dependency)
 	d.Cache.Put(dep)
-	d.DepHandler.Updated(dep)
+	old, _ := oldObj.(*v1.Dependency)
+	if old != nil && isDependencyStatusOnlyUpdate(dep, old) {
+		//the status written by Admiral after a reconcile doesn't change the dependencies
+		return
+	}
+	d.DepHandler.Updated(dep, old)
+}
+
+// returns true once the informer has listed all the dependency records, the cache is partial until then
+func (d *DependencyController) HasSynced() bool {
+	return d.informer != nil && d.informer.HasSynced()
 }
 
 func (d *DependencyController) Deleted(ojb interface{}) {
@@ -109,3 +130,8 @@ func (d *DependencyController) Deleted(ojb interface{}) {
 	d.Cache.Delete(dep)
 	d.DepHandler.Deleted(dep)
 }
+
+func isDependencyStatusOnlyUpdate(dep *v1.Dependency, old *v1.Dependency) bool {
+	return !reflect.DeepEqual(dep.Status, old.Status) && reflect.DeepEqual(dep.Spec, old.Spec) &&
+		reflect.DeepEqual(dep.Labels, old.Labels) && reflect.DeepEqual(dep.Annotations, old.Annotations)
+}
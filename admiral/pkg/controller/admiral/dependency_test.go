@@ -62,6 +62,14 @@ func TestDependencyAddUpdateAndDelete(t *testing.T) {
 		t.Errorf("dep update failed, expected: %v got %v", updatedObj, updatedDepObj)
 	}
 
+	var rangedDeps []*v1.Dependency
+	dependencyController.Cache.Range(func(dep *v1.Dependency) {
+		rangedDeps = append(rangedDeps, dep)
+	})
+	if len(rangedDeps) != 1 || !cmp.Equal(updatedObj.Spec, rangedDeps[0].Spec) {
+		t.Errorf("dep range failed, expected: %v got %v", updatedObj, rangedDeps)
+	}
+
 	//test delete
 	dependencyController.Deleted(updatedDepObj)
 
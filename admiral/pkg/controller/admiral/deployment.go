@@ -32,6 +32,7 @@ type DeploymentController struct {
 	DeploymentHandler DeploymentHandler
 	Cache             *deploymentCache
 	informer          cache.SharedIndexInformer
+	namespaceInformer cache.SharedIndexInformer
 	labelSet          *common.LabelSet
 }
 
@@ -56,6 +57,17 @@ func (p *deploymentCache) Get(key string, env string) *k8sAppsV1.Deployment {
 	}
 }
 
+// Range calls fn for every identity and env in the cache, fn should not call back into the cache
+func (p *deploymentCache) Range(fn func(identity string, env string, deployment *k8sAppsV1.Deployment)) {
+	defer p.mutex.Unlock()
+	p.mutex.Lock()
+	for identity, dce := range p.cache {
+		for env, deployment := range dce.Deployments {
+			fn(identity, env, deployment)
+		}
+	}
+}
+
 func (p *deploymentCache) UpdateDeploymentToClusterCache(key string, deployment *k8sAppsV1.Deployment) {
 	defer p.mutex.Unlock()
 	p.mutex.Lock()
@@ -112,6 +124,8 @@ func NewDeploymentController(clusterID string, stopCh <-chan struct{}, handler D
 		cache.Indexers{},
 	)
 
+	deploymentController.namespaceInformer = newNamespaceInformer(deploymentController.K8sClient, stopCh, resyncPeriod)
+
 	wc := NewMonitoredDelegator(&deploymentController, clusterID, "deployment")
 	NewController("deployment-ctrl-"+config.Host, stopCh, wc, deploymentController.informer)
 
@@ -137,9 +151,17 @@ func HandleAddUpdateDeployment(ojb interface{}, d *DeploymentController) {
 			d.Cache.DeleteFromDeploymentClusterCache(key, deployment)
 			log.Debugf("ignoring deployment %v based on labels", deployment.Name)
 		}
+	} else if !d.shouldIgnoreBasedOnLabels(deployment) {
+		//passed on without an identity so the handler can report it
+		d.DeploymentHandler.Added(deployment)
 	}
 }
 
+// returns true once the informer has listed all the deployments, the cache is partial until then
+func (d *DeploymentController) HasSynced() bool {
+	return d.informer != nil && d.informer.HasSynced()
+}
+
 func (d *DeploymentController) Deleted(ojb interface{}) {
 	deployment := ojb.(*k8sAppsV1.Deployment)
 	key := d.Cache.getKey(deployment)
@@ -162,7 +184,7 @@ func (d *DeploymentController) shouldIgnoreBasedOnLabels(deployment *k8sAppsV1.D
 		return true
 	}
 
-	ns, err := d.K8sClient.CoreV1().Namespaces().Get(deployment.Namespace, meta_v1.GetOptions{})
+	ns, err := getNamespace(d.namespaceInformer, d.K8sClient, deployment.Namespace)
 	if err != nil {
 		log.Warnf("Failed to get namespace object for deployment with namespace %v, err: %v", deployment.Namespace, err)
 		return false
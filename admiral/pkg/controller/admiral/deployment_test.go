@@ -120,6 +120,16 @@ func TestDeploymentController_Added(t *testing.T) {
 			}
 		})
 	}
+
+	//a sidecar injected deployment without an identity isn't cached, the handler reports it
+	deploymentWithoutIdentity := k8sAppsV1.Deployment{}
+	deploymentWithoutIdentity.Spec.Template.Annotations = map[string]string{"sidecar.istio.io/inject": "true"}
+	depController.Cache.cache = map[string]*DeploymentClusterEntry{}
+	mdh.Obj = nil
+	depController.Added(&deploymentWithoutIdentity)
+	if len(depController.Cache.cache) != 0 || mdh.Obj != &deploymentWithoutIdentity {
+		t.Errorf("Expected the deployment without an identity to be passed to the handler without being cached")
+	}
 }
 
 func TestDeploymentController_Deleted(t *testing.T) {
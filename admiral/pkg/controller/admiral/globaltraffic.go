@@ -8,6 +8,7 @@ import (
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
+	"reflect"
 	"sync"
 	"time"
 
@@ -136,6 +137,10 @@ func (d *GlobalTrafficController) Added(ojb interface{}) {
 func (d *GlobalTrafficController) Updated(ojb interface{}, oldObj interface{}) {
 	gtp := ojb.(*v1.GlobalTrafficPolicy)
 	d.Cache.Put(gtp)
+	if old, ok := oldObj.(*v1.GlobalTrafficPolicy); ok && isGtpStatusOnlyUpdate(gtp, old) {
+		//the status written by Admiral after a reconcile doesn't change the generated config
+		return
+	}
 	d.GlobalTrafficHandler.Updated(gtp)
 }
 
@@ -144,3 +149,8 @@ func (d *GlobalTrafficController) Deleted(ojb interface{}) {
 	d.Cache.Delete(gtp)
 	d.GlobalTrafficHandler.Deleted(gtp)
 }
+
+func isGtpStatusOnlyUpdate(gtp *v1.GlobalTrafficPolicy, old *v1.GlobalTrafficPolicy) bool {
+	return !reflect.DeepEqual(gtp.Status, old.Status) && reflect.DeepEqual(gtp.Spec, old.Spec) &&
+		reflect.DeepEqual(gtp.Labels, old.Labels) && reflect.DeepEqual(gtp.Annotations, old.Annotations)
+}
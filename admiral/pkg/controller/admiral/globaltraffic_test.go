@@ -71,6 +71,15 @@ func TestGlobalTrafficAddUpdateDelete(t *testing.T) {
 		t.Errorf("Update should call the handler with the updated object")
 	}
 
+	statusUpdatedGtpObj := updatedGtpObj.DeepCopy()
+	statusUpdatedGtpObj.Status.State = "Synced"
+	handler.Obj = nil
+	globalTrafficController.Updated(statusUpdatedGtpObj, updatedGtpObj)
+
+	if handler.Obj != nil {
+		t.Errorf("Update of the status only should not call the handler")
+	}
+
 	globalTrafficController.Deleted(updatedGtpObj)
 
 	if handler.Obj != nil {
@@ -2,7 +2,12 @@ package admiral
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
 	k8sV1Informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/rest"
 
@@ -13,6 +18,8 @@ import (
 
 // Handler interface contains the methods that are required
 type NodeHandler interface {
+	//called when the locality of the cluster changes, previous is empty the first time the locality is known
+	LocalityChanged(previous string, current string)
 }
 
 type NodeController struct {
@@ -20,16 +27,37 @@ type NodeController struct {
 	NodeHandler NodeHandler
 	Locality    *Locality
 	informer    cache.SharedIndexInformer
+	clusterID   string
+	//key=node name value=locality of the node
+	nodeLocalities map[string]Locality
+	mutex          sync.Mutex
 }
 
 type Locality struct {
-	Region string
+	Region  string
+	Zone    string
+	Subzone string
+}
+
+//returns the locality in the istio format region/zone/subzone
+func (l *Locality) String() string {
+	return common.GetLocality(l.Region, l.Zone, l.Subzone)
+}
+
+//parses a locality in the istio format region/zone/subzone
+func ParseLocality(locality string) *Locality {
+	parts := strings.SplitN(locality, common.Slash, 3)
+	for len(parts) < 3 {
+		parts = append(parts, "")
+	}
+	return &Locality{Region: parts[0], Zone: parts[1], Subzone: parts[2]}
 }
 
 func NewNodeController(clusterID string, stopCh <-chan struct{}, handler NodeHandler, config *rest.Config) (*NodeController, error) {
 
 	nodeController := NodeController{}
 	nodeController.NodeHandler = handler
+	nodeController.clusterID = clusterID
 
 	var err error
 
@@ -51,16 +79,151 @@ func NewNodeController(clusterID string, stopCh <-chan struct{}, handler NodeHan
 }
 
 func (p *NodeController) Added(obj interface{}) {
-	node := obj.(*k8sV1.Node)
-	if p.Locality == nil {
-		p.Locality = &Locality{Region: common.GetNodeLocality(node)}
+	node, ok := obj.(*k8sV1.Node)
+	if !ok {
+		return
 	}
+	p.updateLocality(func(nodeLocalities map[string]Locality) {
+		nodeLocalities[node.Name] = Locality{Region: common.GetNodeRegion(node), Zone: common.GetNodeZone(node), Subzone: common.GetNodeSubzone(node)}
+	})
 }
 
 func (p *NodeController) Updated(obj interface{}, oldObj interface{}) {
-	//ignore
+	//the locality labels of a node can be fixed after it joined the cluster
+	p.Added(obj)
 }
 
 func (p *NodeController) Deleted(obj interface{}) {
-	//ignore
+	var name string
+	switch node := obj.(type) {
+	case *k8sV1.Node:
+		name = node.Name
+	case cache.DeletedFinalStateUnknown:
+		name = node.Key
+	default:
+		return
+	}
+	p.updateLocality(func(nodeLocalities map[string]Locality) {
+		delete(nodeLocalities, name)
+	})
+}
+
+//returns a copy of the locality of the cluster, nil until it's known
+func (p *NodeController) GetLocality() *Locality {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.Locality == nil {
+		return nil
+	}
+	locality := *p.Locality
+	return &locality
+}
+
+/*
+updateLocality applies fn to the localities of the nodes and sets Locality to the locality most of the nodes are in,
+or to the --cluster_locality_overrides value of the cluster when there is one.
+The handler is notified when the locality of the cluster changes.
+*/
+func (p *NodeController) updateLocality(fn func(nodeLocalities map[string]Locality)) {
+	p.mutex.Lock()
+	if p.nodeLocalities == nil {
+		p.nodeLocalities = make(map[string]Locality)
+	}
+	fn(p.nodeLocalities)
+
+	previous := p.Locality
+	var current *Locality
+	if override := common.GetClusterLocalityOverride(p.clusterID); len(override) > 0 {
+		current = ParseLocality(override)
+	} else {
+		current = getDominantLocality(p.nodeLocalities, previous)
+	}
+	changed := current != nil && (previous == nil || *previous != *current)
+	if changed {
+		p.Locality = current
+	}
+	p.mutex.Unlock()
+
+	if !changed {
+		return
+	}
+	var previousLocality string
+	if previous != nil {
+		previousLocality = previous.String()
+	}
+	log.Infof("op=%s type=%v name=%v cluster=%s message=%s", "Update", "locality", current.String(), p.clusterID, "Locality changed from="+previousLocality)
+	if p.NodeHandler != nil {
+		p.NodeHandler.LocalityChanged(previousLocality, current.String())
+	}
+}
+
+/*
+getDominantLocality returns the region with the most nodes, then the zone with the most nodes of that region, then the subzone with the most nodes of that zone.
+Nodes without a region are ignored, as are nodes without a zone (subzone) when picking the zone (subzone).
+The current region, zone and subzone are kept until another one has strictly more nodes, so nodes joining and leaving in turn don't flip the locality.
+*/
+func getDominantLocality(nodeLocalities map[string]Locality, current *Locality) *Locality {
+	if current == nil {
+		current = &Locality{}
+	}
+	regions := make(map[string]int)
+	for _, locality := range nodeLocalities {
+		if len(locality.Region) > 0 {
+			regions[locality.Region]++
+		}
+	}
+	if len(regions) == 0 {
+		if len(current.Region) == 0 {
+			return nil
+		}
+		return current
+	}
+	dominant := Locality{Region: getDominant(regions, current.Region)}
+
+	zones := make(map[string]int)
+	for _, locality := range nodeLocalities {
+		if locality.Region == dominant.Region && len(locality.Zone) > 0 {
+			zones[locality.Zone]++
+		}
+	}
+	currentZone := ""
+	if current.Region == dominant.Region {
+		currentZone = current.Zone
+	}
+	dominant.Zone = getDominant(zones, currentZone)
+
+	subzones := make(map[string]int)
+	for _, locality := range nodeLocalities {
+		if locality.Region == dominant.Region && locality.Zone == dominant.Zone && len(locality.Subzone) > 0 {
+			subzones[locality.Subzone]++
+		}
+	}
+	currentSubzone := ""
+	if current.Region == dominant.Region && current.Zone == dominant.Zone {
+		currentSubzone = current.Subzone
+	}
+	dominant.Subzone = getDominant(subzones, currentSubzone)
+	return &dominant
+}
+
+//returns the key with the highest count, current when no key has a higher count than it, and the first key in order on a tie otherwise
+func getDominant(counts map[string]int, current string) string {
+	max := 0
+	dominant := make([]string, 0)
+	for key, count := range counts {
+		if count > max {
+			max = count
+			dominant = []string{key}
+		} else if count == max {
+			dominant = append(dominant, key)
+		}
+	}
+	if count, ok := counts[current]; ok && count == max {
+		return current
+	}
+	if len(dominant) == 0 {
+		return ""
+	}
+	sort.Strings(dominant)
+	return dominant[0]
 }
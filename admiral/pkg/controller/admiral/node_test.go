@@ -6,6 +6,7 @@ import (
 	k8sV1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/clientcmd"
+	"reflect"
 	"testing"
 )
 
@@ -68,3 +69,111 @@ func TestNodeAddUpdateDelete(t *testing.T) {
 		t.Errorf("region expected %v, got: %v", region, locality.Region)
 	}
 }
+
+func TestNodeAddedZoneLocality(t *testing.T) {
+	nodeController := &NodeController{}
+	nodeObj := &k8sV1.Node{Spec: k8sV1.NodeSpec{}, ObjectMeta: v1.ObjectMeta{Labels: map[string]string{
+		common.NodeTopologyRegionLabel: "us-west-2", common.NodeTopologyZoneLabel: "us-west-2a", common.NodeSubzoneLabel: "rack1"}}}
+
+	nodeController.Added(nodeObj)
+
+	expected := Locality{Region: "us-west-2", Zone: "us-west-2a", Subzone: "rack1"}
+	if nodeController.Locality == nil || *nodeController.Locality != expected {
+		t.Fatalf("locality expected %v, got: %v", expected, nodeController.Locality)
+	}
+	if nodeController.Locality.String() != "us-west-2/us-west-2a/rack1" {
+		t.Errorf("locality string expected us-west-2/us-west-2a/rack1, got: %v", nodeController.Locality.String())
+	}
+}
+
+func TestNodeDominantLocality(t *testing.T) {
+	handler := &test.MockNodeHandler{}
+	nodeController := &NodeController{NodeHandler: handler}
+	getNode := func(name string, region string, zone string) *k8sV1.Node {
+		return &k8sV1.Node{ObjectMeta: v1.ObjectMeta{Name: name, Labels: map[string]string{common.NodeTopologyRegionLabel: region, common.NodeTopologyZoneLabel: zone}}}
+	}
+
+	//the first node is mislabeled
+	nodeController.Added(getNode("node1", "us-east-2", "us-east-2a"))
+	nodeController.Added(getNode("node2", "us-west-2", "us-west-2a"))
+	nodeController.Added(getNode("node3", "us-west-2", "us-west-2a"))
+	//nodes without a region are ignored
+	nodeController.Added(getNode("node4", "", ""))
+	nodeController.Added(getNode("node5", "", ""))
+	if nodeController.Locality.String() != "us-west-2/us-west-2a" {
+		t.Errorf("locality expected us-west-2/us-west-2a, got: %v", nodeController.Locality)
+	}
+
+	//a tie keeps the current locality
+	nodeController.Added(getNode("node6", "us-east-2", "us-east-2a"))
+	if nodeController.Locality.String() != "us-west-2/us-west-2a" {
+		t.Errorf("locality expected us-west-2/us-west-2a, got: %v", nodeController.Locality)
+	}
+
+	nodeController.Updated(getNode("node3", "us-east-2", "us-east-2a"), nil)
+	nodeController.Deleted(getNode("node6", "", ""))
+	if nodeController.Locality.String() != "us-east-2/us-east-2a" {
+		t.Errorf("locality expected us-east-2/us-east-2a, got: %v", nodeController.Locality)
+	}
+
+	expected := []string{"us-east-2/us-east-2a", "us-west-2/us-west-2a", "us-east-2/us-east-2a"}
+	if !reflect.DeepEqual(handler.Localities, expected) {
+		t.Errorf("locality changes expected %v, got: %v", expected, handler.Localities)
+	}
+}
+
+func TestNodeDominantRegionBeforeZone(t *testing.T) {
+	nodeController := &NodeController{}
+	getNode := func(name string, region string, zone string) *k8sV1.Node {
+		return &k8sV1.Node{ObjectMeta: v1.ObjectMeta{Name: name, Labels: map[string]string{common.NodeTopologyRegionLabel: region, common.NodeTopologyZoneLabel: zone}}}
+	}
+
+	//us-west-2 has the most nodes, spread over its zones
+	nodeController.Added(getNode("node1", "us-east-2", "us-east-2a"))
+	nodeController.Added(getNode("node2", "us-east-2", "us-east-2a"))
+	nodeController.Added(getNode("node3", "us-west-2", "us-west-2b"))
+	nodeController.Added(getNode("node4", "us-west-2", "us-west-2a"))
+	nodeController.Added(getNode("node5", "us-west-2", "us-west-2c"))
+	if locality := nodeController.GetLocality(); locality.String() != "us-west-2/us-west-2a" {
+		t.Errorf("locality expected us-west-2/us-west-2a, got: %v", locality)
+	}
+
+	//a zone with more nodes replaces the current one, a zone with as many nodes doesn't
+	nodeController.Added(getNode("node6", "us-west-2", "us-west-2b"))
+	if locality := nodeController.GetLocality(); locality.String() != "us-west-2/us-west-2b" {
+		t.Errorf("locality expected us-west-2/us-west-2b, got: %v", locality)
+	}
+	nodeController.Added(getNode("node7", "us-west-2", "us-west-2a"))
+	if locality := nodeController.GetLocality(); locality.String() != "us-west-2/us-west-2b" {
+		t.Errorf("locality expected us-west-2/us-west-2b, got: %v", locality)
+	}
+}
+
+func TestNodeLocalityOverride(t *testing.T) {
+	common.SetClusterLocalityOverrides(map[string]string{"cluster1": "us-east-2/us-east-2b"})
+	defer common.SetClusterLocalityOverrides(nil)
+
+	nodeController := &NodeController{clusterID: "cluster1"}
+	nodeController.Added(&k8sV1.Node{ObjectMeta: v1.ObjectMeta{Name: "node1", Labels: map[string]string{common.NodeTopologyRegionLabel: "us-west-2"}}})
+
+	expected := Locality{Region: "us-east-2", Zone: "us-east-2b"}
+	if nodeController.Locality == nil || *nodeController.Locality != expected {
+		t.Errorf("locality expected %v, got: %v", expected, nodeController.Locality)
+	}
+}
+
+func TestParseLocality(t *testing.T) {
+	testCases := []struct {
+		locality string
+		expected Locality
+	}{
+		{locality: "us-west-2", expected: Locality{Region: "us-west-2"}},
+		{locality: "us-west-2/us-west-2a", expected: Locality{Region: "us-west-2", Zone: "us-west-2a"}},
+		{locality: "us-west-2/us-west-2a/rack1", expected: Locality{Region: "us-west-2", Zone: "us-west-2a", Subzone: "rack1"}},
+	}
+	for _, c := range testCases {
+		if locality := ParseLocality(c.locality); *locality != c.expected || locality.String() != c.locality {
+			t.Errorf("locality expected %v, got: %v", c.expected, locality)
+		}
+	}
+}
@@ -36,12 +36,13 @@ type RolloutClusterEntry struct {
 }
 
 type RolloutController struct {
-	K8sClient      kubernetes.Interface
-	RolloutClient  argoprojv1alpha1.ArgoprojV1alpha1Interface
-	RolloutHandler RolloutHandler
-	informer       cache.SharedIndexInformer
-	Cache          *rolloutCache
-	labelSet       *common.LabelSet
+	K8sClient         kubernetes.Interface
+	RolloutClient     argoprojv1alpha1.ArgoprojV1alpha1Interface
+	RolloutHandler    RolloutHandler
+	informer          cache.SharedIndexInformer
+	namespaceInformer cache.SharedIndexInformer
+	Cache             *rolloutCache
+	labelSet          *common.LabelSet
 }
 
 type rolloutCache struct {
@@ -72,6 +73,17 @@ func (p *rolloutCache) Get(key string, env string) *argo.Rollout {
 	}
 }
 
+// Range calls fn for every identity and env in the cache, fn should not call back into the cache
+func (p *rolloutCache) Range(fn func(identity string, env string, rollout *argo.Rollout)) {
+	defer p.mutex.Unlock()
+	p.mutex.Lock()
+	for identity, rce := range p.cache {
+		for env, rollout := range rce.Rollouts {
+			fn(identity, env, rollout)
+		}
+	}
+}
+
 func (p *rolloutCache) Delete(pod *RolloutClusterEntry) {
 	defer p.mutex.Unlock()
 	p.mutex.Lock()
@@ -122,7 +134,7 @@ func (d *RolloutController) shouldIgnoreBasedOnLabelsForRollout(rollout *argo.Ro
 		return true
 	}
 
-	ns, err := d.K8sClient.CoreV1().Namespaces().Get(rollout.Namespace, meta_v1.GetOptions{})
+	ns, err := getNamespace(d.namespaceInformer, d.K8sClient, rollout.Namespace)
 	if err != nil {
 		log.Warnf("Failed to get namespace object for rollout with namespace %v, err: %v", rollout.Namespace, err)
 		return false
@@ -167,6 +179,8 @@ func NewRolloutsController(clusterID string, stopCh <-chan struct{}, handler Rol
 	//Initialize informer
 	roController.informer = argoRolloutsInformerFactory.Argoproj().V1alpha1().Rollouts().Informer()
 
+	roController.namespaceInformer = newNamespaceInformer(roController.K8sClient, stopCh, resyncPeriod)
+
 	mcd := NewMonitoredDelegator(&roController, clusterID, "rollout")
 	NewController("rollouts-ctrl-"+clusterID, stopCh, mcd, roController.informer)
 	return &roController, nil
@@ -191,9 +205,17 @@ func HandleAddUpdateRollout(ojb interface{}, roc *RolloutController) {
 			roc.Cache.DeleteFromRolloutToClusterCache(key, rollout)
 			log.Debugf("ignoring rollout %v based on labels", rollout.Name)
 		}
+	} else if !roc.shouldIgnoreBasedOnLabelsForRollout(rollout) {
+		//passed on without an identity so the handler can report it
+		roc.RolloutHandler.Added(rollout)
 	}
 }
 
+// returns true once the informer has listed all the rollouts, the cache is partial until then
+func (roc *RolloutController) HasSynced() bool {
+	return roc.informer != nil && roc.informer.HasSynced()
+}
+
 func (roc *RolloutController) Deleted(ojb interface{}) {
 	rollout := ojb.(*argo.Rollout)
 	key := roc.Cache.getKey(rollout)
@@ -3,18 +3,25 @@ package admiral
 import (
 	"fmt"
 	"github.com/prometheus/common/log"
+	"net"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 
 	"sync"
 
 	k8sV1 "k8s.io/api/core/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8sV1Informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 )
@@ -36,6 +43,17 @@ type ServiceController struct {
 	ServiceHandler ServiceHandler
 	Cache          *serviceCache
 	informer       cache.SharedIndexInformer
+	//watches the istio-system namespace, which carries the network of the cluster
+	namespaceInformer cache.SharedIndexInformer
+}
+
+//an address of an east west gateway, with the network, locality and weight of the endpoints pointing to it
+type LoadBalancer struct {
+	Address  string
+	Port     int
+	Network  string
+	Locality string
+	Weight   uint32
 }
 
 type serviceCache struct {
@@ -115,36 +133,99 @@ func (s *serviceCache) Delete(service *k8sV1.Service) {
 	}
 }
 
-func (s *serviceCache) GetLoadBalancer(key string, namespace string) (string, int) {
-	var (
-		lb     = "dummy.admiral.global"
-		lbPort = common.DefaultMtlsPort
-	)
-	services := s.Get(namespace)
-	if len(services) == 0 {
-		return lb, 0
-	}
-	for _, service := range services {
-		if service.Labels["app"] == key {
-			loadBalancerStatus := service.Status.LoadBalancer.Ingress
-			if len(loadBalancerStatus) > 0 {
-				if len(loadBalancerStatus[0].Hostname) > 0 {
-					return loadBalancerStatus[0].Hostname, common.DefaultMtlsPort
-				} else {
-					return loadBalancerStatus[0].IP, common.DefaultMtlsPort
-				}
-			} else if len(service.Spec.ExternalIPs) > 0 {
-				lb = service.Spec.ExternalIPs[0]
-				for _, port := range service.Spec.Ports {
-					if port.Port == common.DefaultMtlsPort {
-						lbPort = int(port.NodePort)
-						return lb, lbPort
-					}
-				}
+/*
+GetLoadBalancers returns every address of the services matching the selector in the namespace, sorted by address.
+The addresses come from the admiral.io/gateway-addresses annotation of the service when it has one, otherwise from its load balancer status or its external IPs.
+The network, locality and weight of an address come from the topology.istio.io/network label, and the admiral.io/locality
+and admiral.io/weight annotations of its service, they are left empty when the service doesn't have them.
+Nothing is returned for the services that don't have an address yet.
+*/
+func (s *serviceCache) GetLoadBalancers(selector labels.Selector, namespace string) []LoadBalancer {
+	loadBalancers := make([]LoadBalancer, 0)
+	for _, service := range s.Get(namespace) {
+		if !selector.Matches(labels.Set(service.Labels)) {
+			continue
+		}
+		var weight uint64
+		if value, ok := service.Annotations[common.GatewayWeightAnnotation]; ok {
+			var err error
+			if weight, err = strconv.ParseUint(value, 10, 32); err != nil {
+				log.Errorf("Ignoring invalid weight=%s of gateway name=%s namespace=%s err=%v", value, service.Name, service.Namespace, err)
+			}
+		}
+		for _, address := range getServiceAddresses(service) {
+			address.Network = service.Labels[common.NetworkLabel]
+			address.Locality = service.Annotations[common.GatewayLocalityAnnotation]
+			address.Weight = uint32(weight)
+			loadBalancers = append(loadBalancers, address)
+		}
+	}
+	sort.Slice(loadBalancers, func(i, j int) bool {
+		return loadBalancers[i].Address < loadBalancers[j].Address
+	})
+	return loadBalancers
+}
+
+//returns the addresses of a gateway service, with the mtls port of the gateway
+func getServiceAddresses(service *k8sV1.Service) []LoadBalancer {
+	//the mtls port of the nodes, for the services that are reached through their node port
+	var nodePort = common.DefaultMtlsPort
+	for _, servicePort := range service.Spec.Ports {
+		if servicePort.Port == common.DefaultMtlsPort && servicePort.NodePort > 0 {
+			nodePort = int(servicePort.NodePort)
+			break
+		}
+	}
+	addresses := make([]LoadBalancer, 0)
+	if value, ok := service.Annotations[common.GatewayAddressesAnnotation]; ok {
+		//static addresses, like the addresses of the nodes of an on-prem cluster, as host or host:port
+		for _, address := range strings.Split(value, ",") {
+			address = strings.TrimSpace(address)
+			if len(address) == 0 {
+				continue
+			}
+			host, port, err := net.SplitHostPort(address)
+			if err != nil {
+				addresses = append(addresses, LoadBalancer{Address: address, Port: nodePort})
+				continue
+			}
+			portNumber, err := strconv.Atoi(port)
+			if err != nil {
+				log.Errorf("Ignoring invalid address=%s of gateway name=%s namespace=%s err=%v", address, service.Name, service.Namespace, err)
+				continue
+			}
+			addresses = append(addresses, LoadBalancer{Address: host, Port: portNumber})
+		}
+	} else if len(service.Status.LoadBalancer.Ingress) > 0 {
+		for _, ingress := range service.Status.LoadBalancer.Ingress {
+			if len(ingress.Hostname) > 0 {
+				addresses = append(addresses, LoadBalancer{Address: ingress.Hostname, Port: common.DefaultMtlsPort})
+			} else if len(ingress.IP) > 0 {
+				addresses = append(addresses, LoadBalancer{Address: ingress.IP, Port: common.DefaultMtlsPort})
 			}
 		}
+	} else {
+		for _, externalIP := range service.Spec.ExternalIPs {
+			addresses = append(addresses, LoadBalancer{Address: externalIP, Port: nodePort})
+		}
+	}
+	return addresses
+}
+
+//returns the network of the cluster, from the topology.istio.io/network label of the istio-system namespace
+func (s *ServiceController) GetClusterNetwork() string {
+	if s.namespaceInformer == nil {
+		return ""
+	}
+	obj, exists, err := s.namespaceInformer.GetStore().GetByKey(common.NamespaceIstioSystem)
+	if err != nil || !exists {
+		return ""
+	}
+	namespace, ok := obj.(*k8sV1.Namespace)
+	if !ok {
+		return ""
 	}
-	return lb, lbPort
+	return namespace.Labels[common.NetworkLabel]
 }
 
 func NewServiceController(clusterID string, stopCh <-chan struct{}, handler ServiceHandler, config *rest.Config, resyncPeriod time.Duration) (*ServiceController, error) {
@@ -164,24 +245,118 @@ func NewServiceController(clusterID string, stopCh <-chan struct{}, handler Serv
 		return nil, fmt.Errorf("failed to create ingress service controller k8s client: %v", err)
 	}
 
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ingress service controller dynamic client: %v", err)
+	}
+
 	serviceController.informer = cache.NewSharedIndexInformer(
-		&cache.ListWatch{
-			ListFunc: func(opts meta_v1.ListOptions) (runtime.Object, error) {
-				return serviceController.K8sClient.CoreV1().Services(meta_v1.NamespaceAll).List(opts)
-			},
-			WatchFunc: func(opts meta_v1.ListOptions) (watch.Interface, error) {
-				return serviceController.K8sClient.CoreV1().Services(meta_v1.NamespaceAll).Watch(opts)
-			},
-		},
+		newServiceListWatch(dynamicClient),
 		&k8sV1.Service{}, resyncPeriod, cache.Indexers{},
 	)
 
 	mcd := NewMonitoredDelegator(&serviceController, clusterID, "service")
 	NewController("service-ctrl-"+config.Host, stopCh, mcd, serviceController.informer)
 
+	serviceController.namespaceInformer = k8sV1Informers.NewFilteredNamespaceInformer(serviceController.K8sClient, resyncPeriod, cache.Indexers{},
+		func(opts *meta_v1.ListOptions) {
+			opts.FieldSelector = "metadata.name=" + common.NamespaceIstioSystem
+		})
+	go serviceController.namespaceInformer.Run(stopCh)
+
 	return &serviceController, nil
 }
 
+var servicesResource = k8sV1.SchemeGroupVersion.WithResource("services")
+
+/*
+Lists and watches the services with the dynamic client. k8s api v0.17 doesn't have the appProtocol field of the service ports
+and drops it when decoding a service, so the services are decoded here with their appProtocols recorded in the admiral.io/app-protocols annotation.
+*/
+func newServiceListWatch(client dynamic.Interface) *cache.ListWatch {
+	return &cache.ListWatch{
+		ListFunc: func(opts meta_v1.ListOptions) (runtime.Object, error) {
+			list, err := client.Resource(servicesResource).Namespace(meta_v1.NamespaceAll).List(opts)
+			if err != nil {
+				return nil, err
+			}
+			services := &k8sV1.ServiceList{}
+			services.ResourceVersion = list.GetResourceVersion()
+			services.Continue = list.GetContinue()
+			for i := range list.Items {
+				service, err := toService(&list.Items[i])
+				if err != nil {
+					return nil, err
+				}
+				services.Items = append(services.Items, *service)
+			}
+			return services, nil
+		},
+		WatchFunc: func(opts meta_v1.ListOptions) (watch.Interface, error) {
+			w, err := client.Resource(servicesResource).Namespace(meta_v1.NamespaceAll).Watch(opts)
+			if err != nil {
+				return nil, err
+			}
+			return watch.Filter(w, func(event watch.Event) (watch.Event, bool) {
+				obj, ok := event.Object.(*unstructured.Unstructured)
+				//error events carry a status, which the informer reads from the unstructured object
+				if !ok || event.Type == watch.Error {
+					return event, true
+				}
+				service, err := toService(obj)
+				if err != nil {
+					log.Errorf("Ignoring service event=%v name=%s namespace=%s err=%v", event.Type, obj.GetName(), obj.GetNamespace(), err)
+					return event, false
+				}
+				event.Object = service
+				return event, true
+			}), nil
+		},
+	}
+}
+
+//decodes a service read with the dynamic client, the appProtocols of its ports are recorded as port=appProtocol in the admiral.io/app-protocols annotation
+func toService(obj *unstructured.Unstructured) (*k8sV1.Service, error) {
+	service := &k8sV1.Service{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), service); err != nil {
+		return nil, err
+	}
+	ports, _, _ := unstructured.NestedSlice(obj.Object, "spec", "ports")
+	appProtocols := make([]string, 0)
+	for _, port := range ports {
+		portFields, ok := port.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		portNumber, _, _ := unstructured.NestedInt64(portFields, "port")
+		appProtocol, _, _ := unstructured.NestedString(portFields, "appProtocol")
+		if portNumber > 0 && len(appProtocol) > 0 {
+			appProtocols = append(appProtocols, strconv.FormatInt(portNumber, 10)+"="+appProtocol)
+		}
+	}
+	if len(appProtocols) > 0 {
+		if service.Annotations == nil {
+			service.Annotations = make(map[string]string)
+		}
+		service.Annotations[common.AppProtocolsAnnotation] = strings.Join(appProtocols, ",")
+	}
+	return service, nil
+}
+
+//returns the appProtocol of a port of the service, from the admiral.io/app-protocols annotation recorded when the service was read
+func GetAppProtocol(service *k8sV1.Service, port int32) string {
+	if service == nil {
+		return ""
+	}
+	for _, appProtocol := range strings.Split(service.Annotations[common.AppProtocolsAnnotation], ",") {
+		portAndProtocol := strings.SplitN(appProtocol, "=", 2)
+		if len(portAndProtocol) == 2 && portAndProtocol[0] == strconv.Itoa(int(port)) {
+			return portAndProtocol[1]
+		}
+	}
+	return ""
+}
+
 func (s *ServiceController) Added(obj interface{}) {
 	service := obj.(*k8sV1.Service)
 	s.Cache.Put(service)
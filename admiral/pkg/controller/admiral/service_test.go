@@ -12,8 +12,14 @@ import (
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/uuid"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+	k8sV1Informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -37,6 +43,67 @@ func TestNewServiceController(t *testing.T) {
 }
 
 //Doing triple duty - also testing get/delete
+func getTestUnstructuredService(name string, ports ...map[string]interface{}) *unstructured.Unstructured {
+	servicePorts := make([]interface{}, 0, len(ports))
+	for _, port := range ports {
+		servicePorts = append(servicePorts, port)
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   map[string]interface{}{"name": name, "namespace": "ns", "annotations": map[string]interface{}{"foo": "bar"}},
+		"spec":       map[string]interface{}{"ports": servicePorts},
+	}}
+}
+
+func TestServiceListWatch(t *testing.T) {
+	client := dynamicFake.NewSimpleDynamicClient(runtime.NewScheme(),
+		getTestUnstructuredService("s1",
+			map[string]interface{}{"name": "http", "port": int64(80), "appProtocol": "http2"},
+			map[string]interface{}{"name": "db", "port": int64(3306), "appProtocol": "mysql"},
+			map[string]interface{}{"name": "tcp", "port": int64(9000)}))
+	listWatch := newServiceListWatch(client)
+
+	obj, err := listWatch.List(metaV1.ListOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	services, ok := obj.(*v1.ServiceList)
+	if !ok || len(services.Items) != 1 {
+		t.Fatalf("Expected a list of one service, got %v", obj)
+	}
+	service := &services.Items[0]
+	assert.Equal(t, "s1", service.Name)
+	assert.Equal(t, "bar", service.Annotations["foo"])
+	assert.Equal(t, "80=http2,3306=mysql", service.Annotations[common.AppProtocolsAnnotation])
+	assert.Equal(t, 3, len(service.Spec.Ports))
+	assert.Equal(t, "http2", GetAppProtocol(service, 80))
+	assert.Equal(t, "mysql", GetAppProtocol(service, 3306))
+	assert.Equal(t, "", GetAppProtocol(service, 9000))
+
+	w, err := listWatch.Watch(metaV1.ListOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	defer w.Stop()
+	_, err = client.Resource(servicesResource).Namespace("ns").Create(
+		getTestUnstructuredService("s2", map[string]interface{}{"name": "grpc", "port": int64(8080), "appProtocol": "grpc"}), metaV1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	select {
+	case event := <-w.ResultChan():
+		service, ok := event.Object.(*v1.Service)
+		if !ok {
+			t.Fatalf("Expected a service, got %v", event.Object)
+		}
+		assert.Equal(t, "s2", service.Name)
+		assert.Equal(t, "grpc", GetAppProtocol(service, 8080))
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for the service event")
+	}
+}
+
 func TestServiceCache_Put(t *testing.T) {
 	serviceCache := serviceCache{}
 	serviceCache.cache = make(map[string]*ServiceClusterEntry)
@@ -101,159 +168,284 @@ func TestServiceCache_Put(t *testing.T) {
 
 }
 
-func TestServiceCache_GetLoadBalancer(t *testing.T) {
+func TestServiceCache_GetLoadBalancers(t *testing.T) {
 	sc := serviceCache{}
 	sc.cache = make(map[string]*ServiceClusterEntry)
 	sc.mutex = &sync.Mutex{}
 
-	service := &v1.Service{}
-	service.Name = "test-service"
-	service.Namespace = "ns"
-	service.Status = v1.ServiceStatus{}
-	service.Status.LoadBalancer = v1.LoadBalancerStatus{}
-	service.Status.LoadBalancer.Ingress = append(service.Status.LoadBalancer.Ingress, v1.LoadBalancerIngress{Hostname: "hostname.com"})
-	service.Labels = map[string]string{"app": "test-service"}
-
-	s2 := &v1.Service{}
-	s2.Name = "test-service-ip"
-	s2.Namespace = "ns"
-	s2.Status = v1.ServiceStatus{}
-	s2.Status.LoadBalancer = v1.LoadBalancerStatus{}
-	s2.Status.LoadBalancer.Ingress = append(s2.Status.LoadBalancer.Ingress, v1.LoadBalancerIngress{IP: "1.2.3.4"})
-	s2.Labels = map[string]string{"app": "test-service-ip"}
-
-	// The primary use case is to support ingress gateways for local development
-	externalIPService := &v1.Service{}
-	externalIPService.Name = "test-service-externalip"
-	externalIPService.Namespace = "ns"
-	externalIPService.Spec = v1.ServiceSpec{}
-	externalIPService.Spec.ExternalIPs = []string{"1.2.3.4"}
-	externalIPService.Spec.Ports = []v1.ServicePort{
+	westGateway := &v1.Service{}
+	westGateway.Name = "eastwestgateway-west"
+	westGateway.Namespace = "istio-system"
+	westGateway.Labels = map[string]string{"istio": "eastwestgateway", common.NetworkLabel: "network1"}
+	westGateway.Annotations = map[string]string{common.GatewayLocalityAnnotation: "us-west-2/us-west-2a", common.GatewayWeightAnnotation: "80"}
+	westGateway.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{Hostname: "west-b.com"}, {IP: "1.2.3.4"}}
+
+	eastGateway := &v1.Service{}
+	eastGateway.Name = "eastwestgateway-east"
+	eastGateway.Namespace = "istio-system"
+	eastGateway.Labels = map[string]string{"istio": "eastwestgateway"}
+	eastGateway.Annotations = map[string]string{common.GatewayWeightAnnotation: "invalid"}
+	eastGateway.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{Hostname: "east.com"}}
+
+	ingressGateway := &v1.Service{}
+	ingressGateway.Name = "istio-ingressgateway"
+	ingressGateway.Namespace = "istio-system"
+	ingressGateway.Labels = map[string]string{"app": "istio-ingressgateway"}
+	ingressGateway.Spec.ExternalIPs = []string{"5.6.7.8"}
+	ingressGateway.Spec.Ports = []v1.ServicePort{{Name: "tls", Port: common.DefaultMtlsPort, NodePort: 30800}}
+
+	hostnameGateway := &v1.Service{}
+	hostnameGateway.Name = "gateway-hostname"
+	hostnameGateway.Namespace = "istio-system"
+	hostnameGateway.Labels = map[string]string{"app": "gateway-hostname"}
+	hostnameGateway.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{Hostname: "hostname.com"}}
+
+	ipGateway := &v1.Service{}
+	ipGateway.Name = "gateway-ip"
+	ipGateway.Namespace = "istio-system"
+	ipGateway.Labels = map[string]string{"app": "gateway-ip"}
+	ipGateway.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: "2.3.4.5"}}
+
+	//no node port for the mtls port, the nodes are reached on the mtls port itself
+	externalIPGateway := &v1.Service{}
+	externalIPGateway.Name = "gateway-externalip"
+	externalIPGateway.Namespace = "istio-system"
+	externalIPGateway.Labels = map[string]string{"app": "gateway-externalip"}
+	externalIPGateway.Spec.ExternalIPs = []string{"6.7.8.9"}
+	externalIPGateway.Spec.Ports = []v1.ServicePort{{Name: "http", Port: 80, NodePort: 30080}}
+
+	pendingGateway := &v1.Service{}
+	pendingGateway.Name = "gateway-pending"
+	pendingGateway.Namespace = "istio-system"
+	pendingGateway.Labels = map[string]string{"app": "gateway-pending"}
+	pendingGateway.Spec.Ports = []v1.ServicePort{{Name: "tls", Port: common.DefaultMtlsPort, NodePort: 30800}}
+
+	nodePortGateway := &v1.Service{}
+	nodePortGateway.Name = "eastwestgateway-nodeport"
+	nodePortGateway.Namespace = "istio-system"
+	nodePortGateway.Labels = map[string]string{"istio": "nodeport"}
+	nodePortGateway.Annotations = map[string]string{common.GatewayAddressesAnnotation: "10.0.0.1, 10.0.0.2:31000,invalid:port"}
+	nodePortGateway.Spec.Ports = []v1.ServicePort{{Name: "tls", Port: common.DefaultMtlsPort, NodePort: 30443}}
+	nodePortGateway.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{Hostname: "ignored.com"}}
+
+	ignoredGateway := &v1.Service{}
+	ignoredGateway.Name = "gateway-ignored"
+	ignoredGateway.Namespace = "istio-system"
+	ignoredGateway.Labels = map[string]string{"app": "gateway-ignored"}
+	ignoredGateway.Annotations = map[string]string{common.AdmiralIgnoreAnnotation: "true"}
+	ignoredGateway.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{Hostname: "ignored.com"}}
+
+	ignoredLaterGateway := &v1.Service{}
+	ignoredLaterGateway.Name = "gateway-ignored-later"
+	ignoredLaterGateway.Namespace = "istio-system"
+	ignoredLaterGateway.Labels = map[string]string{"app": "gateway-ignored-later"}
+	ignoredLaterGateway.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{Hostname: "ignored-later.com"}}
+
+	unignoredLaterGateway := &v1.Service{}
+	unignoredLaterGateway.Name = "gateway-unignored-later"
+	unignoredLaterGateway.Namespace = "istio-system"
+	unignoredLaterGateway.Labels = map[string]string{"app": "gateway-unignored-later"}
+	unignoredLaterGateway.Annotations = map[string]string{common.AdmiralIgnoreAnnotation: "true"}
+	unignoredLaterGateway.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{Hostname: "unignored-later.com"}}
+
+	sc.Put(westGateway)
+	sc.Put(eastGateway)
+	sc.Put(ingressGateway)
+	sc.Put(hostnameGateway)
+	sc.Put(ipGateway)
+	sc.Put(externalIPGateway)
+	sc.Put(pendingGateway)
+	sc.Put(nodePortGateway)
+	sc.Put(ignoredGateway)
+	sc.Put(ignoredLaterGateway)
+	sc.Put(unignoredLaterGateway)
+
+	ignoredLaterGateway.Annotations = map[string]string{common.AdmiralIgnoreAnnotation: "true"}
+	unignoredLaterGateway.Annotations = map[string]string{common.AdmiralIgnoreAnnotation: "false"}
+	sc.Put(ignoredLaterGateway)   //Ensuring that if the ignore annotation is added to a service, it's no longer found
+	sc.Put(unignoredLaterGateway) //And ensuring that if the ignore annotation is removed from a service, it becomes found
+
+	testCases := []struct {
+		name                  string
+		selector              string
+		ns                    string
+		expectedLoadBalancers []LoadBalancer
+	}{
+		{
+			name:     "Returns every address of every matching gateway",
+			selector: "istio=eastwestgateway",
+			ns:       "istio-system",
+			expectedLoadBalancers: []LoadBalancer{
+				{Address: "1.2.3.4", Port: common.DefaultMtlsPort, Network: "network1", Locality: "us-west-2/us-west-2a", Weight: 80},
+				{Address: "east.com", Port: common.DefaultMtlsPort},
+				{Address: "west-b.com", Port: common.DefaultMtlsPort, Network: "network1", Locality: "us-west-2/us-west-2a", Weight: 80},
+			},
+		},
+		{
+			name:                  "Finds the hostname of the load balancer",
+			selector:              "app=gateway-hostname",
+			ns:                    "istio-system",
+			expectedLoadBalancers: []LoadBalancer{{Address: "hostname.com", Port: common.DefaultMtlsPort}},
+		},
+		{
+			name:                  "Falls back to the IP of the load balancer",
+			selector:              "app=gateway-ip",
+			ns:                    "istio-system",
+			expectedLoadBalancers: []LoadBalancer{{Address: "2.3.4.5", Port: common.DefaultMtlsPort}},
+		},
+		{
+			name:                  "Falls back to externalIP with the node port of the mtls port",
+			selector:              "app=istio-ingressgateway",
+			ns:                    "istio-system",
+			expectedLoadBalancers: []LoadBalancer{{Address: "5.6.7.8", Port: 30800}},
+		},
+		{
+			name:                  "Falls back to externalIP with the mtls port when it has no node port",
+			selector:              "app=gateway-externalip",
+			ns:                    "istio-system",
+			expectedLoadBalancers: []LoadBalancer{{Address: "6.7.8.9", Port: common.DefaultMtlsPort}},
+		},
 		{
-			"http",
-			v1.ProtocolTCP,
-			common.DefaultMtlsPort,
-			intstr.FromInt(80),
-			30800,
+			name:                  "Returns nothing for a gateway without an address yet",
+			selector:              "app=gateway-pending",
+			ns:                    "istio-system",
+			expectedLoadBalancers: []LoadBalancer{},
+		},
+		{
+			name:                  "Uses the static addresses of the gateway",
+			selector:              "istio=nodeport",
+			ns:                    "istio-system",
+			expectedLoadBalancers: []LoadBalancer{{Address: "10.0.0.1", Port: 30443}, {Address: "10.0.0.2", Port: 31000}},
+		},
+		{
+			name:                  "Ignores services with the ignore annotation",
+			selector:              "app=gateway-ignored",
+			ns:                    "istio-system",
+			expectedLoadBalancers: []LoadBalancer{},
+		},
+		{
+			name:                  "Ignores services when the ignore annotation is added after the service had been added to the cache",
+			selector:              "app=gateway-ignored-later",
+			ns:                    "istio-system",
+			expectedLoadBalancers: []LoadBalancer{},
+		},
+		{
+			name:                  "Finds services when the ignore annotation is added initially, then removed",
+			selector:              "app=gateway-unignored-later",
+			ns:                    "istio-system",
+			expectedLoadBalancers: []LoadBalancer{{Address: "unignored-later.com", Port: common.DefaultMtlsPort}},
+		},
+		{
+			name:                  "Returns nothing when no gateway matches",
+			selector:              "istio=ingressgateway",
+			ns:                    "istio-system",
+			expectedLoadBalancers: []LoadBalancer{},
+		},
+		{
+			name:                  "Returns nothing when there are no services",
+			selector:              "istio=eastwestgateway",
+			ns:                    "ns",
+			expectedLoadBalancers: []LoadBalancer{},
 		},
 	}
-	externalIPService.Labels = map[string]string{"app": "test-service-externalip"}
-
-	ignoreService := &v1.Service{}
-	ignoreService.Name = "test-service-ignored"
-	ignoreService.Namespace = "ns"
-	ignoreService.Status = v1.ServiceStatus{}
-	ignoreService.Status.LoadBalancer = v1.LoadBalancerStatus{}
-	ignoreService.Status.LoadBalancer.Ingress = append(service.Status.LoadBalancer.Ingress, v1.LoadBalancerIngress{Hostname: "hostname.com"})
-	ignoreService.Annotations = map[string]string{"admiral.io/ignore": "true"}
-	ignoreService.Labels = map[string]string{"app": "test-service-ignored"}
-
-	ignoreService2 := &v1.Service{}
-	ignoreService2.Name = "test-service-ignored-later"
-	ignoreService2.Namespace = "ns"
-	ignoreService2.Status = v1.ServiceStatus{}
-	ignoreService2.Status.LoadBalancer = v1.LoadBalancerStatus{}
-	ignoreService2.Status.LoadBalancer.Ingress = append(service.Status.LoadBalancer.Ingress, v1.LoadBalancerIngress{Hostname: "hostname.com"})
-	ignoreService2.Labels = map[string]string{"app": "test-service-ignored-later"}
-
-	ignoreService3 := &v1.Service{}
-	ignoreService3.Name = "test-service-unignored-later"
-	ignoreService3.Namespace = "ns"
-	ignoreService3.Status = v1.ServiceStatus{}
-	ignoreService3.Status.LoadBalancer = v1.LoadBalancerStatus{}
-	ignoreService3.Status.LoadBalancer.Ingress = append(service.Status.LoadBalancer.Ingress, v1.LoadBalancerIngress{Hostname: "hostname.com"})
-	ignoreService3.Annotations = map[string]string{"admiral.io/ignore": "true"}
-	ignoreService3.Labels = map[string]string{"app": "test-service-unignored-later"}
-
-	sc.Put(service)
-	sc.Put(s2)
-	sc.Put(externalIPService)
-	sc.Put(ignoreService)
-	sc.Put(ignoreService2)
-	sc.Put(ignoreService3)
-
-	ignoreService2.Annotations = map[string]string{"admiral.io/ignore": "true"}
-	ignoreService3.Annotations = map[string]string{"admiral.io/ignore": "false"}
-
-	sc.Put(ignoreService2) //Ensuring that if the ignore label is added to a service, it's no longer found
-	sc.Put(ignoreService3) //And ensuring that if the ignore label is removed from a service, it becomes found
 
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			selector, err := labels.Parse(c.selector)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			loadBalancers := sc.GetLoadBalancers(selector, c.ns)
+			if !cmp.Equal(loadBalancers, c.expectedLoadBalancers) {
+				t.Errorf("Unexpected load balancers returned: %v", cmp.Diff(c.expectedLoadBalancers, loadBalancers))
+			}
+		})
+	}
+}
+
+func TestGetServiceAddresses(t *testing.T) {
 	testCases := []struct {
-		name           string
-		cache          *serviceCache
-		key            string
-		ns             string
-		expectedReturn string
-		expectedPort   int
+		name              string
+		addresses         string
+		nodePort          int32
+		expectedAddresses []LoadBalancer
 	}{
 		{
-			name:           "Find service load balancer when present",
-			cache:          &sc,
-			key:            "test-service",
-			ns:             "ns",
-			expectedReturn: "hostname.com",
-			expectedPort:   common.DefaultMtlsPort,
-		},
-		{
-			name:           "Return default when service not present",
-			cache:          &sc,
-			key:            "test-service",
-			ns:             "ns-incorrect",
-			expectedReturn: "dummy.admiral.global",
-			expectedPort:   0,
+			name:              "Uses the port of a host:port address",
+			addresses:         "10.0.0.1:31000",
+			nodePort:          30443,
+			expectedAddresses: []LoadBalancer{{Address: "10.0.0.1", Port: 31000}},
 		},
 		{
-			name:           "Falls back to IP",
-			cache:          &sc,
-			key:            "test-service-ip",
-			ns:             "ns",
-			expectedReturn: "1.2.3.4",
-			expectedPort:   common.DefaultMtlsPort,
+			name:              "Uses the node port of the mtls port for a bare host",
+			addresses:         "node1.com",
+			nodePort:          30443,
+			expectedAddresses: []LoadBalancer{{Address: "node1.com", Port: 30443}},
 		},
 		{
-			name:           "Falls back to externalIP",
-			cache:          &sc,
-			key:            "test-service-externalip",
-			ns:             "ns",
-			expectedReturn: "1.2.3.4",
-			expectedPort:   30800,
+			name:              "Uses the mtls port for a bare host when there is no node port",
+			addresses:         "node1.com",
+			expectedAddresses: []LoadBalancer{{Address: "node1.com", Port: common.DefaultMtlsPort}},
 		},
 		{
-			name:           "Successfully ignores services with the ignore label",
-			cache:          &sc,
-			key:            "test-service-ignored",
-			ns:             "ns",
-			expectedReturn: "dummy.admiral.global",
-			expectedPort:   common.DefaultMtlsPort,
+			name:              "Skips the addresses with an invalid port",
+			addresses:         "10.0.0.1:port,10.0.0.2:31000",
+			nodePort:          30443,
+			expectedAddresses: []LoadBalancer{{Address: "10.0.0.2", Port: 31000}},
 		},
 		{
-			name:           "Successfully ignores services when the ignore label is added after the service had been added to the cache for the first time",
-			cache:          &sc,
-			key:            "test-service-ignored-later",
-			ns:             "ns",
-			expectedReturn: "dummy.admiral.global",
-			expectedPort:   common.DefaultMtlsPort,
+			name:              "Skips the empty addresses and trims the spaces",
+			addresses:         " 10.0.0.1 ,, 10.0.0.2:31000 ",
+			nodePort:          30443,
+			expectedAddresses: []LoadBalancer{{Address: "10.0.0.1", Port: 30443}, {Address: "10.0.0.2", Port: 31000}},
 		},
 		{
-			name:           "Successfully finds services when the ignore label is added initially, then removed",
-			cache:          &sc,
-			key:            "test-service-unignored-later",
-			ns:             "ns",
-			expectedReturn: "hostname.com",
-			expectedPort:   common.DefaultMtlsPort,
+			name:              "Returns nothing when every address is invalid",
+			addresses:         "10.0.0.1:port",
+			nodePort:          30443,
+			expectedAddresses: []LoadBalancer{},
 		},
 	}
 
 	for _, c := range testCases {
 		t.Run(c.name, func(t *testing.T) {
-			loadBalancer, port := c.cache.GetLoadBalancer(c.key, c.ns)
-			if loadBalancer != c.expectedReturn || port != c.expectedPort {
-				t.Errorf("Unexpected load balancer returned. Got %v:%v, expected %v:%v", loadBalancer, port, c.expectedReturn, c.expectedPort)
+			service := &v1.Service{}
+			service.Name = "gateway"
+			service.Namespace = "istio-system"
+			service.Annotations = map[string]string{common.GatewayAddressesAnnotation: c.addresses}
+			if c.nodePort > 0 {
+				service.Spec.Ports = []v1.ServicePort{{Name: "tls", Port: common.DefaultMtlsPort, NodePort: c.nodePort}}
+			}
+			//the annotation takes precedence over the load balancer status
+			service.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{Hostname: "ignored.com"}}
+			addresses := getServiceAddresses(service)
+			if !cmp.Equal(addresses, c.expectedAddresses) {
+				t.Errorf("Unexpected addresses returned: %v", cmp.Diff(c.expectedAddresses, addresses))
 			}
 		})
 	}
 }
 
+func TestServiceController_GetClusterNetwork(t *testing.T) {
+	serviceController := ServiceController{}
+	if network := serviceController.GetClusterNetwork(); network != "" {
+		t.Errorf("Wanted no network without a namespace informer, got %v", network)
+	}
+
+	serviceController.namespaceInformer = k8sV1Informers.NewNamespaceInformer(fake.NewSimpleClientset(), 0, cache.Indexers{})
+	if network := serviceController.GetClusterNetwork(); network != "" {
+		t.Errorf("Wanted no network before the namespace is known, got %v", network)
+	}
+
+	namespace := &v1.Namespace{}
+	namespace.Name = common.NamespaceIstioSystem
+	namespace.Labels = map[string]string{common.NetworkLabel: "network1"}
+	serviceController.namespaceInformer.GetStore().Add(namespace)
+	if network := serviceController.GetClusterNetwork(); network != "network1" {
+		t.Errorf("Wanted network1, got %v", network)
+	}
+}
+
 func TestConcurrentGetAndPut(t *testing.T) {
 	serviceCache := serviceCache{}
 	serviceCache.cache = make(map[string]*ServiceClusterEntry)
@@ -0,0 +1,220 @@
+package admiral
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sAppsinformers "k8s.io/client-go/informers/apps/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Handler interface contains the methods that are required
+type StatefulSetHandler interface {
+	Added(obj *k8sAppsV1.StatefulSet)
+	Deleted(obj *k8sAppsV1.StatefulSet)
+}
+
+type StatefulSetClusterEntry struct {
+	Identity     string
+	StatefulSets map[string]*k8sAppsV1.StatefulSet
+}
+
+type StatefulSetController struct {
+	K8sClient          kubernetes.Interface
+	StatefulSetHandler StatefulSetHandler
+	Cache              *statefulSetCache
+	informer           cache.SharedIndexInformer
+	namespaceInformer  cache.SharedIndexInformer
+	labelSet           *common.LabelSet
+}
+
+type statefulSetCache struct {
+	//map of dependencies key=identity value array of onboarded identities
+	cache map[string]*StatefulSetClusterEntry
+	mutex *sync.Mutex
+}
+
+func (p *statefulSetCache) getKey(statefulSet *k8sAppsV1.StatefulSet) string {
+	return common.GetStatefulSetGlobalIdentifier(statefulSet)
+}
+
+func (p *statefulSetCache) Get(key string, env string) *k8sAppsV1.StatefulSet {
+	defer p.mutex.Unlock()
+	p.mutex.Lock()
+	sce := p.cache[key]
+	if sce != nil {
+		return sce.StatefulSets[env]
+	} else {
+		return nil
+	}
+}
+
+// Range calls fn for every identity and env in the cache, fn should not call back into the cache
+func (p *statefulSetCache) Range(fn func(identity string, env string, statefulSet *k8sAppsV1.StatefulSet)) {
+	defer p.mutex.Unlock()
+	p.mutex.Lock()
+	for identity, sce := range p.cache {
+		for env, statefulSet := range sce.StatefulSets {
+			fn(identity, env, statefulSet)
+		}
+	}
+}
+
+func (p *statefulSetCache) UpdateStatefulSetToClusterCache(key string, statefulSet *k8sAppsV1.StatefulSet) {
+	defer p.mutex.Unlock()
+	p.mutex.Lock()
+
+	env := common.GetEnvForStatefulSet(statefulSet)
+
+	sce := p.cache[key]
+
+	if sce == nil {
+		sce = &StatefulSetClusterEntry{
+			Identity:     key,
+			StatefulSets: make(map[string]*k8sAppsV1.StatefulSet),
+		}
+	}
+	sce.StatefulSets[env] = statefulSet
+	p.cache[sce.Identity] = sce
+}
+
+func (p *statefulSetCache) DeleteFromStatefulSetClusterCache(key string, statefulSet *k8sAppsV1.StatefulSet) {
+	defer p.mutex.Unlock()
+	p.mutex.Lock()
+
+	env := common.GetEnvForStatefulSet(statefulSet)
+
+	sce := p.cache[key]
+
+	if sce != nil {
+		delete(sce.StatefulSets, env)
+	}
+}
+
+func NewStatefulSetController(clusterID string, stopCh <-chan struct{}, handler StatefulSetHandler, config *rest.Config, resyncPeriod time.Duration) (*StatefulSetController, error) {
+
+	statefulSetController := StatefulSetController{}
+	statefulSetController.StatefulSetHandler = handler
+	statefulSetController.labelSet = common.GetLabelSet()
+
+	statefulSetCache := statefulSetCache{}
+	statefulSetCache.cache = make(map[string]*StatefulSetClusterEntry)
+	statefulSetCache.mutex = &sync.Mutex{}
+
+	statefulSetController.Cache = &statefulSetCache
+	var err error
+
+	statefulSetController.K8sClient, err = K8sClientFromConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create statefulset controller k8s client: %v", err)
+	}
+
+	statefulSetController.informer = k8sAppsinformers.NewStatefulSetInformer(
+		statefulSetController.K8sClient,
+		meta_v1.NamespaceAll,
+		resyncPeriod,
+		cache.Indexers{},
+	)
+
+	statefulSetController.namespaceInformer = newNamespaceInformer(statefulSetController.K8sClient, stopCh, resyncPeriod)
+
+	wc := NewMonitoredDelegator(&statefulSetController, clusterID, "statefulset")
+	NewController("statefulset-ctrl-"+config.Host, stopCh, wc, statefulSetController.informer)
+
+	return &statefulSetController, nil
+}
+
+func (s *StatefulSetController) Added(obj interface{}) {
+	HandleAddUpdateStatefulSet(obj, s)
+}
+
+func (s *StatefulSetController) Updated(obj interface{}, oldObj interface{}) {
+	HandleAddUpdateStatefulSet(obj, s)
+}
+
+func HandleAddUpdateStatefulSet(obj interface{}, s *StatefulSetController) {
+	statefulSet := obj.(*k8sAppsV1.StatefulSet)
+	key := s.Cache.getKey(statefulSet)
+	if len(key) > 0 {
+		if !s.shouldIgnoreBasedOnLabels(statefulSet) {
+			s.Cache.UpdateStatefulSetToClusterCache(key, statefulSet)
+			s.StatefulSetHandler.Added(statefulSet)
+		} else {
+			s.Cache.DeleteFromStatefulSetClusterCache(key, statefulSet)
+			log.Debugf("ignoring statefulset %v based on labels", statefulSet.Name)
+		}
+	} else if !s.shouldIgnoreBasedOnLabels(statefulSet) {
+		//passed on without an identity so the handler can report it
+		s.StatefulSetHandler.Added(statefulSet)
+	}
+}
+
+// returns true once the informer has listed all the statefulsets, the cache is partial until then
+func (s *StatefulSetController) HasSynced() bool {
+	return s.informer != nil && s.informer.HasSynced()
+}
+
+func (s *StatefulSetController) Deleted(obj interface{}) {
+	statefulSet := obj.(*k8sAppsV1.StatefulSet)
+	key := s.Cache.getKey(statefulSet)
+	s.StatefulSetHandler.Deleted(statefulSet)
+	if len(key) > 0 {
+		s.Cache.DeleteFromStatefulSetClusterCache(key, statefulSet)
+	}
+}
+
+func (s *StatefulSetController) shouldIgnoreBasedOnLabels(statefulSet *k8sAppsV1.StatefulSet) bool {
+	if statefulSet.Spec.Template.Labels[s.labelSet.AdmiralIgnoreLabel] == "true" { //if we should ignore, do that and who cares what else is there
+		return true
+	}
+
+	if statefulSet.Spec.Template.Annotations[s.labelSet.DeploymentAnnotation] != "true" { //Not sidecar injected, we don't want to inject
+		return true
+	}
+
+	if statefulSet.Annotations[common.AdmiralIgnoreAnnotation] == "true" {
+		return true
+	}
+
+	ns, err := getNamespace(s.namespaceInformer, s.K8sClient, statefulSet.Namespace)
+	if err != nil {
+		log.Warnf("Failed to get namespace object for statefulset with namespace %v, err: %v", statefulSet.Namespace, err)
+		return false
+	}
+
+	if ns.Annotations[common.AdmiralIgnoreAnnotation] == "true" {
+		return true
+	}
+	return false //labels are fine, we should not ignore
+}
+
+func (s *StatefulSetController) GetStatefulSetBySelectorInNamespace(serviceSelector map[string]string, namespace string) []k8sAppsV1.StatefulSet {
+
+	matchedStatefulSets, err := s.K8sClient.AppsV1().StatefulSets(namespace).List(meta_v1.ListOptions{})
+
+	if err != nil {
+		log.Errorf("Failed to list statefulsets in cluster, error: %v", err)
+		return nil
+	}
+
+	if matchedStatefulSets.Items == nil {
+		return []k8sAppsV1.StatefulSet{}
+	}
+
+	filteredStatefulSets := make([]k8sAppsV1.StatefulSet, 0)
+
+	for _, statefulSet := range matchedStatefulSets.Items {
+		if common.IsServiceMatch(serviceSelector, statefulSet.Spec.Selector) {
+			filteredStatefulSets = append(filteredStatefulSets, statefulSet)
+		}
+	}
+
+	return filteredStatefulSets
+}
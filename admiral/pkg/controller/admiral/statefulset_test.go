@@ -0,0 +1,175 @@
+package admiral
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/test"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func TestStatefulSetController_Added(t *testing.T) {
+	//StatefulSets with the correct label are added to the cache
+	msh := test.MockStatefulSetHandler{}
+	cache := statefulSetCache{
+		cache: map[string]*StatefulSetClusterEntry{},
+		mutex: &sync.Mutex{},
+	}
+	labelset := common.LabelSet{
+		DeploymentAnnotation: "sidecar.istio.io/inject",
+		AdmiralIgnoreLabel:   "admiral-ignore",
+	}
+	stsController := StatefulSetController{
+		StatefulSetHandler: &msh,
+		Cache:              &cache,
+		labelSet:           &labelset,
+	}
+	statefulSet := k8sAppsV1.StatefulSet{}
+	statefulSet.Spec.Template.Labels = map[string]string{"identity": "id", "istio-injected": "true"}
+	statefulSet.Spec.Template.Annotations = map[string]string{"sidecar.istio.io/inject": "true"}
+	statefulSetWithBadLabels := k8sAppsV1.StatefulSet{}
+	statefulSetWithBadLabels.Spec.Template.Labels = map[string]string{"identity": "id", "random-label": "true"}
+	statefulSetWithIgnoreLabels := k8sAppsV1.StatefulSet{}
+	statefulSetWithIgnoreLabels.Spec.Template.Labels = map[string]string{"identity": "id", "istio-injected": "true", "admiral-ignore": "true"}
+	statefulSetWithIgnoreLabels.Spec.Template.Annotations = map[string]string{"sidecar.istio.io/inject": "true"}
+	statefulSetWithNsIgnoreAnnotations := k8sAppsV1.StatefulSet{}
+	statefulSetWithNsIgnoreAnnotations.Spec.Template.Labels = map[string]string{"identity": "id"}
+	statefulSetWithNsIgnoreAnnotations.Spec.Template.Annotations = map[string]string{"sidecar.istio.io/inject": "true"}
+	statefulSetWithNsIgnoreAnnotations.Namespace = "test-ns"
+
+	testCases := []struct {
+		name                string
+		statefulSet         *k8sAppsV1.StatefulSet
+		expectedStatefulSet *k8sAppsV1.StatefulSet
+	}{
+		{
+			name:                "Expects statefulset to be added to the cache when the correct label is present",
+			statefulSet:         &statefulSet,
+			expectedStatefulSet: &statefulSet,
+		},
+		{
+			name:                "Expects statefulset to not be added to the cache when the correct label is not present",
+			statefulSet:         &statefulSetWithBadLabels,
+			expectedStatefulSet: nil,
+		},
+		{
+			name:                "Expects ignored statefulset identified by label to not be added to the cache",
+			statefulSet:         &statefulSetWithIgnoreLabels,
+			expectedStatefulSet: nil,
+		},
+		{
+			name:                "Expects ignored statefulset identified by namespace annotation to not be added to the cache",
+			statefulSet:         &statefulSetWithNsIgnoreAnnotations,
+			expectedStatefulSet: nil,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			stsController.K8sClient = fake.NewSimpleClientset()
+			ns := coreV1.Namespace{}
+			ns.Name = "test-ns"
+			ns.Annotations = map[string]string{"admiral.io/ignore": "true"}
+			stsController.K8sClient.CoreV1().Namespaces().Create(&ns)
+			stsController.Cache.cache = map[string]*StatefulSetClusterEntry{}
+
+			stsController.Added(c.statefulSet)
+
+			cached := stsController.Cache.Get("id", common.Default)
+			if cached != c.expectedStatefulSet {
+				t.Errorf("Incorrect statefulset in the cache. Got %v expected %v", cached, c.expectedStatefulSet)
+			}
+		})
+	}
+}
+
+func TestStatefulSetController_Deleted(t *testing.T) {
+	msh := test.MockStatefulSetHandler{}
+	cache := statefulSetCache{
+		cache: map[string]*StatefulSetClusterEntry{},
+		mutex: &sync.Mutex{},
+	}
+	stsController := StatefulSetController{
+		StatefulSetHandler: &msh,
+		Cache:              &cache,
+	}
+	statefulSet := k8sAppsV1.StatefulSet{}
+	statefulSet.Spec.Template.Labels = map[string]string{"identity": "id"}
+
+	stsController.Cache.UpdateStatefulSetToClusterCache("id", &statefulSet)
+	stsController.Deleted(&statefulSet)
+
+	if stsController.Cache.Get("id", common.Default) != nil {
+		t.Errorf("StatefulSet should be deleted from the cache")
+	}
+	//deleting a statefulset not present in the cache should not fail
+	stsController.Deleted(&statefulSet)
+}
+
+func TestNewStatefulSetController(t *testing.T) {
+	config, err := clientcmd.BuildConfigFromFlags("", "../../test/resources/admins@fake-cluster.k8s.local")
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	stop := make(chan struct{})
+	stsHandler := test.MockStatefulSetHandler{}
+
+	stsCon, err := NewStatefulSetController("", stop, &stsHandler, config, time.Duration(1000))
+
+	if stsCon == nil {
+		t.Errorf("StatefulSet controller should not be nil")
+	}
+}
+
+func TestStatefulSetController_GetStatefulSetBySelectorInNamespace(t *testing.T) {
+	statefulSet := k8sAppsV1.StatefulSet{}
+	statefulSet.Namespace = "namespace"
+	statefulSet.Name = "fake-app-statefulset-qal"
+	statefulSet.Spec = k8sAppsV1.StatefulSetSpec{
+		Selector: &v1.LabelSelector{MatchLabels: map[string]string{"identity": "app1"}},
+	}
+	statefulSet2 := k8sAppsV1.StatefulSet{}
+	statefulSet2.Namespace = "namespace"
+	statefulSet2.Name = "fake-app-statefulset-e2e"
+	statefulSet2.Spec = k8sAppsV1.StatefulSetSpec{
+		Selector: &v1.LabelSelector{MatchLabels: map[string]string{"identity": "app2"}},
+	}
+
+	stsController := &StatefulSetController{K8sClient: fake.NewSimpleClientset(&statefulSet, &statefulSet2)}
+
+	testCases := []struct {
+		name          string
+		selector      map[string]string
+		expectedNames []string
+	}{
+		{
+			name:          "Get one",
+			selector:      map[string]string{"identity": "app1"},
+			expectedNames: []string{"fake-app-statefulset-qal"},
+		},
+		{
+			name:          "Get none",
+			selector:      map[string]string{"identity": "app3"},
+			expectedNames: []string{},
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			returned := stsController.GetStatefulSetBySelectorInNamespace(c.selector, "namespace")
+			if len(returned) != len(c.expectedNames) {
+				t.Fatalf("Returned the wrong number of statefulsets. Found %v but expected %v", len(returned), len(c.expectedNames))
+			}
+			for i, name := range c.expectedNames {
+				if returned[i].Name != name {
+					t.Errorf("Wanted statefulset %v, got %v", name, returned[i].Name)
+				}
+			}
+		})
+	}
+}
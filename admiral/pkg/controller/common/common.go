@@ -1,9 +1,12 @@
 package common
 
 import (
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"strings"
+	"time"
 
 	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1"
 	log "github.com/sirupsen/logrus"
@@ -19,8 +22,21 @@ const (
 	Grpc                          = "grpc"
 	GrpcWeb                       = "grpc-web"
 	Http2                         = "http2"
+	Https                         = "https"
+	Tcp                           = "tcp"
+	Tls                           = "tls"
+	Mongo                         = "mongo"
+	Mysql                         = "mysql"
+	Redis                         = "redis"
 	DefaultMtlsPort               = 15443
 	DefaultServiceEntryPort       = 80
+	DefaultFullReconcileQPS       = 10
+	DefaultReconcileQPS           = 20
+	DefaultReconcileWorkers       = 2
+	DefaultClusterWriteWorkers    = 10
+	DefaultClusterWriteTimeout    = 30 * time.Second
+	DefaultStatusUpdateQPS        = 5
+	DefaultWebhookPort            = 9443
 	Sep                           = "."
 	Dash                          = "-"
 	Slash                         = "/"
@@ -29,11 +45,24 @@ const (
 	MulticlusterIngressGateway    = "istio-multicluster-ingressgateway"
 	LocalAddressPrefix            = "240.0"
 	NodeRegionLabel               = "failure-domain.beta.kubernetes.io/region"
+	NodeZoneLabel                 = "failure-domain.beta.kubernetes.io/zone"
+	NodeTopologyRegionLabel       = "topology.kubernetes.io/region"
+	NodeTopologyZoneLabel         = "topology.kubernetes.io/zone"
+	NodeSubzoneLabel              = "topology.istio.io/subzone"
+	NetworkLabel                  = "topology.istio.io/network"
+	GatewayLocalityAnnotation     = "admiral.io/locality"
+	GatewayWeightAnnotation       = "admiral.io/weight"
+	GatewayAddressesAnnotation    = "admiral.io/gateway-addresses"
+	AppProtocolsAnnotation        = "admiral.io/app-protocols"
 	SpiffePrefix                  = "spiffe://"
 	SidecarEnabledPorts           = "traffic.sidecar.istio.io/includeInboundPorts"
+	CreatedByAnnotation           = "app.kubernetes.io/created-by"
+	CreatedByAdmiral              = "admiral"
+	SpecHashAnnotation            = "admiral.io/spec-hash"
 	Default                       = "default"
 	AdmiralIgnoreAnnotation       = "admiral.io/ignore"
 	AdmiralCnameCaseSensitive     = "admiral.io/cname-case-sensitive"
+	AdmiralPodHostnames           = "admiral.io/pod-hostnames"
 	BlueGreenRolloutPreviewPrefix = "preview"
 	RolloutPodHashLabel           = "rollouts-pod-template-hash"
 	RolloutActiveServiceSuffix	  = "active-service"
@@ -54,6 +83,7 @@ const (
 	VirtualService  ResourceType = "VirtualService"
 	DestinationRule ResourceType = "DestinationRule"
 	ServiceEntry    ResourceType = "ServiceEntry"
+	Sidecar         ResourceType = "Sidecar"
 )
 
 func GetPodGlobalIdentifier(pod *k8sV1.Pod) string {
@@ -65,32 +95,12 @@ func GetPodGlobalIdentifier(pod *k8sV1.Pod) string {
 }
 
 func GetDeploymentGlobalIdentifier(deployment *k8sAppsV1.Deployment) string {
-	identity := deployment.Spec.Template.Labels[GetWorkloadIdentifier()]
-	if len(identity) == 0 {
-		//TODO can this be removed now? This was for backward compatibility
-		identity = deployment.Spec.Template.Annotations[GetWorkloadIdentifier()]
-	}
-	return identity
+	return GetGlobalIdentifierFromPodTemplate(&deployment.Spec.Template)
 }
 
 // GetCname returns cname in the format <env>.<service identity>.global, Ex: stage.Admiral.services.registry.global
 func GetCname(deployment *k8sAppsV1.Deployment, identifier string, nameSuffix string) string {
-	var environment = GetEnv(deployment)
-	alias := GetValueForKeyFromDeployment(identifier, deployment)
-	if len(alias) == 0 {
-		log.Warnf("%v label missing on deployment %v in namespace %v. Falling back to annotation to create cname.", identifier, deployment.Name, deployment.Namespace)
-		alias = deployment.Spec.Template.Annotations[identifier]
-	}
-	if len(alias) == 0 {
-		log.Errorf("Unable to get cname for deployment with name %v in namespace %v as it doesn't have the %v annotation", deployment.Name, deployment.Namespace, identifier)
-		return ""
-	}
-	cname := GetCnameVal([]string{environment, alias, nameSuffix})
-	if deployment.Spec.Template.Annotations[AdmiralCnameCaseSensitive] == "true" {
-		log.Infof("admiral.io/cname-case-sensitive annotation enabled on deployment with name %v", deployment.Name)
-		return cname
-	}
-	return strings.ToLower(cname)
+	return GetCnameFromPodTemplate("deployment", &deployment.ObjectMeta, &deployment.Spec.Template, identifier, nameSuffix)
 }
 
 func GetCnameVal(vals []string) string {
@@ -98,52 +108,45 @@ func GetCnameVal(vals []string) string {
 }
 
 func GetEnv(deployment *k8sAppsV1.Deployment) string {
-	var environment = deployment.Spec.Template.Annotations[GetEnvKey()]
-	if len(environment) == 0 {
-		environment = deployment.Spec.Template.Labels[GetEnvKey()]
-	}
-	if len(environment) == 0 {
-		environment = deployment.Spec.Template.Labels[Env]
-	}
-	if len(environment) == 0 {
-		splitNamespace := strings.Split(deployment.Namespace, Dash)
-		if len(splitNamespace) > 1 {
-			environment = splitNamespace[len(splitNamespace)-1]
-		}
-		log.Warnf("Using deprecated approach to deduce env from namespace for deployment, name=%v in namespace=%v", deployment.Name, deployment.Namespace)
-	}
-	if len(environment) == 0 {
-		environment = Default
-	}
-	return environment
+	return GetEnvFromPodTemplate("deployment", &deployment.ObjectMeta, &deployment.Spec.Template)
 }
 
 // GetSAN returns SAN for a service entry in the format spiffe://<domain>/<identifier>, Ex: spiffe://subdomain.domain.com/Admiral.platform.mesh.server
 func GetSAN(domain string, deployment *k8sAppsV1.Deployment, identifier string) string {
-	identifierVal := GetValueForKeyFromDeployment(identifier, deployment)
-	if len(identifierVal) == 0 {
-		log.Errorf("Unable to get SAN for deployment with name %v in namespace %v as it doesn't have the %v annotation or label", deployment.Name, deployment.Namespace, identifier)
-		return ""
+	return GetSANFromPodTemplate("deployment", domain, &deployment.ObjectMeta, &deployment.Spec.Template, identifier)
+}
+
+//returns the locality of the node in the istio format region/zone/subzone
+func GetNodeLocality(node *k8sV1.Node) string {
+	return GetLocality(GetNodeRegion(node), GetNodeZone(node), GetNodeSubzone(node))
+}
+
+func GetNodeRegion(node *k8sV1.Node) string {
+	if region := node.Labels[NodeTopologyRegionLabel]; len(region) > 0 {
+		return region
 	}
-	if len(domain) > 0 {
-		return SpiffePrefix + domain + Slash + identifierVal
-	} else {
-		return SpiffePrefix + identifierVal
+	return node.Labels[NodeRegionLabel]
+}
+
+func GetNodeZone(node *k8sV1.Node) string {
+	if zone := node.Labels[NodeTopologyZoneLabel]; len(zone) > 0 {
+		return zone
 	}
+	return node.Labels[NodeZoneLabel]
 }
 
-func GetNodeLocality(node *k8sV1.Node) string {
-	region := node.Labels[NodeRegionLabel]
-	return region
+func GetNodeSubzone(node *k8sV1.Node) string {
+	return node.Labels[NodeSubzoneLabel]
+}
+
+//joins region, zone and subzone the way istio expects the locality of an endpoint, without the trailing empty values
+func GetLocality(region string, zone string, subzone string) string {
+	locality := strings.Join([]string{region, zone, subzone}, Slash)
+	return strings.TrimRight(locality, Slash)
 }
 
 func GetValueForKeyFromDeployment(key string, deployment *k8sAppsV1.Deployment) string {
-	value := deployment.Spec.Template.Labels[key]
-	if len(value) == 0 {
-		log.Warnf("%v label missing on deployment %v in namespace %v. Falling back to annotation.", key, deployment.Name, deployment.Namespace)
-		value = deployment.Spec.Template.Annotations[key]
-	}
-	return value
+	return GetValueForKeyFromPodTemplate("deployment", key, &deployment.ObjectMeta, &deployment.Spec.Template)
 }
 
 func GetGtpEnv(gtp *v1.GlobalTrafficPolicy) string {
@@ -184,6 +187,15 @@ func ConstructGtpKey(env, identity string) string {
 	return fmt.Sprintf("%s.%s", env, identity)
 }
 
+//returns the hash of the spec of an istio object, json sorts the keys of the maps so equal specs have the same hash
+func GetSpecHash(spec interface{}) (string, error) {
+	bytes, err := json.Marshal(spec)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(bytes)), nil
+}
+
 func ShouldIgnoreResource(metadata v12.ObjectMeta) bool {
 	return  metadata.Annotations[AdmiralIgnoreAnnotation] == "true" || metadata.Labels[AdmiralIgnoreAnnotation] == "true"
 }
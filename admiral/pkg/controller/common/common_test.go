@@ -5,9 +5,11 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	v12 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1"
 	k8sAppsV1 "k8s.io/api/apps/v1"
+	networking "istio.io/api/networking/v1alpha3"
 	k8sCoreV1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -158,6 +160,18 @@ func TestNodeLocality(t *testing.T) {
 			node:     k8sCoreV1.Node{Spec: k8sCoreV1.NodeSpec{}, ObjectMeta: v1.ObjectMeta{Annotations: map[string]string{}}},
 			expected: "",
 		},
+		{
+			name: "should return the region, zone and subzone of the node",
+			node: k8sCoreV1.Node{Spec: k8sCoreV1.NodeSpec{}, ObjectMeta: v1.ObjectMeta{Labels: map[string]string{
+				NodeRegionLabel: nodeLocalityLabel, NodeZoneLabel: "us-west-2a", NodeSubzoneLabel: "rack1"}}},
+			expected: "us-west-2/us-west-2a/rack1",
+		},
+		{
+			name: "should prefer the topology labels over the deprecated ones",
+			node: k8sCoreV1.Node{Spec: k8sCoreV1.NodeSpec{}, ObjectMeta: v1.ObjectMeta{Labels: map[string]string{
+				NodeRegionLabel: "us-east-2", NodeZoneLabel: "us-east-2a", NodeTopologyRegionLabel: nodeLocalityLabel, NodeTopologyZoneLabel: "us-west-2b"}}},
+			expected: "us-west-2/us-west-2b",
+		},
 	}
 
 	for _, c := range testCases {
@@ -356,4 +370,32 @@ func TestGetGtpEnv(t *testing.T) {
 		})
 	}
 
-}
\ No newline at end of file
+}
+func TestGetSpecHash(t *testing.T) {
+	newServiceEntry := func(ports map[string]uint32) *networking.ServiceEntry {
+		return &networking.ServiceEntry{
+			Hosts:     []string{"e2e.bar.mesh"},
+			Endpoints: []*networking.ServiceEntry_Endpoint{{Address: "east.mesh", Ports: ports}},
+		}
+	}
+	ports := map[string]uint32{"http": 15443}
+	for i := 0; i < 10; i++ {
+		ports[strconv.Itoa(i)] = 15443
+	}
+	samePorts := make(map[string]uint32)
+	for name, port := range ports {
+		samePorts[name] = port
+	}
+
+	hash, err := GetSpecHash(newServiceEntry(ports))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if sameHash, _ := GetSpecHash(newServiceEntry(samePorts)); sameHash != hash {
+		t.Errorf("Wanted the same hash for the same spec, got %v and %v", hash, sameHash)
+	}
+	samePorts["http"] = 80
+	if otherHash, _ := GetSpecHash(newServiceEntry(samePorts)); otherHash == hash {
+		t.Errorf("Wanted another hash for another spec, got %v", otherHash)
+	}
+}
@@ -32,6 +32,10 @@ func GetArgoRolloutsEnabled() bool {
 	return admiralParams.ArgoRolloutsEnabled
 }
 
+func GetStatefulSetsEnabled() bool {
+	return admiralParams.StatefulSetsEnabled
+}
+
 func GetKubeconfigPath() string {
 	return admiralParams.KubeconfigPath
 }
@@ -96,12 +100,126 @@ func GetMetricsEnabled() bool {
 	return admiralParams.MetricsEnabled
 }
 
+func GetFullReconcileQPS() float32 {
+	if admiralParams.FullReconcileQPS <= 0 {
+		return DefaultFullReconcileQPS
+	}
+	return admiralParams.FullReconcileQPS
+}
+
+func GetReconcileDebounce() time.Duration {
+	return admiralParams.ReconcileDebounce
+}
+
+func GetReconcileQPS() float32 {
+	if admiralParams.ReconcileQPS <= 0 {
+		return DefaultReconcileQPS
+	}
+	return admiralParams.ReconcileQPS
+}
+
+func GetReconcileWorkers() int {
+	if admiralParams.ReconcileWorkers <= 0 {
+		return DefaultReconcileWorkers
+	}
+	return admiralParams.ReconcileWorkers
+}
+
+func GetClusterWriteWorkers() int {
+	if admiralParams.ClusterWriteWorkers <= 0 {
+		return DefaultClusterWriteWorkers
+	}
+	return admiralParams.ClusterWriteWorkers
+}
+
+func GetClusterWriteTimeout() time.Duration {
+	if admiralParams.ClusterWriteTimeout <= 0 {
+		return DefaultClusterWriteTimeout
+	}
+	return admiralParams.ClusterWriteTimeout
+}
+
+func GetApiTokenPath() string {
+	return admiralParams.ApiTokenPath
+}
+
+func GetOrphanSweepInterval() time.Duration {
+	return admiralParams.OrphanSweepInterval
+}
+
+func GetOrphanGracePeriod() time.Duration {
+	return admiralParams.OrphanGracePeriod
+}
+
+func GetOrphanSweepDryRun() bool {
+	return admiralParams.OrphanSweepDryRun
+}
+
+func GetDryRun() bool {
+	return admiralParams.DryRun
+}
+
+//returns the maximum number of GTP and dependency record status updates per second, 0 when the status updates are disabled
+func GetStatusUpdateQPS() float32 {
+	if admiralParams.StatusUpdateQPS < 0 {
+		return 0
+	}
+	return admiralParams.StatusUpdateQPS
+}
+
+//returns true if the dependency records naming identities unknown to Admiral are rejected by the webhook instead of admitted with a warning
+func GetRejectUnknownDestinations() bool {
+	return admiralParams.RejectUnknownDestinations
+}
+
+func GetClusterLocalityOverride(clusterID string) string {
+	return admiralParams.ClusterLocalityOverrides[clusterID]
+}
+
+//returns the label selector of the east west gateways of the cluster, app=<gateway_app> unless the cluster has its own selector
+func GetClusterGatewaySelector(clusterID string) string {
+	if selector := admiralParams.ClusterGatewaySelectors[clusterID]; len(selector) > 0 {
+		return selector
+	}
+	return "app=" + admiralParams.LabelSet.GatewayApp
+}
+
 ///Setters - be careful
 
 func SetKubeconfigPath(path string) {
 	admiralParams.KubeconfigPath = path
 }
 
+// for unit test only
+func SetApiTokenPath(path string) {
+	admiralParams.ApiTokenPath = path
+}
+
+// for unit test only
+func SetClusterLocalityOverrides(overrides map[string]string) {
+	admiralParams.ClusterLocalityOverrides = overrides
+}
+
+// for unit test only
+func SetClusterGatewaySelectors(selectors map[string]string) {
+	admiralParams.ClusterGatewaySelectors = selectors
+}
+
+// for unit test only
+func SetClusterWriteTimeout(timeout time.Duration) {
+	admiralParams.ClusterWriteTimeout = timeout
+}
+
+// for unit test only
+func SetDryRun(dryRun bool) {
+	admiralParams.DryRun = dryRun
+}
+
+// for unit test only
+func SetRejectUnknownDestinations(reject bool) {
+	admiralParams.RejectUnknownDestinations = reject
+}
+
 // for unit test only
 func SetEnablePrometheus(value bool) {
 	admiralParams.MetricsEnabled = value
@@ -6,18 +6,53 @@ import (
 )
 
 const (
-	ClustersMonitoredMetricName    = "clusters_monitored"
-	EventsProcessedTotalMetricName = "events_processed_total"
+	ClustersMonitoredMetricName     = "clusters_monitored"
+	EventsProcessedTotalMetricName  = "events_processed_total"
+	StateTransitionsTotalMetricName = "state_transitions_total"
+	LastPromotionTimeMetricName     = "last_promotion_time_seconds"
+	ReadOnlyStateMetricName         = "read_only_state"
+	OrphansFoundMetricName          = "orphaned_objects"
+	OrphansRemovedTotalMetricName   = "orphaned_objects_removed_total"
+	GatewayReadyMetricName          = "gateway_ready"
+	EndpointsSkippedTotalMetricName = "remote_endpoints_skipped_total"
+	ReconcileQueueDepthMetricName   = "identity_reconcile_queue_depth"
+	ReconcileCoalescedMetricName    = "identity_reconcile_coalesced_events_total"
+	ReconcileDurationMetricName     = "identity_reconcile_duration_seconds"
+	ClusterWritesTotalMetricName    = "cluster_writes_total"
+	ClusterWritesSkippedMetricName  = "cluster_writes_skipped_total"
+	DriftDetectedTotalMetricName    = "drift_detected_total"
 
 	AddEventLabelValue    = "add"
 	UpdateEventLabelValue = "update"
 	DeleteEventLabelValue = "delete"
+
+	ReadOnlyStateLabelValue  = "read_only"
+	ReadWriteStateLabelValue = "read_write"
 )
 
 var (
 	metricsOnce          sync.Once
 	RemoteClustersMetric Gauge
 	EventsProcessed      Counter
+
+	AdmiralStateTransitions  Counter
+	AdmiralLastPromotionTime Gauge
+	AdmiralReadOnlyState     Gauge
+
+	OrphansFound   Gauge
+	OrphansRemoved Counter
+
+	GatewayReady     Gauge
+	EndpointsSkipped Counter
+
+	ReconcileQueueDepth Gauge
+	ReconcileCoalesced  Counter
+	ReconcileDuration   Histogram
+
+	ClusterWrites        Counter
+	ClusterWritesSkipped Counter
+
+	DriftDetected Counter
 )
 
 type Gauge interface {
@@ -30,6 +65,11 @@ type Counter interface {
 	Inc()
 }
 
+type Histogram interface {
+	With(labelValues ...string) Histogram
+	Observe(value float64)
+}
+
 /*
 InitializeMetrics depends on AdmiralParams for metrics enablement.
 */
@@ -37,6 +77,19 @@ func InitializeMetrics() {
 	metricsOnce.Do(func() {
 		RemoteClustersMetric = NewGaugeFrom(ClustersMonitoredMetricName, "Gauge for the clusters monitored by Admiral", []string{})
 		EventsProcessed = NewCounterFrom(EventsProcessedTotalMetricName, "Counter for the events processed by Admiral", []string{"cluster", "object_type", "event_type"})
+		AdmiralStateTransitions = NewCounterFrom(StateTransitionsTotalMetricName, "Counter for the read-only/read-write transitions of Admiral, labelled by the state transitioned to and what triggered it", []string{"state", "source"})
+		AdmiralLastPromotionTime = NewGaugeFrom(LastPromotionTimeMetricName, "Unix time of the last transition of Admiral from read-only to read-write", []string{})
+		AdmiralReadOnlyState = NewGaugeFrom(ReadOnlyStateMetricName, "Gauge set to 1 while Admiral is read-only and to 0 while it is read-write", []string{})
+		OrphansFound = NewGaugeFrom(OrphansFoundMetricName, "Gauge for the objects created by Admiral in the sync namespace of a cluster that Admiral doesn't want anymore, found by the last sweep", []string{"cluster", "object_type"})
+		OrphansRemoved = NewCounterFrom(OrphansRemovedTotalMetricName, "Counter for the orphaned objects deleted by the sweep", []string{"cluster", "object_type"})
+		GatewayReady = NewGaugeFrom(GatewayReadyMetricName, "Gauge set to 1 while the east west gateways of a cluster have an address and to 0 while they don't", []string{"cluster"})
+		EndpointsSkipped = NewCounterFrom(EndpointsSkippedTotalMetricName, "Counter for the ServiceEntries generated without the endpoints of a cluster, as the cluster had no east west gateway address", []string{"cluster"})
+		ReconcileQueueDepth = NewGaugeFrom(ReconcileQueueDepthMetricName, "Gauge for the identity/env pairs waiting in the reconcile queue", []string{})
+		ReconcileCoalesced = NewCounterFrom(ReconcileCoalescedMetricName, "Counter for the events coalesced with an identity/env pair already waiting in the reconcile queue", []string{})
+		ReconcileDuration = NewHistogramFrom(ReconcileDurationMetricName, "Histogram for the time taken to reconcile an identity/env pair from the reconcile queue", []string{})
+		ClusterWrites = NewCounterFrom(ClusterWritesTotalMetricName, "Counter for the ServiceEntries, DestinationRules and VirtualServices created and updated by Admiral in a cluster", []string{"cluster", "object_type", "op"})
+		ClusterWritesSkipped = NewCounterFrom(ClusterWritesSkippedMetricName, "Counter for the updates of ServiceEntries, DestinationRules and VirtualServices skipped as the object already had the desired spec", []string{"cluster", "object_type"})
+		DriftDetected = NewCounterFrom(DriftDetectedTotalMetricName, "Counter for the ServiceEntries and DestinationRules generated by Admiral that were found modified or deleted outside of Admiral and reconciled again", []string{"cluster", "object_type", "drift"})
 	})
 }
 
@@ -60,8 +113,19 @@ func NewCounterFrom(name string, help string, labelNames []string) Counter {
 	return &PromCounter{c, labelNames}
 }
 
+func NewHistogramFrom(name string, help string, labelNames []string) Histogram {
+	if !GetMetricsEnabled() {
+		return &NoopHistogram{}
+	}
+	opts := prometheus.HistogramOpts{Name: name, Help: help, Buckets: prometheus.DefBuckets}
+	h := prometheus.NewHistogramVec(opts, labelNames)
+	prometheus.MustRegister(h)
+	return &PromHistogram{h, labelNames}
+}
+
 type NoopGauge struct{}
 type NoopCounter struct{}
+type NoopHistogram struct{}
 
 type PromGauge struct {
 	g   *prometheus.GaugeVec
@@ -73,28 +137,43 @@ type PromCounter struct {
 	lvs []string
 }
 
-func (g *PromGauge) With(labelValues ...string) Gauge {
-	g.lvs = append([]string{}, labelValues...)
+type PromHistogram struct {
+	h   *prometheus.HistogramVec
+	lvs []string
+}
 
-	return g
+//returns a gauge with its own label values, so concurrent callers with different labels don't overwrite each other's
+func (g *PromGauge) With(labelValues ...string) Gauge {
+	return &PromGauge{g.g, append([]string{}, labelValues...)}
 }
 
 func (g *PromGauge) Set(value float64) {
 	g.g.WithLabelValues(g.lvs...).Set(value)
 }
 
+//returns a counter with its own label values, so concurrent callers with different labels don't overwrite each other's
 func (c *PromCounter) With(labelValues ...string) Counter {
-	c.lvs = append([]string{}, labelValues...)
-
-	return c
+	return &PromCounter{c.c, append([]string{}, labelValues...)}
 }
 
 func (c *PromCounter) Inc() {
 	c.c.WithLabelValues(c.lvs...).Inc()
 }
 
+//returns a histogram with its own label values, so concurrent callers with different labels don't overwrite each other's
+func (h *PromHistogram) With(labelValues ...string) Histogram {
+	return &PromHistogram{h.h, append([]string{}, labelValues...)}
+}
+
+func (h *PromHistogram) Observe(value float64) {
+	h.h.WithLabelValues(h.lvs...).Observe(value)
+}
+
 func (g *NoopGauge) Set(float64)          {}
 func (g *NoopGauge) With(...string) Gauge { return g }
 
 func (g *NoopCounter) Inc()                   {}
 func (g *NoopCounter) With(...string) Counter { return g }
+
+func (h *NoopHistogram) Observe(float64)          {}
+func (h *NoopHistogram) With(...string) Histogram { return h }
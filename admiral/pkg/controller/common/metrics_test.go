@@ -3,12 +3,14 @@ package common
 import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"regexp"
 	"strconv"
+	"sync"
 	"testing"
 )
 
@@ -130,3 +132,84 @@ func TestNewCounterFrom(t *testing.T) {
 		})
 	}
 }
+
+func TestNewHistogramFrom(t *testing.T) {
+	type args struct {
+		prom        bool
+		name        string
+		help        string
+		values      []float64
+		labelNames  []string
+		labelValues []string
+	}
+	tc := []struct {
+		name       string
+		args       args
+		wantMetric bool
+		wantCount  int64
+	}{
+		{
+			name:       "Should return a Noop histogram",
+			args:       args{false, "myhistogram", "", []float64{0.5}, []string{}, []string{}},
+			wantMetric: false,
+		},
+		{
+			name:       "Should return a Prometheus histogram",
+			args:       args{true, "myhistogram", "", []float64{0.5, 2}, []string{"l1", "l2"}, []string{"v1", "v2"}},
+			wantMetric: true,
+			wantCount:  2,
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			SetEnablePrometheus(tt.args.prom)
+
+			// exercise metric
+			actual := NewHistogramFrom(tt.args.name, tt.args.help, tt.args.labelNames)
+			for _, value := range tt.args.values {
+				actual.With(tt.args.labelValues...).Observe(value)
+			}
+
+			// query metrics endpoint
+			s := httptest.NewServer(promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{}))
+			defer s.Close()
+
+			// parse response
+			resp, _ := http.Get(s.URL)
+			buf, _ := ioutil.ReadAll(resp.Body)
+			actualString := string(buf)
+
+			// verify
+			if tt.wantMetric {
+				pattern := tt.args.name + `_count{l1="v1",l2="v2"} ([0-9]+)`
+				re := regexp.MustCompile(pattern)
+				s2 := re.FindStringSubmatch(actualString)[1]
+				f, _ := strconv.ParseInt(s2, 0, 64)
+				assert.Equal(t, tt.wantCount, f)
+			}
+			assert.Equal(t, 200, resp.StatusCode)
+		})
+	}
+}
+
+func TestCounterWithConcurrentLabels(t *testing.T) {
+	SetEnablePrometheus(true)
+	counter := NewCounterFrom("myconcurrentcounter", "", []string{"cluster"})
+
+	var wg sync.WaitGroup
+	for _, cluster := range []string{"cluster1", "cluster2"} {
+		wg.Add(1)
+		go func(cluster string) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				counter.With(cluster).Inc()
+			}
+		}(cluster)
+	}
+	wg.Wait()
+
+	vec := counter.(*PromCounter).c
+	assert.Equal(t, float64(100), testutil.ToFloat64(vec.WithLabelValues("cluster1")))
+	assert.Equal(t, float64(100), testutil.ToFloat64(vec.WithLabelValues("cluster2")))
+}
@@ -5,50 +5,20 @@ import (
 	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1"
 	log "github.com/sirupsen/logrus"
 	"sort"
-	"strings"
 )
 
 // GetCname returns cname in the format <env>.<service identity>.global, Ex: stage.Admiral.services.registry.global
 func GetCnameForRollout(rollout *argo.Rollout, identifier string, nameSuffix string) string {
-	var environment = GetEnvForRollout(rollout)
-	alias := GetValueForKeyFromRollout(identifier, rollout)
-	if len(alias) == 0 {
-		log.Warnf("%v label missing on deployment %v in namespace %v. Falling back to annotation to create cname.", identifier, rollout.Name, rollout.Namespace)
-		alias = rollout.Spec.Template.Annotations[identifier]
-	}
-	if len(alias) == 0 {
-		log.Errorf("Unable to get cname for deployment with name %v in namespace %v as it doesn't have the %v annotation", rollout.Name, rollout.Namespace, identifier)
-		return ""
-	}
-	cname := environment + Sep + alias + Sep + nameSuffix
-	if rollout.Spec.Template.Annotations[AdmiralCnameCaseSensitive] == "true" {
-		log.Infof("admiral.io/cname-case-sensitive annotation enabled on rollout with name %v", rollout.Name)
-		return cname
-	}
-	return strings.ToLower(cname)
+	return GetCnameFromPodTemplate("rollout", &rollout.ObjectMeta, &rollout.Spec.Template, identifier, nameSuffix)
 }
 
 // GetSAN returns SAN for a service entry in the format spiffe://<domain>/<identifier>, Ex: spiffe://subdomain.domain.com/Admiral.platform.mesh.server
 func GetSANForRollout(domain string, rollout *argo.Rollout, identifier string) string {
-	identifierVal := GetValueForKeyFromRollout(identifier, rollout)
-	if len(identifierVal) == 0 {
-		log.Errorf("Unable to get SAN for rollout with name %v in namespace %v as it doesn't have the %v annotation or label", rollout.Name, rollout.Namespace, identifier)
-		return ""
-	}
-	if len(domain) > 0 {
-		return SpiffePrefix + domain + Slash + identifierVal
-	} else {
-		return SpiffePrefix + identifierVal
-	}
+	return GetSANFromPodTemplate("rollout", domain, &rollout.ObjectMeta, &rollout.Spec.Template, identifier)
 }
 
 func GetValueForKeyFromRollout(key string, rollout *argo.Rollout) string {
-	value := rollout.Spec.Template.Labels[key]
-	if len(value) == 0 {
-		log.Warnf("%v label missing on deployment %v in namespace %v. Falling back to annotation.", key, rollout.Name, rollout.Namespace)
-		value = rollout.Spec.Template.Annotations[key]
-	}
-	return value
+	return GetValueForKeyFromPodTemplate("rollout", key, &rollout.ObjectMeta, &rollout.Spec.Template)
 }
 
 //Returns the list of rollouts to which this GTP should apply. It is assumed that all inputs already are an identity match
@@ -87,12 +57,7 @@ func MatchRolloutsToGTP(gtp *v1.GlobalTrafficPolicy, rollouts []argo.Rollout) []
 }
 
 func GetRolloutGlobalIdentifier(rollout *argo.Rollout) string {
-	identity := rollout.Spec.Template.Labels[GetWorkloadIdentifier()]
-	if len(identity) == 0 {
-		//TODO can this be removed now? This was for backward compatibility
-		identity = rollout.Spec.Template.Annotations[GetWorkloadIdentifier()]
-	}
-	return identity
+	return GetGlobalIdentifierFromPodTemplate(&rollout.Spec.Template)
 }
 
 //Find the GTP that best matches the rollout.
@@ -158,22 +123,5 @@ func MatchGTPsToRollout(gtpList []v1.GlobalTrafficPolicy, rollout *argo.Rollout)
 }
 
 func GetEnvForRollout(rollout *argo.Rollout) string {
-	var environment = rollout.Spec.Template.Annotations[GetEnvKey()]
-	if len(environment) == 0 {
-		environment = rollout.Spec.Template.Labels[GetEnvKey()]
-	}
-	if len(environment) == 0 {
-		environment = rollout.Spec.Template.Labels[Env]
-	}
-	if len(environment) == 0 {
-		splitNamespace := strings.Split(rollout.Namespace, Dash)
-		if len(splitNamespace) > 1 {
-			environment = splitNamespace[len(splitNamespace)-1]
-		}
-		log.Warnf("Using deprecated approach to deduce env from namespace for rollout, name=%v in namespace=%v", rollout.Name, rollout.Namespace)
-	}
-	if len(environment) == 0 {
-		environment = Default
-	}
-	return environment
+	return GetEnvFromPodTemplate("rollout", &rollout.ObjectMeta, &rollout.Spec.Template)
 }
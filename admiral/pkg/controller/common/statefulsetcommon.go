@@ -0,0 +1,42 @@
+package common
+
+import (
+	"strconv"
+
+	k8sAppsV1 "k8s.io/api/apps/v1"
+)
+
+func GetStatefulSetGlobalIdentifier(statefulSet *k8sAppsV1.StatefulSet) string {
+	return GetGlobalIdentifierFromPodTemplate(&statefulSet.Spec.Template)
+}
+
+// GetCnameForStatefulSet returns cname in the format <env>.<service identity>.global, Ex: stage.Admiral.services.registry.global
+func GetCnameForStatefulSet(statefulSet *k8sAppsV1.StatefulSet, identifier string, nameSuffix string) string {
+	return GetCnameFromPodTemplate("statefulset", &statefulSet.ObjectMeta, &statefulSet.Spec.Template, identifier, nameSuffix)
+}
+
+// GetSANForStatefulSet returns SAN for a service entry in the format spiffe://<domain>/<identifier>, Ex: spiffe://subdomain.domain.com/Admiral.platform.mesh.server
+func GetSANForStatefulSet(domain string, statefulSet *k8sAppsV1.StatefulSet, identifier string) string {
+	return GetSANFromPodTemplate("statefulset", domain, &statefulSet.ObjectMeta, &statefulSet.Spec.Template, identifier)
+}
+
+func GetValueForKeyFromStatefulSet(key string, statefulSet *k8sAppsV1.StatefulSet) string {
+	return GetValueForKeyFromPodTemplate("statefulset", key, &statefulSet.ObjectMeta, &statefulSet.Spec.Template)
+}
+
+func GetEnvForStatefulSet(statefulSet *k8sAppsV1.StatefulSet) string {
+	return GetEnvFromPodTemplate("statefulset", &statefulSet.ObjectMeta, &statefulSet.Spec.Template)
+}
+
+// GetStatefulSetPodNames returns the names of the pods of a statefulset in ordinal order, Ex: mongo-0, mongo-1
+func GetStatefulSetPodNames(statefulSet *k8sAppsV1.StatefulSet) []string {
+	var replicas int32 = 1
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+	podNames := make([]string, 0, replicas)
+	for i := int32(0); i < replicas; i++ {
+		podNames = append(podNames, statefulSet.Name+Dash+strconv.Itoa(int(i)))
+	}
+	return podNames
+}
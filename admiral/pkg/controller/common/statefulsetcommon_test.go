@@ -0,0 +1,122 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetEnvForStatefulSet(t *testing.T) {
+
+	testCases := []struct {
+		name        string
+		statefulSet k8sAppsV1.StatefulSet
+		expected    string
+	}{
+		{
+			name:        "should return default env",
+			statefulSet: k8sAppsV1.StatefulSet{Spec: k8sAppsV1.StatefulSetSpec{Template: corev1.PodTemplateSpec{ObjectMeta: v1.ObjectMeta{Labels: map[string]string{}}}}},
+			expected:    Default,
+		},
+		{
+			name:        "should return valid env from label",
+			statefulSet: k8sAppsV1.StatefulSet{Spec: k8sAppsV1.StatefulSetSpec{Template: corev1.PodTemplateSpec{ObjectMeta: v1.ObjectMeta{Labels: map[string]string{"env": "stage2"}}}}},
+			expected:    "stage2",
+		},
+		{
+			name:        "should return valid env from new env annotation",
+			statefulSet: k8sAppsV1.StatefulSet{Spec: k8sAppsV1.StatefulSetSpec{Template: corev1.PodTemplateSpec{ObjectMeta: v1.ObjectMeta{Annotations: map[string]string{"admiral.io/env": "stage1"}, Labels: map[string]string{"env": "stage2"}}}}},
+			expected:    "stage1",
+		},
+		{
+			name:        "should return env from namespace suffix",
+			statefulSet: k8sAppsV1.StatefulSet{ObjectMeta: v1.ObjectMeta{Namespace: "uswest2-prd"}, Spec: k8sAppsV1.StatefulSetSpec{Template: corev1.PodTemplateSpec{ObjectMeta: v1.ObjectMeta{Labels: map[string]string{}}}}},
+			expected:    "prd",
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			env := GetEnvForStatefulSet(&c.statefulSet)
+			if env != c.expected {
+				t.Errorf("Wanted env: %s, got: %s", c.expected, env)
+			}
+		})
+	}
+}
+
+func TestGetCnameForStatefulSet(t *testing.T) {
+
+	nameSuffix := "global"
+	identifier := "identity"
+	identifierVal := "COMPANY.platform.server"
+
+	testCases := []struct {
+		name        string
+		statefulSet k8sAppsV1.StatefulSet
+		expected    string
+	}{
+		{
+			name:        "should return valid cname (from label)",
+			statefulSet: k8sAppsV1.StatefulSet{Spec: k8sAppsV1.StatefulSetSpec{Template: corev1.PodTemplateSpec{ObjectMeta: v1.ObjectMeta{Labels: map[string]string{identifier: identifierVal, "env": "stage"}}}}},
+			expected:    "stage." + "company.platform.server" + ".global",
+		},
+		{
+			name:        "should return valid cname (from annotation)",
+			statefulSet: k8sAppsV1.StatefulSet{Spec: k8sAppsV1.StatefulSetSpec{Template: corev1.PodTemplateSpec{ObjectMeta: v1.ObjectMeta{Annotations: map[string]string{identifier: identifierVal}, Labels: map[string]string{"env": "stage"}}}}},
+			expected:    "stage." + "company.platform.server" + ".global",
+		},
+		{
+			name:        "should return case sensitive cname when the annotation is set",
+			statefulSet: k8sAppsV1.StatefulSet{Spec: k8sAppsV1.StatefulSetSpec{Template: corev1.PodTemplateSpec{ObjectMeta: v1.ObjectMeta{Annotations: map[string]string{AdmiralCnameCaseSensitive: "true"}, Labels: map[string]string{identifier: identifierVal, "env": "stage"}}}}},
+			expected:    "stage." + identifierVal + ".global",
+		},
+		{
+			name:        "should return empty string",
+			statefulSet: k8sAppsV1.StatefulSet{Spec: k8sAppsV1.StatefulSetSpec{Template: corev1.PodTemplateSpec{ObjectMeta: v1.ObjectMeta{Labels: map[string]string{"env": "stage"}}}}},
+			expected:    "",
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			cname := GetCnameForStatefulSet(&c.statefulSet, identifier, nameSuffix)
+			if cname != c.expected {
+				t.Errorf("Wanted Cname: %s, got: %s", c.expected, cname)
+			}
+		})
+	}
+}
+
+func TestGetStatefulSetPodNames(t *testing.T) {
+	var replicas int32 = 3
+
+	testCases := []struct {
+		name        string
+		statefulSet k8sAppsV1.StatefulSet
+		expected    []string
+	}{
+		{
+			name:        "should default to a single replica",
+			statefulSet: k8sAppsV1.StatefulSet{ObjectMeta: v1.ObjectMeta{Name: "mongo"}},
+			expected:    []string{"mongo-0"},
+		},
+		{
+			name:        "should return one pod name per replica",
+			statefulSet: k8sAppsV1.StatefulSet{ObjectMeta: v1.ObjectMeta{Name: "mongo"}, Spec: k8sAppsV1.StatefulSetSpec{Replicas: &replicas}},
+			expected:    []string{"mongo-0", "mongo-1", "mongo-2"},
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			podNames := GetStatefulSetPodNames(&c.statefulSet)
+			if !reflect.DeepEqual(podNames, c.expected) {
+				t.Errorf("Wanted pod names: %v, got: %v", c.expected, podNames)
+			}
+		})
+	}
+}
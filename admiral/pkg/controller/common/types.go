@@ -30,6 +30,7 @@ type SidecarEgressMap struct {
 
 type AdmiralParams struct {
 	ArgoRolloutsEnabled        bool
+	StatefulSetsEnabled        bool
 	KubeconfigPath             string
 	CacheRefreshDuration       time.Duration
 	ClusterRegistriesNamespace string
@@ -48,6 +49,24 @@ type AdmiralParams struct {
 	AdmiralStateCheckerName    string
 	DRStateStoreConfigPath     string
 	ServiceEntryIPPrefix       string
+	FullReconcileQPS           float32
+	ApiTokenPath               string
+	OrphanSweepInterval        time.Duration
+	OrphanGracePeriod          time.Duration
+	OrphanSweepDryRun          bool
+	ClusterLocalityOverrides   map[string]string
+	ClusterGatewaySelectors    map[string]string
+	ReconcileDebounce          time.Duration
+	ReconcileQPS               float32
+	ReconcileWorkers           int
+	ClusterWriteWorkers        int
+	ClusterWriteTimeout        time.Duration
+	DryRun                     bool
+	StatusUpdateQPS            float32
+	WebhookPort                int
+	WebhookCertPath            string
+	WebhookKeyPath             string
+	RejectUnknownDestinations  bool
 }
 
 func (b AdmiralParams) String() string {
@@ -61,7 +80,25 @@ func (b AdmiralParams) String() string {
 		fmt.Sprintf("SecretResolver=%v ", b.SecretResolver) +
 		fmt.Sprintf("AdmiralStateCheckername=%v ", b.AdmiralStateCheckerName) +
 		fmt.Sprintf("DRStateStoreConfigPath=%v ", b.DRStateStoreConfigPath) +
-		fmt.Sprintf("ServiceEntryIPPrefix=%v ", b.ServiceEntryIPPrefix)
+		fmt.Sprintf("ServiceEntryIPPrefix=%v ", b.ServiceEntryIPPrefix) +
+		fmt.Sprintf("FullReconcileQPS=%v ", b.FullReconcileQPS) +
+		fmt.Sprintf("ApiTokenPath=%v ", b.ApiTokenPath) +
+		fmt.Sprintf("OrphanSweepInterval=%v ", b.OrphanSweepInterval) +
+		fmt.Sprintf("OrphanGracePeriod=%v ", b.OrphanGracePeriod) +
+		fmt.Sprintf("OrphanSweepDryRun=%v ", b.OrphanSweepDryRun) +
+		fmt.Sprintf("ClusterLocalityOverrides=%v ", b.ClusterLocalityOverrides) +
+		fmt.Sprintf("ClusterGatewaySelectors=%v ", b.ClusterGatewaySelectors) +
+		fmt.Sprintf("ReconcileDebounce=%v ", b.ReconcileDebounce) +
+		fmt.Sprintf("ReconcileQPS=%v ", b.ReconcileQPS) +
+		fmt.Sprintf("ReconcileWorkers=%v ", b.ReconcileWorkers) +
+		fmt.Sprintf("ClusterWriteWorkers=%v ", b.ClusterWriteWorkers) +
+		fmt.Sprintf("ClusterWriteTimeout=%v ", b.ClusterWriteTimeout) +
+		fmt.Sprintf("DryRun=%v ", b.DryRun) +
+		fmt.Sprintf("StatusUpdateQPS=%v ", b.StatusUpdateQPS) +
+		fmt.Sprintf("WebhookPort=%v ", b.WebhookPort) +
+		fmt.Sprintf("WebhookCertPath=%v ", b.WebhookCertPath) +
+		fmt.Sprintf("WebhookKeyPath=%v ", b.WebhookKeyPath) +
+		fmt.Sprintf("RejectUnknownDestinations=%v ", b.RejectUnknownDestinations)
 }
 
 type LabelSet struct {
@@ -160,6 +197,26 @@ func (s *MapOfMaps) Delete(key string) {
 	delete(s.cache, key)
 }
 
+// DeleteMap deletes key from the map of pkey, the map of pkey is deleted when it becomes empty
+func (s *MapOfMaps) DeleteMap(pkey string, key string) {
+	defer s.mutex.Unlock()
+	s.mutex.Lock()
+	var mapVal = s.cache[pkey]
+	if mapVal == nil {
+		return
+	}
+	mapVal.Delete(key)
+	if len(mapVal.cache) == 0 {
+		delete(s.cache, pkey)
+	}
+}
+
+func (s *MapOfMaps) Len() int {
+	defer s.mutex.Unlock()
+	s.mutex.Lock()
+	return len(s.cache)
+}
+
 func (s *MapOfMaps) Map() map[string]*Map {
 	return s.cache
 }
@@ -195,6 +252,20 @@ func (s *SidecarEgressMap) Delete(key string) {
 	delete(s.cache, key)
 }
 
+// DeleteNamespace deletes the SidecarEgress of the namespace from the map of the identity
+func (s *SidecarEgressMap) DeleteNamespace(identity string, namespace string) {
+	defer s.mutex.Unlock()
+	s.mutex.Lock()
+	var mapVal = s.cache[identity]
+	if mapVal == nil {
+		return
+	}
+	delete(mapVal, namespace)
+	if len(mapVal) == 0 {
+		delete(s.cache, identity)
+	}
+}
+
 // Map func returns a map of identity to namespace:SidecarEgress map
 // Iterating through the returned map is not implicitly thread safe,
 // use (s *SidecarEgressMap) Range() func instead.
@@ -39,6 +39,10 @@ func TestMapOfMaps(t *testing.T) {
 		t.Fail()
 	}
 
+	if mapOfMaps.Len() != 4 {
+		t.Fail()
+	}
+
 	mapOfMaps.Put("pkey4", "prod.a.global", "127.0.10.1")
 
 	mapOfMaps.Delete("pkey2")
@@ -46,6 +50,12 @@ func TestMapOfMaps(t *testing.T) {
 	if map3 != nil {
 		t.Fail()
 	}
+
+	mapOfMaps.DeleteMap("pkey1", "dev.a.global1")
+	if mapOfMaps.Get("pkey1") != nil {
+		t.Errorf("DeleteMap should delete the map of pkey1 once it is empty")
+	}
+	mapOfMaps.DeleteMap("pkey5", "dev.a.global1")
 }
 
 func TestEgressMap(t *testing.T) {
@@ -74,6 +84,12 @@ func TestEgressMap(t *testing.T) {
 		t.FailNow()
 	}
 
+	egressMap.Put(payments, ordersNs, ordersFqdn, map[string]string{ordersCname: ordersCname})
+	egressMap.DeleteNamespace(payments, ordersNs)
+	if _, ok := egressMap.Get(payments)[ordersNs]; ok || len(egressMap.Get(payments)) != 1 {
+		t.Errorf("DeleteNamespace should only delete the %v namespace, got %v", ordersNs, egressMap.Get(payments))
+	}
+
 	egressMapForIter := egressMap.Map()
 
 	if len(egressMapForIter) != 1 {
@@ -0,0 +1,81 @@
+package common
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	k8sV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//The helpers below are shared by every workload kind (deployment, rollout, statefulset) as they only read the workload metadata and its pod template.
+//kind is only used for logging
+
+func GetGlobalIdentifierFromPodTemplate(template *k8sV1.PodTemplateSpec) string {
+	identity := template.Labels[GetWorkloadIdentifier()]
+	if len(identity) == 0 {
+		//TODO can this be removed now? This was for backward compatibility
+		identity = template.Annotations[GetWorkloadIdentifier()]
+	}
+	return identity
+}
+
+// GetCnameFromPodTemplate returns cname in the format <env>.<service identity>.global, Ex: stage.Admiral.services.registry.global
+func GetCnameFromPodTemplate(kind string, meta *metaV1.ObjectMeta, template *k8sV1.PodTemplateSpec, identifier string, nameSuffix string) string {
+	var environment = GetEnvFromPodTemplate(kind, meta, template)
+	alias := GetValueForKeyFromPodTemplate(kind, identifier, meta, template)
+	if len(alias) == 0 {
+		log.Errorf("Unable to get cname for %v with name %v in namespace %v as it doesn't have the %v annotation", kind, meta.Name, meta.Namespace, identifier)
+		return ""
+	}
+	cname := GetCnameVal([]string{environment, alias, nameSuffix})
+	if template.Annotations[AdmiralCnameCaseSensitive] == "true" {
+		log.Infof("admiral.io/cname-case-sensitive annotation enabled on %v with name %v", kind, meta.Name)
+		return cname
+	}
+	return strings.ToLower(cname)
+}
+
+// GetSANFromPodTemplate returns SAN for a service entry in the format spiffe://<domain>/<identifier>, Ex: spiffe://subdomain.domain.com/Admiral.platform.mesh.server
+func GetSANFromPodTemplate(kind string, domain string, meta *metaV1.ObjectMeta, template *k8sV1.PodTemplateSpec, identifier string) string {
+	identifierVal := GetValueForKeyFromPodTemplate(kind, identifier, meta, template)
+	if len(identifierVal) == 0 {
+		log.Errorf("Unable to get SAN for %v with name %v in namespace %v as it doesn't have the %v annotation or label", kind, meta.Name, meta.Namespace, identifier)
+		return ""
+	}
+	if len(domain) > 0 {
+		return SpiffePrefix + domain + Slash + identifierVal
+	} else {
+		return SpiffePrefix + identifierVal
+	}
+}
+
+func GetValueForKeyFromPodTemplate(kind string, key string, meta *metaV1.ObjectMeta, template *k8sV1.PodTemplateSpec) string {
+	value := template.Labels[key]
+	if len(value) == 0 {
+		log.Warnf("%v label missing on %v %v in namespace %v. Falling back to annotation.", key, kind, meta.Name, meta.Namespace)
+		value = template.Annotations[key]
+	}
+	return value
+}
+
+func GetEnvFromPodTemplate(kind string, meta *metaV1.ObjectMeta, template *k8sV1.PodTemplateSpec) string {
+	var environment = template.Annotations[GetEnvKey()]
+	if len(environment) == 0 {
+		environment = template.Labels[GetEnvKey()]
+	}
+	if len(environment) == 0 {
+		environment = template.Labels[Env]
+	}
+	if len(environment) == 0 {
+		splitNamespace := strings.Split(meta.Namespace, Dash)
+		if len(splitNamespace) > 1 {
+			environment = splitNamespace[len(splitNamespace)-1]
+		}
+		log.Warnf("Using deprecated approach to deduce env from namespace for %v, name=%v in namespace=%v", kind, meta.Name, meta.Namespace)
+	}
+	if len(environment) == 0 {
+		environment = Default
+	}
+	return environment
+}
@@ -0,0 +1,15 @@
+package istio
+
+import (
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	versioned "istio.io/client-go/pkg/clientset/versioned"
+	"k8s.io/client-go/rest"
+)
+
+//returns the client the istio objects are read and written with. A call to a cluster whose API server doesn't answer fails after --cluster_write_timeout,
+//so it doesn't outlive the write it's part of. The informers use a client without timeout, their watches are long running calls
+func newWriteClient(config *rest.Config) (versioned.Interface, error) {
+	writeConfig := rest.CopyConfig(config)
+	writeConfig.Timeout = common.GetClusterWriteTimeout()
+	return versioned.NewForConfig(writeConfig)
+}
@@ -9,6 +9,9 @@ import (
 	versioned "istio.io/client-go/pkg/clientset/versioned"
 	informers "istio.io/client-go/pkg/informers/externalversions/networking/v1alpha3"
 	k8sV1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 )
@@ -43,7 +46,10 @@ func NewServiceEntryController(clusterID string, stopCh <-chan struct{}, handler
 		return nil, fmt.Errorf("failed to create service entry k8s client: %v", err)
 	}
 
-	seController.IstioClient = ic
+	seController.IstioClient, err = newWriteClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create service entry k8s client: %v", err)
+	}
 
 	seController.informer = informers.NewServiceEntryInformer(ic, k8sV1.NamespaceAll, resyncPeriod, cache.Indexers{})
 
@@ -67,3 +73,39 @@ func (sec *ServiceEntryController) Deleted(ojb interface{}) {
 	se := ojb.(*networking.ServiceEntry)
 	sec.ServiceEntryHandler.Deleted(se)
 }
+
+//returns a copy of the ServiceEntry from the informer cache, the API server is queried until the cache is synced
+func (sec *ServiceEntryController) Get(namespace string, name string) (*networking.ServiceEntry, error) {
+	if sec.informer == nil || !sec.informer.HasSynced() {
+		return sec.IstioClient.NetworkingV1alpha3().ServiceEntries(namespace).Get(name, metaV1.GetOptions{})
+	}
+	obj, exists, err := sec.informer.GetIndexer().GetByKey(namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, k8sErrors.NewNotFound(networking.Resource("serviceentries"), name)
+	}
+	return obj.(*networking.ServiceEntry).DeepCopy(), nil
+}
+
+//returns the ServiceEntries of the namespace, of every namespace when it's empty, from the informer cache, the API server is queried until the cache is synced.
+//The returned objects are shared with the informer cache and must not be modified.
+func (sec *ServiceEntryController) List(namespace string) ([]*networking.ServiceEntry, error) {
+	if sec.informer == nil || !sec.informer.HasSynced() {
+		list, err := sec.IstioClient.NetworkingV1alpha3().ServiceEntries(namespace).List(metaV1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		items := make([]*networking.ServiceEntry, 0, len(list.Items))
+		for i := range list.Items {
+			items = append(items, &list.Items[i])
+		}
+		return items, nil
+	}
+	items := make([]*networking.ServiceEntry, 0)
+	err := cache.ListAllByNamespace(sec.informer.GetIndexer(), namespace, labels.Everything(), func(obj interface{}) {
+		items = append(items, obj.(*networking.ServiceEntry))
+	})
+	return items, err
+}
@@ -5,7 +5,12 @@ import (
 	"github.com/istio-ecosystem/admiral/admiral/pkg/test"
 	"istio.io/api/networking/v1alpha3"
 	v1alpha32 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
+	informers "istio.io/client-go/pkg/informers/externalversions/networking/v1alpha3"
+	k8sV1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 	"testing"
 	"time"
@@ -50,3 +55,64 @@ func TestNewServiceEntryController(t *testing.T) {
 		t.Errorf("Handler should have no obj")
 	}
 }
+
+func TestServiceEntryControllerGet(t *testing.T) {
+	fakeIstioClient := istiofake.NewSimpleClientset()
+	fakeIstioClient.NetworkingV1alpha3().ServiceEntries("ns").Create(&v1alpha32.ServiceEntry{ObjectMeta: v1.ObjectMeta{Name: "se1", Namespace: "ns"}})
+	serviceEntryController := ServiceEntryController{IstioClient: fakeIstioClient}
+
+	//without an informer the API server is queried
+	if se, err := serviceEntryController.Get("ns", "se1"); err != nil || se.Name != "se1" {
+		t.Errorf("Wanted se1 from the API server, got %v %v", se, err)
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	serviceEntryController.informer = informers.NewServiceEntryInformer(fakeIstioClient, k8sV1.NamespaceAll, 0, cache.Indexers{})
+	go serviceEntryController.informer.Run(stop)
+	if !cache.WaitForCacheSync(stop, serviceEntryController.informer.HasSynced) {
+		t.Fatalf("Timed out waiting for the informer to sync")
+	}
+	serviceEntryController.informer.GetIndexer().Add(&v1alpha32.ServiceEntry{ObjectMeta: v1.ObjectMeta{Name: "se2", Namespace: "ns"}})
+
+	se, err := serviceEntryController.Get("ns", "se2")
+	if err != nil || se.Name != "se2" {
+		t.Errorf("Wanted se2 from the informer cache, got %v %v", se, err)
+	}
+	//the cached object is not shared with the caller
+	se.Labels = map[string]string{"identity": "bar"}
+	if cached, _ := serviceEntryController.Get("ns", "se2"); cached.Labels != nil {
+		t.Errorf("Wanted a copy of the cached object, got %v", cached)
+	}
+	if _, err := serviceEntryController.Get("ns", "se3"); !k8sErrors.IsNotFound(err) {
+		t.Errorf("Wanted a not found error, got %v", err)
+	}
+}
+
+func TestServiceEntryControllerList(t *testing.T) {
+	fakeIstioClient := istiofake.NewSimpleClientset()
+	fakeIstioClient.NetworkingV1alpha3().ServiceEntries("ns").Create(&v1alpha32.ServiceEntry{ObjectMeta: v1.ObjectMeta{Name: "se1", Namespace: "ns"}})
+	fakeIstioClient.NetworkingV1alpha3().ServiceEntries("ns2").Create(&v1alpha32.ServiceEntry{ObjectMeta: v1.ObjectMeta{Name: "se2", Namespace: "ns2"}})
+	serviceEntryController := ServiceEntryController{IstioClient: fakeIstioClient}
+
+	//without an informer the API server is queried
+	if ses, err := serviceEntryController.List("ns"); err != nil || len(ses) != 1 || ses[0].Name != "se1" {
+		t.Errorf("Wanted se1 from the API server, got %v %v", ses, err)
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	serviceEntryController.informer = informers.NewServiceEntryInformer(fakeIstioClient, k8sV1.NamespaceAll, 0, cache.Indexers{})
+	go serviceEntryController.informer.Run(stop)
+	if !cache.WaitForCacheSync(stop, serviceEntryController.informer.HasSynced) {
+		t.Fatalf("Timed out waiting for the informer to sync")
+	}
+	serviceEntryController.informer.GetIndexer().Add(&v1alpha32.ServiceEntry{ObjectMeta: v1.ObjectMeta{Name: "se3", Namespace: "ns"}})
+
+	if ses, err := serviceEntryController.List("ns"); err != nil || len(ses) != 2 {
+		t.Errorf("Wanted se1 and se3 from the informer cache, got %v %v", ses, err)
+	}
+	if ses, err := serviceEntryController.List(""); err != nil || len(ses) != 3 {
+		t.Errorf("Wanted the ServiceEntries of every namespace, got %v %v", ses, err)
+	}
+}
@@ -43,7 +43,10 @@ func NewSidecarController(clusterID string, stopCh <-chan struct{}, handler Side
 		return nil, fmt.Errorf("failed to create sidecar controller k8s client: %v", err)
 	}
 
-	sidecarController.IstioClient = ic
+	sidecarController.IstioClient, err = newWriteClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sidecar controller k8s client: %v", err)
+	}
 
 	sidecarController.informer = informers.NewSidecarInformer(ic, k8sV1.NamespaceAll, resyncPeriod, cache.Indexers{})
 
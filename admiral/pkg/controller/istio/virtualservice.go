@@ -9,6 +9,9 @@ import (
 	"istio.io/client-go/pkg/clientset/versioned"
 	informers "istio.io/client-go/pkg/informers/externalversions/networking/v1alpha3"
 	k8sV1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 )
@@ -38,7 +41,10 @@ func NewVirtualServiceController(clusterID string, stopCh <-chan struct{}, handl
 		return nil, fmt.Errorf("failed to create virtual service controller k8s client: %v", err)
 	}
 
-	drController.IstioClient = ic
+	drController.IstioClient, err = newWriteClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create virtual service controller k8s client: %v", err)
+	}
 
 	drController.informer = informers.NewVirtualServiceInformer(ic, k8sV1.NamespaceAll, resyncPeriod, cache.Indexers{})
 
@@ -63,3 +69,39 @@ func (sec *VirtualServiceController) Deleted(ojb interface{}) {
 	sec.VirtualServiceHandler.Deleted(dr)
 
 }
+
+//returns a copy of the VirtualService from the informer cache, the API server is queried until the cache is synced
+func (sec *VirtualServiceController) Get(namespace string, name string) (*networking.VirtualService, error) {
+	if sec.informer == nil || !sec.informer.HasSynced() {
+		return sec.IstioClient.NetworkingV1alpha3().VirtualServices(namespace).Get(name, metaV1.GetOptions{})
+	}
+	obj, exists, err := sec.informer.GetIndexer().GetByKey(namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, k8sErrors.NewNotFound(networking.Resource("virtualservices"), name)
+	}
+	return obj.(*networking.VirtualService).DeepCopy(), nil
+}
+
+//returns the VirtualServices of the namespace, of every namespace when it's empty, from the informer cache, the API server is queried until the cache is synced.
+//The returned objects are shared with the informer cache and must not be modified.
+func (sec *VirtualServiceController) List(namespace string) ([]*networking.VirtualService, error) {
+	if sec.informer == nil || !sec.informer.HasSynced() {
+		list, err := sec.IstioClient.NetworkingV1alpha3().VirtualServices(namespace).List(metaV1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		items := make([]*networking.VirtualService, 0, len(list.Items))
+		for i := range list.Items {
+			items = append(items, &list.Items[i])
+		}
+		return items, nil
+	}
+	items := make([]*networking.VirtualService, 0)
+	err := cache.ListAllByNamespace(sec.informer.GetIndexer(), namespace, labels.Everything(), func(obj interface{}) {
+		items = append(items, obj.(*networking.VirtualService))
+	})
+	return items, err
+}
@@ -26,16 +26,28 @@ func (m *MockIstioConfigStore) Delete(typ, name, namespace string) error {
 }
 
 type MockDeploymentHandler struct {
+	Obj *k8sAppsV1.Deployment
 }
 
 func (m *MockDeploymentHandler) Added(obj *k8sAppsV1.Deployment) {
-
+	m.Obj = obj
 }
 
 func (m *MockDeploymentHandler) Deleted(obj *k8sAppsV1.Deployment) {
 
 }
 
+type MockStatefulSetHandler struct {
+}
+
+func (m *MockStatefulSetHandler) Added(obj *k8sAppsV1.StatefulSet) {
+
+}
+
+func (m *MockStatefulSetHandler) Deleted(obj *k8sAppsV1.StatefulSet) {
+
+}
+
 type MockRolloutHandler struct {
 }
 
@@ -78,7 +90,8 @@ func (m MockPodHandler) Deleted(obj *k8sCoreV1.Pod) {
 }
 
 type MockNodeHandler struct {
-	Obj *k8sCoreV1.Node
+	Obj        *k8sCoreV1.Node
+	Localities []string
 }
 
 func (m *MockNodeHandler) Added(obj *k8sCoreV1.Node) {
@@ -89,6 +102,10 @@ func (m *MockNodeHandler) Deleted(obj *k8sCoreV1.Node) {
 	m.Obj = nil
 }
 
+func (m *MockNodeHandler) LocalityChanged(previous string, current string) {
+	m.Localities = append(m.Localities, current)
+}
+
 type MockDependencyHandler struct {
 }
 
@@ -96,7 +113,7 @@ func (m *MockDependencyHandler) Added(obj *v1.Dependency) {
 
 }
 
-func (m *MockDependencyHandler) Updated(obj *v1.Dependency) {
+func (m *MockDependencyHandler) Updated(obj *v1.Dependency, oldObj *v1.Dependency) {
 
 }
 